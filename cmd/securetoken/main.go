@@ -0,0 +1,121 @@
+// Command securetoken is a tool for working with securetoken.
+//
+// Usage:
+//
+//	securetoken bench [-duration d] [-sizes n,n,...]
+//
+// The bench command reports the token size and the seals and unseals per
+// second of each supported AEAD for each payload size on the current
+// hardware, as JSON on stdout, so that reports can be tracked across releases.
+package main
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nicksnyder/go-securetoken/securetoken"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	switch os.Args[1] {
+	case "bench":
+		if err := bench(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, "securetoken bench:", err)
+			os.Exit(1)
+		}
+	default:
+		usage()
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: securetoken bench [-duration d] [-sizes n,n,...]")
+	os.Exit(2)
+}
+
+func bench(args []string) error {
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	duration := fs.Duration("duration", time.Second, "how long to measure each case and size")
+	sizes := fs.String("sizes", "16,256,4096", "comma separated payload sizes in bytes")
+	fs.Parse(args)
+
+	var payloadSizes []int
+	for _, s := range strings.Split(*sizes, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid payload size %q", s)
+		}
+		payloadSizes = append(payloadSizes, n)
+	}
+	cases, err := benchCases()
+	if err != nil {
+		return err
+	}
+	report, err := securetoken.Bench(cases, payloadSizes, *duration)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+// benchCases returns a Tokener with a random key for each supported AEAD,
+// token version and encoding.
+func benchCases() ([]securetoken.BenchCase, error) {
+	var cases []securetoken.BenchCase
+	for _, size := range []int{16, 32} {
+		key := make([]byte, size)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+		tok, err := securetoken.NewTokener(key, time.Hour)
+		if err != nil {
+			return nil, err
+		}
+		cases = append(cases, securetoken.BenchCase{Name: fmt.Sprintf("aes-%d-gcm", size*8), Tokener: tok})
+	}
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	kr := securetoken.NewKeyring()
+	if err := kr.AddKey(1, key); err != nil {
+		return nil, err
+	}
+	tok, err := securetoken.NewKeyringTokener(kr, time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	cases = append(cases, securetoken.BenchCase{Name: "aes-256-gcm-keyring", Tokener: tok})
+
+	for _, e := range []struct {
+		name string
+		enc  securetoken.Encoding
+	}{
+		{"base58", securetoken.Base58},
+		{"crockford32", securetoken.Crockford32},
+		{"base45", securetoken.Base45},
+	} {
+		tok, err := securetoken.NewTokener(key, time.Hour, securetoken.WithEncoding(e.enc))
+		if err != nil {
+			return nil, err
+		}
+		cases = append(cases, securetoken.BenchCase{Name: "aes-256-gcm-" + e.name, Tokener: tok})
+	}
+
+	auto, err := securetoken.NewTokener(key, time.Hour, securetoken.WithAutoAEAD())
+	if err != nil {
+		return nil, err
+	}
+	return append(cases, securetoken.BenchCase{Name: "auto", Tokener: auto}), nil
+}
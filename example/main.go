@@ -1,16 +1,19 @@
 package main
 
 import (
+	"crypto/rand"
 	"html/template"
 	"log"
 	"net/http"
 	"time"
 
 	"github.com/nicksnyder/go-securetoken/securetoken"
+	"github.com/nicksnyder/go-securetoken/securetoken/httptoken"
 )
 
-var unsafeKey = []byte("1234567887654321")
 var tokener *securetoken.Tokener
+var csrf *httptoken.CSRF
+var homeTemplate *template.Template
 var cookieName = "session"
 
 func main() {
@@ -18,17 +21,25 @@ func main() {
 	http.HandleFunc("/login", handleLogin)
 	http.HandleFunc("/logout", handleLogout)
 
-	var err error
-	tokener, err = securetoken.NewTokener(unsafeKey, 24*time.Hour)
+	// A real application loads its key from configuration so that
+	// sessions survive restarts. The key must come from crypto/rand.
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic(err)
+	}
+	tokener = securetoken.MustNewTokener(key, 24*time.Hour, securetoken.WithKeyCheck())
+	csrfTokener, err := tokener.Clone(securetoken.WithPurpose(httptoken.PurposeCSRF))
 	if err != nil {
 		panic(err)
 	}
+	csrf = &httptoken.CSRF{Tokener: csrfTokener.WithTTL(time.Hour)}
+	homeTemplate = template.Must(template.New("").Funcs(httptoken.FuncMap(tokener, csrf)).Parse(homeHTML))
 
 	log.Println("Demo running at http://localhost:8080")
 	log.Fatal(http.ListenAndServe(":8080", nil))
 }
 
-var homeTemplate = template.Must(template.New("").Parse(`
+const homeHTML = `
 <!DOCTYPE html>
 <html>
 	<head></head>
@@ -37,6 +48,7 @@ var homeTemplate = template.Must(template.New("").Parse(`
 			<p>Token: {{.Token}}</p>
 			<p>You are signed in as {{.Email}}</p>
 			<form action="logout" method="POST">
+				{{csrfField .Token}}
 				<input type="submit" value="Logout"/>
 			</form>
 		{{else}}
@@ -47,7 +59,7 @@ var homeTemplate = template.Must(template.New("").Parse(`
 		{{end}}
 	</body>
 </html>
-`))
+`
 
 func handleHome(w http.ResponseWriter, r *http.Request) {
 	c, err := r.Cookie(cookieName)
@@ -57,7 +69,15 @@ func handleHome(w http.ResponseWriter, r *http.Request) {
 	}
 	email, err := tokener.UnsealString(c.Value)
 	if err != nil {
-		panic(err)
+		// An expired or tampered cookie just means the user is signed out.
+		log.Printf("unseal session cookie: %v", err)
+		http.SetCookie(w, &http.Cookie{
+			Name:     cookieName,
+			Expires:  time.Unix(1, 0),
+			HttpOnly: true,
+		})
+		homeTemplate.Execute(w, nil)
+		return
 	}
 	homeTemplate.Execute(w, map[string]string{
 		"Token": c.Value,
@@ -80,6 +100,11 @@ func handleLogin(w http.ResponseWriter, r *http.Request) {
 }
 
 func handleLogout(w http.ResponseWriter, r *http.Request) {
+	c, err := r.Cookie(cookieName)
+	if err != nil || csrf.Check(r, c.Value) != nil {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
 	http.SetCookie(w, &http.Cookie{
 		Name:     cookieName,
 		Expires:  time.Unix(1, 0),
@@ -0,0 +1,52 @@
+package securetoken
+
+import "sync"
+
+// adCacheSize bounds the number of entries of an adCache. Rekeying
+// keyrings add a header every period, so the cache is emptied when full.
+const adCacheSize = 64
+
+// An adCache holds the additional data built for each header and purpose,
+// so that tokens sealed and unsealed with the same key and purpose do not
+// build it again. Clones share the cache of their Tokener, so Tokeners
+// that differ in purpose, or tokens of several keys, each keep an entry.
+type adCache struct {
+	mu      sync.RWMutex
+	entries map[adKey][]byte
+}
+
+// adKey identifies the additional data of a header and purpose.
+type adKey struct {
+	header  [1 + KeyIDLength]byte
+	n       int
+	purpose string
+}
+
+// get returns header followed by purpose. The result must not be modified.
+func (c *adCache) get(header []byte, purpose string) []byte {
+	if len(header) > len(adKey{}.header) {
+		return buildAD(header, purpose)
+	}
+	k := adKey{n: len(header), purpose: purpose}
+	copy(k.header[:], header)
+	c.mu.RLock()
+	ad, ok := c.entries[k]
+	c.mu.RUnlock()
+	if ok {
+		return ad
+	}
+	ad = buildAD(header, purpose)
+	c.mu.Lock()
+	if c.entries == nil || len(c.entries) >= adCacheSize {
+		c.entries = make(map[adKey][]byte)
+	}
+	c.entries[k] = ad
+	c.mu.Unlock()
+	return ad
+}
+
+func buildAD(header []byte, purpose string) []byte {
+	ad := make([]byte, 0, len(header)+len(purpose))
+	ad = append(ad, header...)
+	return append(ad, purpose...)
+}
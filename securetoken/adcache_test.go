@@ -0,0 +1,44 @@
+package securetoken
+
+import "testing"
+
+func TestAdditionalDataCache(t *testing.T) {
+	tok, err := NewTokener(key, ttl, WithPurpose("csrf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := tok.Seal([]byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := tok.Clone(WithPurpose("session"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Alternate between the purposes, which share the cache.
+	for i := 0; i < 2; i++ {
+		if p, err := tok.Unseal(sealed); string(p) != "hello" || err != nil {
+			t.Errorf("Unseal(%q) = %q, %v; expected %q, <nil>", sealed, p, err, "hello")
+		}
+		if p, err := other.Unseal(sealed); p != nil || err != ErrTokenInvalid {
+			t.Errorf("Unseal(%q) with another purpose = %q, %v; expected <nil>, %s", sealed, p, err, ErrTokenInvalid)
+		}
+	}
+
+	// Both purposes stay cached.
+	header := []byte{Version1}
+	if allocs := testing.AllocsPerRun(100, func() {
+		tok.additionalData(Version2, header, nil)
+		other.additionalData(Version2, header, nil)
+	}); allocs != 0 {
+		t.Errorf("additionalData() allocated %v times; expected the cached additional data", allocs)
+	}
+
+	// The cache is bounded.
+	for id := 0; id < 2*adCacheSize; id++ {
+		tok.additionalData(Version2, []byte{Version2, 0, 0, 0, byte(id)}, nil)
+	}
+	if n := len(tok.adCache.entries); n > adCacheSize {
+		t.Errorf("the cache has %d entries; expected at most %d", n, adCacheSize)
+	}
+}
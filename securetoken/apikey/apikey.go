@@ -0,0 +1,136 @@
+// Package apikey implements long-lived API keys of the form
+//
+//	<prefix>_<id>_<secret><checksum>
+//
+// such as "acme_3f9c2a7be01d4c55_AgAAAAEx...1a2b3c4d".
+//
+// The prefix names the issuer so that leaked keys are easy to recognize
+// and scan for. The id is public and random; applications store it to
+// look up and revoke keys. The secret is a token sealed by a
+// securetoken.Tokener that binds the id to its subject, so a key cannot be
+// forged or have its id changed. The checksum is a CRC-32 of the rest of
+// the key that clients can check with Valid to catch typos and truncation
+// without contacting the server.
+package apikey
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"strings"
+
+	"github.com/nicksnyder/go-securetoken/securetoken"
+)
+
+// Purpose is the purpose that Tokeners of API keys should be created
+// with (see securetoken.WithPurpose).
+const Purpose = "securetoken.apikey"
+
+const (
+	idLength       = 16 // hex digits
+	checksumLength = 8  // hex digits
+)
+
+// ErrMalformed is returned by Parse and Verify when a key does not have
+// the shape of an API key, has the wrong prefix, or fails its checksum.
+var ErrMalformed = errors.New("apikey: malformed key")
+
+// A Key is a verified API key.
+type Key struct {
+	// ID is the public lookup id of the key.
+	ID string
+
+	// Subject is the principal that the key was issued to.
+	Subject string
+}
+
+// A Manager issues and verifies API keys.
+type Manager struct {
+	// Prefix identifies keys of this Manager, e.g. "acme" or "acme_live".
+	Prefix string
+
+	// Tokener seals the secret part of keys. Its ttl is the longest
+	// that a key can be used, so it is typically very long; use the id
+	// to revoke keys earlier.
+	Tokener securetoken.SealUnsealer
+}
+
+type payload struct {
+	ID      string `json:"id"`
+	Subject string `json:"sub"`
+}
+
+// Issue returns a new API key for subject together with its id,
+// which the caller should store to be able to list and revoke the key.
+// The key itself should be shown to the user once and never stored.
+func (m *Manager) Issue(subject string) (key string, id string, err error) {
+	buf := make([]byte, idLength/2)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	id = hex.EncodeToString(buf)
+	p, err := json.Marshal(payload{ID: id, Subject: subject})
+	if err != nil {
+		return "", "", err
+	}
+	secret, err := m.Tokener.Seal(p)
+	if err != nil {
+		return "", "", err
+	}
+	body := m.Prefix + "_" + id + "_" + string(secret)
+	return body + checksum(body), id, nil
+}
+
+// Parse returns the id of key without verifying its secret part.
+// It is cheap enough to use for looking up the key before calling Verify.
+func (m *Manager) Parse(key string) (id string, err error) {
+	id, _, err = m.split(key)
+	return id, err
+}
+
+// Verify returns the id and subject of key.
+// The caller must still check that the key has not been revoked.
+func (m *Manager) Verify(key string) (*Key, error) {
+	id, secret, err := m.split(key)
+	if err != nil {
+		return nil, err
+	}
+	buf, err := m.Tokener.Unseal([]byte(secret))
+	if err != nil {
+		return nil, err
+	}
+	p := &payload{}
+	if err := json.Unmarshal(buf, p); err != nil || p.ID != id {
+		return nil, securetoken.ErrTokenInvalid
+	}
+	return &Key{ID: p.ID, Subject: p.Subject}, nil
+}
+
+// split returns the id and secret of key.
+func (m *Manager) split(key string) (id, secret string, err error) {
+	if !Valid(key) || !strings.HasPrefix(key, m.Prefix+"_") {
+		return "", "", ErrMalformed
+	}
+	rest := key[len(m.Prefix)+1 : len(key)-checksumLength]
+	if len(rest) < idLength+1 || rest[idLength] != '_' {
+		return "", "", ErrMalformed
+	}
+	return rest[:idLength], rest[idLength+1:], nil
+}
+
+// Valid reports whether key has the shape of an API key and a correct checksum.
+// It does not need any key material, so clients can use it to detect typos.
+func Valid(key string) bool {
+	if len(key) <= checksumLength {
+		return false
+	}
+	body, sum := key[:len(key)-checksumLength], key[len(key)-checksumLength:]
+	return strings.Count(body, "_") >= 2 && checksum(body) == sum
+}
+
+func checksum(body string) string {
+	return fmt.Sprintf("%08x", crc32.ChecksumIEEE([]byte(body)))
+}
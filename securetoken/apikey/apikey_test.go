@@ -0,0 +1,63 @@
+package apikey
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nicksnyder/go-securetoken/securetoken"
+	"github.com/nicksnyder/go-securetoken/securetoken/securetokentest"
+)
+
+func newManager(t *testing.T) *Manager {
+	return &Manager{Prefix: "acme", Tokener: securetokentest.NewTokener(t, securetoken.WithPurpose(Purpose))}
+}
+
+func TestIssueVerify(t *testing.T) {
+	m := newManager(t)
+	key, id, err := m.Issue("org-42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(key, "acme_"+id+"_") || !Valid(key) {
+		t.Errorf("Issue() = %q, %q; expected a valid key with prefix acme and id %[2]q", key, id)
+	}
+	if got, err := m.Parse(key); got != id || err != nil {
+		t.Errorf("Parse(%q) = %q, %v; expected %q, <nil>", key, got, err, id)
+	}
+	k, err := m.Verify(key)
+	if err != nil || k.ID != id || k.Subject != "org-42" {
+		t.Errorf("Verify(%q) = %+v, %v; expected id %q and subject org-42", key, k, err, id)
+	}
+}
+
+func TestVerifyRejects(t *testing.T) {
+	m := newManager(t)
+	key, id, err := m.Issue("org-42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, _, err := m.Issue("org-43")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A key whose id was swapped, with a recomputed checksum.
+	body := strings.Replace(other[:len(other)-checksumLength], other[5:5+idLength], id, 1)
+	swapped := body + checksum(body)
+
+	tests := []struct {
+		key string
+		err error
+	}{
+		{"", ErrMalformed},
+		{key[:len(key)-1], ErrMalformed},
+		{key[:10] + "x" + key[11:], ErrMalformed},
+		{"beta" + key[4:], ErrMalformed},
+		{swapped, securetoken.ErrTokenInvalid},
+	}
+	for _, test := range tests {
+		if k, err := m.Verify(test.key); err != test.err {
+			t.Errorf("Verify(%q) = %+v, %v; expected %s", test.key, k, err, test.err)
+		}
+	}
+}
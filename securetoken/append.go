@@ -0,0 +1,77 @@
+package securetoken
+
+import "slices"
+
+// AppendSeal is similar to SealAAD except the token is appended to dst
+// and the extended slice is returned. The token is built in the spare
+// capacity of dst, so when dst is large enough sealing allocates nothing
+// for its output. Bulk jobs can slice every token from one arena this way.
+// If sealing fails, dst is returned unchanged.
+func (t *Tokener) AppendSeal(dst, plaintext, aad []byte) ([]byte, error) {
+	out, err := t.appendSeal(dst, plaintext, aad)
+	t.reportSeal(nil, len(plaintext), len(out)-len(dst), err)
+	return out, err
+}
+
+// appendSeal is AppendSeal without the metrics hook.
+func (t *Tokener) appendSeal(dst, plaintext, aad []byte) ([]byte, error) {
+	id, aead, err := t.sealKey()
+	if err != nil {
+		t.logSeal(id, err)
+		return dst, err
+	}
+	n := len(dst)
+	plaintext = t.pad(plaintext)
+	rawLen := t.sealedLengthWith(aead, plaintext, false)
+	encLen := t.encoding.EncodedLen(rawLen)
+	buf := slices.Grow(dst, encLen+rawLen)
+	// The raw token goes past the room for the encoded token, which the
+	// next append overwrites.
+	tok := buf[n+encLen : n+encLen : n+encLen+rawLen]
+	tok = t.appendHeader(tok, id)
+	hdr := len(tok)
+	tok, err = t.appendNonce(tok, aead.NonceSize())
+	if err == nil {
+		tok, err = sealAEAD(aead, tok, tok[hdr:], plaintext, t.additionalData(t.version, tok[:hdr], aad))
+	}
+	t.logSeal(id, err)
+	if err != nil {
+		return dst, err
+	}
+	t.encoding.Encode(buf[n:n+encLen], tok)
+	return buf[:n+encLen], nil
+}
+
+// AppendUnseal is similar to Unseal except the plaintext is appended to dst
+// and the extended slice is returned. The token is decoded and opened in the
+// spare capacity of dst, so when dst is large enough unsealing allocates
+// nothing for its output. If unsealing fails, dst is returned unchanged.
+func (t *Tokener) AppendUnseal(dst, sealed []byte, opts ...UnsealOption) ([]byte, error) {
+	cfg := newUnsealConfig(opts)
+	if err := cfg.needsClaims(); err != nil {
+		return dst, err
+	}
+	cfg.dst = dst
+	out, _, err := t.unsealFor("", sealed, cfg)
+	t.logUnseal("", len(sealed), nil, err)
+	t.reportUnseal(nil, cfg, len(sealed), err)
+	if err != nil {
+		return dst, err
+	}
+	return out, nil
+}
+
+// decodeAppend decodes src past the room that dst needs for the plaintext,
+// so that the plaintext can be opened into the returned dst without
+// overlapping it. If dst is nil, src is decoded into a new buffer.
+func (t *Tokener) decodeAppend(dst, src []byte) ([]byte, []byte, error) {
+	if dst == nil {
+		decoded, err := t.decode(src)
+		return nil, decoded, err
+	}
+	n, m := len(dst), t.encoding.DecodedLen(len(src))
+	dst = slices.Grow(dst, 2*m)
+	buf := dst[n+m : n+2*m]
+	k, err := t.encoding.Decode(buf, src)
+	return dst, buf[:k], err
+}
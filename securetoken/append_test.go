@@ -0,0 +1,38 @@
+package securetoken
+
+import "testing"
+
+func TestAppendSealUnseal(t *testing.T) {
+	tok, err := NewTokener(key, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	arena := make([]byte, 0, 1024)
+	arena, err = tok.AppendSeal(arena, []byte("hello"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := len(arena)
+	arena, err = tok.AppendSeal(arena, []byte("world"), []byte("aad"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cap(arena) != 1024 {
+		t.Errorf("AppendSeal() grew the arena to %d bytes; expected it to fit in 1024", cap(arena))
+	}
+	if expected := tok.sealedLength([]byte("hello"), true); first != expected {
+		t.Errorf("AppendSeal() appended %d bytes; expected %d", first, expected)
+	}
+
+	hello, world := arena[:first], arena[first:]
+	if p, err := tok.Unseal(hello); string(p) != "hello" || err != nil {
+		t.Errorf("Unseal(%q) = %q, %v; expected %q, <nil>", hello, p, err, "hello")
+	}
+	out := []byte("> ")
+	if out, err = tok.AppendUnseal(out, world, WithAAD([]byte("aad"))); string(out) != "> world" || err != nil {
+		t.Errorf("AppendUnseal(%q) = %q, %v; expected %q, <nil>", world, out, err, "> world")
+	}
+	if out, err := tok.AppendUnseal(out, world); string(out) != "> world" || err != ErrTokenInvalid {
+		t.Errorf("AppendUnseal(%q) without aad = %q, %v; expected %q, %s", world, out, err, "> world", ErrTokenInvalid)
+	}
+}
@@ -0,0 +1,114 @@
+package securetoken
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrAttestationInvalid is returned by SignedKeyAttestation.Verify when the
+// attestation was not signed by the given key or has been altered.
+var ErrAttestationInvalid = errors.New("securetoken: invalid key attestation")
+
+// attestationContext separates attestation signatures from other
+// signatures made with the same signing key.
+const attestationContext = "securetoken key attestation v1\n"
+
+// A KeyAttestation records the provenance of a key,
+// so that auditors can check how the keys that sealed tokens were made.
+type KeyAttestation struct {
+	// KeyID is the id of the key in its Keyring.
+	KeyID uint32 `json:"kid"`
+
+	// KeyCheck is the key check value of the key (see KeyCheckValue),
+	// which binds the attestation to the key itself rather than to
+	// whatever key has the id.
+	KeyCheck []byte `json:"kcv"`
+
+	// Created is when the key was created.
+	Created time.Time `json:"created"`
+
+	// Creator identifies who or what created the key,
+	// e.g. a key ceremony or a deployment pipeline.
+	Creator string `json:"creator"`
+
+	// HSM is the attestation of the hardware security module that
+	// generated or holds the key, in the format of the HSM, if any.
+	HSM []byte `json:"hsm,omitempty"`
+}
+
+// A SignedKeyAttestation is a KeyAttestation signed with Ed25519 by the
+// party that vouches for it, such as the security team.
+type SignedKeyAttestation struct {
+	KeyAttestation
+	Signature []byte `json:"sig"`
+}
+
+// SignKeyAttestation signs a with priv.
+func SignKeyAttestation(priv ed25519.PrivateKey, a KeyAttestation) (*SignedKeyAttestation, error) {
+	msg, err := a.message()
+	if err != nil {
+		return nil, err
+	}
+	return &SignedKeyAttestation{KeyAttestation: a, Signature: ed25519.Sign(priv, msg)}, nil
+}
+
+// Verify returns ErrAttestationInvalid unless s was signed by pub.
+func (s *SignedKeyAttestation) Verify(pub ed25519.PublicKey) error {
+	msg, err := s.message()
+	if err != nil || !ed25519.Verify(pub, msg, s.Signature) {
+		return ErrAttestationInvalid
+	}
+	return nil
+}
+
+// message returns the signed encoding of a.
+func (a KeyAttestation) message() ([]byte, error) {
+	buf, err := json.Marshal(a)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(attestationContext), buf...), nil
+}
+
+// SetAttestation attaches s to the key with id s.KeyID, replacing any
+// attestation that it had. It returns an error if s.KeyCheck is not the
+// key check value of that key. It does not verify the signature of s,
+// which is for auditors to do with the public key that they trust.
+func (k *Keyring) SetAttestation(s *SignedKeyAttestation) error {
+	e, ok := k.load().keys[s.KeyID]
+	if !ok {
+		return fmt.Errorf("securetoken: key %d does not exist", s.KeyID)
+	}
+	kcv, err := keyCheckValue(e.aead)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal(kcv, s.KeyCheck) {
+		return fmt.Errorf("securetoken: attestation is not for key %d", s.KeyID)
+	}
+	c := *s
+	c.KeyCheck = append([]byte(nil), s.KeyCheck...)
+	c.HSM = append([]byte(nil), s.HSM...)
+	c.Signature = append([]byte(nil), s.Signature...)
+	e.attestation.Store(&c)
+	return nil
+}
+
+// Attestations returns the attestations of the keys in k, ordered by key id.
+// Keys without an attestation are omitted.
+func (k *Keyring) Attestations() []SignedKeyAttestation {
+	s := k.load()
+	var attestations []SignedKeyAttestation
+	for _, id := range k.IDs() {
+		if e, ok := s.keys[id]; ok {
+			if a := e.attestation.Load(); a != nil {
+				attestations = append(attestations, *a)
+			}
+		}
+	}
+	return attestations
+}
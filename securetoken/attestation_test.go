@@ -0,0 +1,77 @@
+package securetoken
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+	"time"
+)
+
+func TestKeyAttestation(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kr := NewKeyring()
+	for _, id := range []uint32{2, 1} {
+		if err := kr.AddKey(id, key); err != nil {
+			t.Fatal(err)
+		}
+	}
+	kcv, err := KeyCheckValue(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []uint32{2, 1} {
+		s, err := SignKeyAttestation(priv, KeyAttestation{KeyID: id, KeyCheck: kcv, Created: time.Unix(1000, 0).UTC(), Creator: "ceremony-7", HSM: []byte("quote")})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := kr.SetAttestation(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	attestations := kr.Attestations()
+	if len(attestations) != 2 || attestations[0].KeyID != 1 || attestations[1].KeyID != 2 {
+		t.Fatalf("Attestations() = %+v; expected keys 1 and 2", attestations)
+	}
+	for _, a := range attestations {
+		if err := a.Verify(pub); err != nil {
+			t.Errorf("Verify() of the attestation of key %d returned %v", a.KeyID, err)
+		}
+	}
+
+	forged := attestations[0]
+	forged.Creator = "mallory"
+	if err := forged.Verify(pub); err != ErrAttestationInvalid {
+		t.Errorf("Verify() of an altered attestation returned %v; expected %s", err, ErrAttestationInvalid)
+	}
+	otherPub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := attestations[0].Verify(otherPub); err != ErrAttestationInvalid {
+		t.Errorf("Verify() with another key returned %v; expected %s", err, ErrAttestationInvalid)
+	}
+
+	if err := kr.SetAttestation(&SignedKeyAttestation{KeyAttestation: KeyAttestation{KeyID: 3}}); err == nil {
+		t.Error("SetAttestation() for a missing key returned nil error")
+	}
+
+	// An attestation of another key with the same id is rejected.
+	otherKCV, err := KeyCheckValue(key2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := SignKeyAttestation(priv, KeyAttestation{KeyID: 1, KeyCheck: otherKCV, Creator: "ceremony-8"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := kr.SetAttestation(other); err == nil {
+		t.Error("SetAttestation() of an attestation of another key returned nil error")
+	}
+	if err := kr.SetAttestation(&SignedKeyAttestation{KeyAttestation: KeyAttestation{KeyID: 1}}); err == nil {
+		t.Error("SetAttestation() without a key check value returned nil error")
+	}
+}
@@ -0,0 +1,68 @@
+package securetoken
+
+import (
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/sha256"
+	"errors"
+)
+
+// Key ids of the keys added by WithAutoAEAD.
+// The id in the header of a token records the AEAD that sealed it,
+// so every Tokener can unseal tokens sealed by any other.
+const (
+	AutoAESGCMKeyID   uint32 = 0
+	AutoChaChaKeyID   uint32 = 1
+	autoChaChaKeyInfo        = "securetoken chacha20poly1305"
+)
+
+var errAutoAEADKeyring = errors.New("securetoken: WithAutoAEAD requires NewTokener")
+
+// These are set by autoaead_xcrypto.go, which detects the CPU features
+// and provides ChaCha20-Poly1305 when built with the xcrypto tag.
+// Without it, WithAutoAEAD always seals with AES-GCM.
+var (
+	hasAESHardware      = func() bool { return true }
+	newChaCha20Poly1305 func(key []byte) (cipher.AEAD, error)
+)
+
+// WithAutoAEAD returns an Option that makes a Tokener created by NewTokener
+// seal with AES-GCM when the CPU accelerates it (AES-NI and PCLMULQDQ on x86,
+// AES and PMULL on ARM) and with ChaCha20-Poly1305 otherwise.
+// The ChaCha20-Poly1305 key is derived from the key of the Tokener.
+// Tokens are sealed as Version2 tokens whose key id is AutoAESGCMKeyID or
+// AutoChaChaKeyID, and tokens sealed with either AEAD are unsealed,
+// so one binary can run across a fleet of mixed hardware.
+//
+// Hardware detection and ChaCha20-Poly1305 require building with the xcrypto tag
+// and golang.org/x/sys and golang.org/x/crypto. Build every binary that shares
+// a key with the tag, or binaries without it will not unseal ChaCha20-Poly1305 tokens.
+func WithAutoAEAD() Option {
+	return func(t *Tokener) error {
+		t.autoAEAD = true
+		return nil
+	}
+}
+
+// addAutoKeys adds the keys of WithAutoAEAD to kr, which holds key as
+// AutoAESGCMKeyID, and makes the key of the preferred AEAD primary.
+func addAutoKeys(kr *Keyring, key []byte) error {
+	if newChaCha20Poly1305 == nil {
+		return nil
+	}
+	k, err := hkdf.Key(sha256.New, key, nil, autoChaChaKeyInfo, 32)
+	if err != nil {
+		return err
+	}
+	aead, err := newChaCha20Poly1305(k)
+	if err != nil {
+		return err
+	}
+	if err := kr.add(AutoChaChaKeyID, &keyEntry{aead: aead, newAEAD: newChaCha20Poly1305, keySize: len(k)}); err != nil {
+		return err
+	}
+	if hasAESHardware() {
+		return nil
+	}
+	return kr.SetPrimary(AutoChaChaKeyID)
+}
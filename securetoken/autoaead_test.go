@@ -0,0 +1,74 @@
+package securetoken
+
+import (
+	"crypto/cipher"
+	"encoding/base64"
+	"testing"
+)
+
+func TestAutoAEAD(t *testing.T) {
+	defer func(has func() bool, chacha func([]byte) (cipher.AEAD, error)) {
+		hasAESHardware, newChaCha20Poly1305 = has, chacha
+	}(hasAESHardware, newChaCha20Poly1305)
+	// AES-GCM with a 16 byte nonce stands in for ChaCha20-Poly1305.
+	newChaCha20Poly1305 = func(key []byte) (cipher.AEAD, error) {
+		return newGCM16(t, key), nil
+	}
+
+	sealWith := func(hasAES bool) (*Tokener, []byte) {
+		hasAESHardware = func() bool { return hasAES }
+		tok, err := NewTokener(key, ttl, WithAutoAEAD())
+		if err != nil {
+			t.Fatal(err)
+		}
+		sealed, err := tok.Seal([]byte("hello"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tok, sealed
+	}
+	x86, fromX86 := sealWith(true)
+	arm, fromARM := sealWith(false)
+
+	for _, test := range []struct {
+		sealed []byte
+		id     uint32
+	}{
+		{fromX86, AutoAESGCMKeyID},
+		{fromARM, AutoChaChaKeyID},
+	} {
+		decoded, err := base64.URLEncoding.DecodeString(string(test.sealed))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ver, id, err := parseHeader(decoded); ver != Version2 || id != test.id || err != nil {
+			t.Errorf("parseHeader(%q) = %d, %d, %v; expected %d, %d, <nil>", test.sealed, ver, id, err, Version2, test.id)
+		}
+		for _, tok := range []*Tokener{x86, arm} {
+			if p, err := tok.Unseal(test.sealed); string(p) != "hello" || err != nil {
+				t.Errorf("Unseal(%q) = %q, %v; expected %q, <nil>", test.sealed, p, err, "hello")
+			}
+		}
+	}
+
+	// Version 1 tokens sealed before WithAutoAEAD are still unsealed.
+	old, err := NewTokener(key, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := old.Seal([]byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p, err := arm.Unseal(sealed); string(p) != "hello" || err != nil {
+		t.Errorf("Unseal(%q) = %q, %v; expected %q, <nil>", sealed, p, err, "hello")
+	}
+
+	kr := NewKeyring()
+	if err := kr.AddKey(0, key); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewKeyringTokener(kr, ttl, WithAutoAEAD()); err != errAutoAEADKeyring {
+		t.Errorf("NewKeyringTokener(WithAutoAEAD()) = %v; expected %s", err, errAutoAEADKeyring)
+	}
+}
@@ -0,0 +1,17 @@
+//go:build xcrypto
+
+package securetoken
+
+import (
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/sys/cpu"
+)
+
+func init() {
+	hasAESHardware = func() bool {
+		return cpu.X86.HasAES && cpu.X86.HasPCLMULQDQ ||
+			cpu.ARM64.HasAES && cpu.ARM64.HasPMULL ||
+			cpu.S390X.HasAES && cpu.S390X.HasGHASH
+	}
+	newChaCha20Poly1305 = chacha20poly1305.New
+}
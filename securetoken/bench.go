@@ -0,0 +1,100 @@
+package securetoken
+
+import (
+	"crypto/rand"
+	"runtime"
+	"time"
+)
+
+// A BenchCase is a Tokener measured by Bench.
+type BenchCase struct {
+	Name    string
+	Tokener *Tokener
+}
+
+// A BenchResult is the measurement of one case and payload size.
+type BenchResult struct {
+	Case          string  `json:"case"`
+	PayloadSize   int     `json:"payload_size"`
+	TokenSize     int     `json:"token_size"`
+	SealsPerSec   float64 `json:"seals_per_sec"`
+	UnsealsPerSec float64 `json:"unseals_per_sec"`
+}
+
+// A BenchReport is the result of Bench along with the hardware it ran on,
+// so that reports from different releases and machines can be compared.
+type BenchReport struct {
+	GoVersion string        `json:"go_version"`
+	GOOS      string        `json:"goos"`
+	GOARCH    string        `json:"goarch"`
+	NumCPU    int           `json:"num_cpu"`
+	Results   []BenchResult `json:"results"`
+}
+
+// Bench measures the token size and the seals and unseals per second of
+// every case for random payloads of each of the given sizes on the current
+// hardware. Each measurement runs for about d on one goroutine.
+// Seals count against the key limits of the Tokeners.
+func Bench(cases []BenchCase, payloadSizes []int, d time.Duration) (*BenchReport, error) {
+	report := &BenchReport{
+		GoVersion: runtime.Version(),
+		GOOS:      runtime.GOOS,
+		GOARCH:    runtime.GOARCH,
+		NumCPU:    runtime.NumCPU(),
+	}
+	for _, c := range cases {
+		for _, size := range payloadSizes {
+			payload := make([]byte, size)
+			if _, err := rand.Read(payload); err != nil {
+				return nil, err
+			}
+			sealed, err := c.Tokener.Seal(payload)
+			if err != nil {
+				return nil, err
+			}
+			seals, err := opsPerSec(d, func() error {
+				_, err := c.Tokener.Seal(payload)
+				return err
+			})
+			if err != nil {
+				return nil, err
+			}
+			unseals, err := opsPerSec(d, func() error {
+				_, err := c.Tokener.Unseal(sealed)
+				return err
+			})
+			if err != nil {
+				return nil, err
+			}
+			report.Results = append(report.Results, BenchResult{
+				Case:          c.Name,
+				PayloadSize:   size,
+				TokenSize:     len(sealed),
+				SealsPerSec:   seals,
+				UnsealsPerSec: unseals,
+			})
+		}
+	}
+	return report, nil
+}
+
+// opsPerSec calls f in batches until d has passed
+// and returns the number of calls per second.
+func opsPerSec(d time.Duration, f func() error) (float64, error) {
+	start := time.Now()
+	n, batch := 0, 1
+	for {
+		for i := 0; i < batch; i++ {
+			if err := f(); err != nil {
+				return 0, err
+			}
+		}
+		n += batch
+		if elapsed := time.Since(start); elapsed >= d {
+			return float64(n) / elapsed.Seconds(), nil
+		}
+		if batch < 1024 {
+			batch *= 2
+		}
+	}
+}
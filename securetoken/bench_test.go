@@ -0,0 +1,25 @@
+package securetoken
+
+import "testing"
+
+func TestBench(t *testing.T) {
+	tok, err := NewTokener(key, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := Bench([]BenchCase{{"aes128-gcm", tok}}, []int{0, 100}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Results) != 2 {
+		t.Fatalf("Bench() returned %d results; expected 2", len(report.Results))
+	}
+	for _, r := range report.Results {
+		if expected := tok.sealedLength(make([]byte, r.PayloadSize), true); r.TokenSize != expected {
+			t.Errorf("Bench() reported a token size of %d for %d bytes; expected %d", r.TokenSize, r.PayloadSize, expected)
+		}
+		if r.SealsPerSec <= 0 || r.UnsealsPerSec <= 0 {
+			t.Errorf("Bench() reported %v seals and %v unseals per second; expected positive rates", r.SealsPerSec, r.UnsealsPerSec)
+		}
+	}
+}
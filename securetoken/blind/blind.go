@@ -0,0 +1,330 @@
+// Package blind issues tokens blindly and redeems them unlinkably,
+// in the style of Privacy Pass, for rate limiting and anti-abuse checks
+// that must not be able to tie a redemption to the issuance it came from.
+//
+// A client creates a random token input and blinds it. The issuer signs
+// the blinded input, typically after checking that the client may have
+// a token (e.g. it solved a challenge), without learning the input. The
+// client unblinds the signature and later redeems the token, possibly with
+// another server, which verifies the signature with the public key of the
+// issuer and records the input so that each token is only redeemed once.
+//
+// Signatures are RSABSSA-SHA384-PSS-Deterministic blind signatures from
+// RFC 9474; the token input is random, so it needs no message prefix.
+// Issuers sign with the Chinese remainder theorem on a randomly blinded
+// value, so the time it takes does not depend on what a client sent.
+// Issuer keys must be at least 2048 bits and should be rotated regularly;
+// tokens are valid for as long as the key that signed them.
+// KeySetHandler publishes issuer keys to the servers that redeem tokens.
+package blind
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"sync"
+)
+
+const (
+	inputLength = 32
+	saltLength  = sha512.Size384
+)
+
+var (
+	// ErrInvalid is returned when a token or blind signature is invalid.
+	ErrInvalid = errors.New("blind: invalid token")
+
+	// ErrSpent is returned by Redeem when a token has already been redeemed.
+	ErrSpent = errors.New("blind: token already redeemed")
+
+	errKeyTooSmall  = errors.New("blind: key must be at least 2048 bits")
+	errMultiPrime   = errors.New("blind: key must have exactly two primes")
+	errSignatureBad = errors.New("blind: signature check failed")
+)
+
+// An Issuer signs blinded token inputs.
+type Issuer struct {
+	key *rsa.PrivateKey
+}
+
+// NewIssuer returns an Issuer that signs with key.
+func NewIssuer(key *rsa.PrivateKey) (*Issuer, error) {
+	if key.N.BitLen() < 2048 {
+		return nil, errKeyTooSmall
+	}
+	if len(key.Primes) != 2 {
+		return nil, errMultiPrime
+	}
+	key.Precompute()
+	return &Issuer{key: key}, nil
+}
+
+// PublicKey returns the key that verifies tokens of i.
+func (i *Issuer) PublicKey() *rsa.PublicKey {
+	return &i.key.PublicKey
+}
+
+// Sign returns the blind signature of a blinded input from Blind.
+func (i *Issuer) Sign(blinded []byte) ([]byte, error) {
+	if len(blinded) != modulusLength(&i.key.PublicKey) {
+		return nil, ErrInvalid
+	}
+	m := new(big.Int).SetBytes(blinded)
+	if m.Sign() == 0 || m.Cmp(i.key.N) >= 0 {
+		return nil, ErrInvalid
+	}
+	s, err := i.sign(m)
+	if err != nil {
+		return nil, err
+	}
+	// Check the signature so that a fault never leaks the key.
+	if new(big.Int).Exp(s, big.NewInt(int64(i.key.E)), i.key.N).Cmp(m) != 0 {
+		return nil, errSignatureBad
+	}
+	return s.FillBytes(make([]byte, modulusLength(&i.key.PublicKey))), nil
+}
+
+// sign returns m^D mod N. It blinds m with a random r^E first, so that the
+// exponentiations never see a value chosen by the client, and uses the
+// Chinese remainder theorem.
+func (i *Issuer) sign(m *big.Int) (*big.Int, error) {
+	k := i.key
+	r, rInv, err := randomUnit(k.N)
+	if err != nil {
+		return nil, err
+	}
+	c := new(big.Int).Exp(r, big.NewInt(int64(k.E)), k.N)
+	c.Mul(c, m).Mod(c, k.N)
+
+	p, q := k.Primes[0], k.Primes[1]
+	m1 := new(big.Int).Exp(c, k.Precomputed.Dp, p)
+	m2 := new(big.Int).Exp(c, k.Precomputed.Dq, q)
+	s := m1.Sub(m1, m2)
+	s.Mul(s, k.Precomputed.Qinv).Mod(s, p)
+	s.Mul(s, q).Add(s, m2)
+	return s.Mul(s, rInv).Mod(s, k.N), nil
+}
+
+// State is kept by a client between Blind and Finalize.
+type State struct {
+	pub   *rsa.PublicKey
+	input []byte
+	rInv  *big.Int
+}
+
+// Blind returns a blinded random token input for the issuer with key pub,
+// to be sent to Issuer.Sign, and the state to finalize the token with.
+func Blind(pub *rsa.PublicKey) ([]byte, *State, error) {
+	if pub.N.BitLen() < 2048 {
+		return nil, nil, errKeyTooSmall
+	}
+	input := make([]byte, inputLength)
+	if _, err := rand.Read(input); err != nil {
+		return nil, nil, err
+	}
+	salt := make([]byte, saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, nil, err
+	}
+	r, rInv, err := randomUnit(pub.N)
+	if err != nil {
+		return nil, nil, err
+	}
+	return blind(pub, input, salt, r, rInv)
+}
+
+// blind is Blind of RFC 9474 with the given input, salt and blind r.
+func blind(pub *rsa.PublicKey, input, salt []byte, r, rInv *big.Int) ([]byte, *State, error) {
+	encoded, err := emsaPSSEncode(input, salt, pub.N.BitLen()-1)
+	if err != nil {
+		return nil, nil, err
+	}
+	m := new(big.Int).SetBytes(encoded)
+	if new(big.Int).GCD(nil, nil, m, pub.N).Cmp(big.NewInt(1)) != 0 {
+		return nil, nil, ErrInvalid
+	}
+	m.Mul(m, new(big.Int).Exp(r, big.NewInt(int64(pub.E)), pub.N))
+	m.Mod(m, pub.N)
+	return m.FillBytes(make([]byte, modulusLength(pub))), &State{pub: pub, input: input, rInv: rInv}, nil
+}
+
+// Finalize unblinds the blind signature from Issuer.Sign and returns the token.
+func (s *State) Finalize(blindSig []byte) ([]byte, error) {
+	sig, err := s.finalize(blindSig)
+	if err != nil {
+		return nil, err
+	}
+	token := append(append([]byte(nil), s.input...), sig...)
+	buf := make([]byte, base64.RawURLEncoding.EncodedLen(len(token)))
+	base64.RawURLEncoding.Encode(buf, token)
+	return buf, nil
+}
+
+// finalize is Finalize of RFC 9474: it returns the signature of the input.
+func (s *State) finalize(blindSig []byte) ([]byte, error) {
+	k := modulusLength(s.pub)
+	if len(blindSig) != k {
+		return nil, ErrInvalid
+	}
+	z := new(big.Int).SetBytes(blindSig)
+	if z.Cmp(s.pub.N) >= 0 {
+		return nil, ErrInvalid
+	}
+	sig := z.Mul(z, s.rInv).Mod(z, s.pub.N).FillBytes(make([]byte, k))
+	if !verifyPSS(s.pub, s.input, sig) {
+		return nil, ErrInvalid
+	}
+	return sig, nil
+}
+
+// Verify returns ErrInvalid unless token, as returned by Finalize,
+// was signed by the issuer with key pub.
+func Verify(pub *rsa.PublicKey, token []byte) error {
+	_, err := verify(pub, token)
+	return err
+}
+
+func verify(pub *rsa.PublicKey, token []byte) ([]byte, error) {
+	k := modulusLength(pub)
+	decoded := make([]byte, base64.RawURLEncoding.DecodedLen(len(token)))
+	n, err := base64.RawURLEncoding.Decode(decoded, token)
+	if err != nil || n != inputLength+k {
+		return nil, ErrInvalid
+	}
+	input, sig := decoded[:inputLength], decoded[inputLength:n]
+	if !verifyPSS(pub, input, sig) {
+		return nil, ErrInvalid
+	}
+	return input, nil
+}
+
+func verifyPSS(pub *rsa.PublicKey, input, sig []byte) bool {
+	hashed := sha512.Sum384(input)
+	return rsa.VerifyPSS(pub, crypto.SHA384, hashed[:], sig, &rsa.PSSOptions{SaltLength: saltLength}) == nil
+}
+
+// A SpentStore records the inputs of redeemed tokens.
+// Implementations must be goroutine safe.
+type SpentStore interface {
+	// Spend records input and reports whether it had not been recorded before.
+	Spend(input []byte) (bool, error)
+}
+
+// A Redeemer redeems tokens.
+type Redeemer struct {
+	// PublicKey is the key of the issuer.
+	PublicKey *rsa.PublicKey
+
+	// Spent records redeemed tokens. It only needs to remember
+	// tokens for as long as PublicKey is in use.
+	Spent SpentStore
+}
+
+// Redeem verifies token and records it as spent.
+// It returns ErrSpent if token has already been redeemed.
+func (r *Redeemer) Redeem(token []byte) error {
+	input, err := verify(r.PublicKey, token)
+	if err != nil {
+		return err
+	}
+	first, err := r.Spent.Spend(input)
+	if err != nil {
+		return err
+	}
+	if !first {
+		return ErrSpent
+	}
+	return nil
+}
+
+// A MemorySpentStore is a SpentStore that keeps inputs in memory.
+// It is goroutine safe.
+type MemorySpentStore struct {
+	mu    sync.Mutex
+	spent map[[inputLength]byte]struct{}
+}
+
+// NewMemorySpentStore returns an empty MemorySpentStore.
+func NewMemorySpentStore() *MemorySpentStore {
+	return &MemorySpentStore{spent: make(map[[inputLength]byte]struct{})}
+}
+
+// Spend implements SpentStore.
+func (s *MemorySpentStore) Spend(input []byte) (bool, error) {
+	var k [inputLength]byte
+	copy(k[:], input)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.spent[k]; ok {
+		return false, nil
+	}
+	s.spent[k] = struct{}{}
+	return true, nil
+}
+
+// emsaPSSEncode returns the EMSA-PSS encoding of input (RFC 8017, section
+// 9.1.1) in emBits bits, with SHA-384 as the hash and MGF1 and salt.
+func emsaPSSEncode(input, salt []byte, emBits int) ([]byte, error) {
+	hLen := sha512.Size384
+	emLen := (emBits + 7) / 8
+	if emLen < hLen+len(salt)+2 {
+		return nil, errKeyTooSmall
+	}
+	mHash := sha512.Sum384(input)
+	h := sha512.New384()
+	h.Write(make([]byte, 8))
+	h.Write(mHash[:])
+	h.Write(salt)
+	hash := h.Sum(nil)
+
+	em := make([]byte, emLen)
+	db := em[:emLen-hLen-1]
+	db[emLen-len(salt)-hLen-2] = 0x01
+	copy(db[emLen-len(salt)-hLen-1:], salt)
+	mgf1XOR(db, hash)
+	db[0] &= 0xff >> uint(8*emLen-emBits)
+	copy(em[emLen-hLen-1:], hash)
+	em[emLen-1] = 0xbc
+	return em, nil
+}
+
+// mgf1XOR XORs out with MGF1 of seed, with SHA-384 as the hash.
+func mgf1XOR(out, seed []byte) {
+	var counter [4]byte
+	for i, c := 0, uint32(0); i < len(out); c++ {
+		binary.BigEndian.PutUint32(counter[:], c)
+		h := sha512.New384()
+		h.Write(seed)
+		h.Write(counter[:])
+		for _, b := range h.Sum(nil) {
+			if i == len(out) {
+				break
+			}
+			out[i] ^= b
+			i++
+		}
+	}
+}
+
+// randomUnit returns a random r invertible modulo n and its inverse.
+func randomUnit(n *big.Int) (r, rInv *big.Int, err error) {
+	for rInv == nil {
+		r, err = rand.Int(rand.Reader, n)
+		if err != nil {
+			return nil, nil, err
+		}
+		if r.Sign() != 0 {
+			rInv = new(big.Int).ModInverse(r, n)
+		}
+	}
+	return r, rInv, nil
+}
+
+func modulusLength(pub *rsa.PublicKey) int {
+	return (pub.N.BitLen() + 7) / 8
+}
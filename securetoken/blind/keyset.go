@@ -0,0 +1,145 @@
+package blind
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var errBadJWK = errors.New("blind: invalid JSON web key")
+
+// A JWK is an RSA public key in JSON Web Key format (RFC 7517).
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// A JWKS is a JSON Web Key Set.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// NewJWK returns pub as a JWK whose Kid is KeyID(pub).
+func NewJWK(pub *rsa.PublicKey) JWK {
+	n, e := jwkParams(pub)
+	return JWK{Kty: "RSA", Kid: KeyID(pub), Use: "sig", N: n, E: e}
+}
+
+// jwkParams returns the base64url encoded modulus and exponent of pub.
+func jwkParams(pub *rsa.PublicKey) (n, e string) {
+	return base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+		base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+}
+
+// PublicKey returns the key of k.
+func (k JWK) PublicKey() (*rsa.PublicKey, error) {
+	if k.Kty != "RSA" {
+		return nil, errBadJWK
+	}
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, errBadJWK
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil || len(e) == 0 || len(e) > 4 {
+		return nil, errBadJWK
+	}
+	pub := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	// Reject the exponents that crypto/rsa rejects.
+	if pub.E < 3 || pub.E%2 == 0 {
+		return nil, errBadJWK
+	}
+	if pub.N.BitLen() < 2048 {
+		return nil, errKeyTooSmall
+	}
+	return pub, nil
+}
+
+// KeyID returns the JWK thumbprint of pub (RFC 7638),
+// which identifies it in a JWKS.
+func KeyID(pub *rsa.PublicKey) string {
+	n, e := jwkParams(pub)
+	h := sha256.Sum256([]byte(`{"e":"` + e + `","kty":"RSA","n":"` + n + `"}`))
+	return base64.RawURLEncoding.EncodeToString(h[:])
+}
+
+// KeySetHandler returns a handler that serves keys, the public keys of
+// issuers, so that third parties can verify tokens without contacting the
+// issuer. It serves a JWKS, or the keys as PEM encoded PKIX public keys if
+// the request accepts application/x-pem-file. Responses may be cached for
+// maxAge and carry an ETag for revalidation. Keys are served as given, so
+// a new handler is needed when an issuer key is added or retired.
+func KeySetHandler(maxAge time.Duration, keys ...*rsa.PublicKey) (http.Handler, error) {
+	set := JWKS{Keys: make([]JWK, 0, len(keys))}
+	var pemBody bytes.Buffer
+	for _, pub := range keys {
+		set.Keys = append(set.Keys, NewJWK(pub))
+		der, err := x509.MarshalPKIXPublicKey(pub)
+		if err != nil {
+			return nil, err
+		}
+		if err := pem.Encode(&pemBody, &pem.Block{Type: "PUBLIC KEY", Bytes: der}); err != nil {
+			return nil, err
+		}
+	}
+	jwksBody, err := json.Marshal(set)
+	if err != nil {
+		return nil, err
+	}
+	jwks := newKeySetResponse("application/jwk-set+json", jwksBody)
+	raw := newKeySetResponse("application/x-pem-file", pemBody.Bytes())
+	cacheControl := "public, max-age=" + strconv.Itoa(int(maxAge/time.Second))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		resp := jwks
+		if strings.Contains(r.Header.Get("Accept"), raw.contentType) {
+			resp = raw
+		}
+		h := w.Header()
+		h.Set("Cache-Control", cacheControl)
+		h.Set("Vary", "Accept")
+		h.Set("ETag", resp.etag)
+		if r.Header.Get("If-None-Match") == resp.etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		h.Set("Content-Type", resp.contentType)
+		h.Set("Content-Length", strconv.Itoa(len(resp.body)))
+		if r.Method == http.MethodGet {
+			w.Write(resp.body)
+		}
+	}), nil
+}
+
+// keySetResponse is a response body of KeySetHandler.
+type keySetResponse struct {
+	contentType string
+	body        []byte
+	etag        string
+}
+
+func newKeySetResponse(contentType string, body []byte) keySetResponse {
+	h := sha256.Sum256(body)
+	return keySetResponse{
+		contentType: contentType,
+		body:        body,
+		etag:        `"` + base64.RawURLEncoding.EncodeToString(h[:16]) + `"`,
+	}
+}
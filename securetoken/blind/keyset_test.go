@@ -0,0 +1,89 @@
+package blind
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestKeySetHandler(t *testing.T) {
+	issuer := newIssuer(t)
+	h, err := KeySetHandler(time.Hour, issuer.PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/.well-known/jwks.json", nil))
+	if rec.Code != 200 || rec.Header().Get("Cache-Control") != "public, max-age=3600" {
+		t.Fatalf("GET = %d with Cache-Control %q; expected 200 with max-age=3600", rec.Code, rec.Header().Get("Cache-Control"))
+	}
+	var set JWKS
+	if err := json.Unmarshal(rec.Body.Bytes(), &set); err != nil {
+		t.Fatal(err)
+	}
+	if len(set.Keys) != 1 || set.Keys[0].Kid != KeyID(issuer.PublicKey()) {
+		t.Fatalf("GET returned %+v; expected the issuer key", set)
+	}
+	pub, err := set.Keys[0].PublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pub.Equal(issuer.PublicKey()) {
+		t.Error("JWK.PublicKey() is not the issuer key")
+	}
+
+	// A token issued by the issuer verifies with the served key.
+	blinded, state, err := Blind(issuer.PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	blindSig, err := issuer.Sign(blinded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := state.Finalize(blindSig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Verify(pub, token); err != nil {
+		t.Errorf("Verify() with the served key returned %v", err)
+	}
+
+	etag := rec.Header().Get("ETag")
+	r := httptest.NewRequest("GET", "/.well-known/jwks.json", nil)
+	r.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+	if rec.Code != 304 {
+		t.Errorf("GET with If-None-Match %s = %d; expected 304", etag, rec.Code)
+	}
+
+	r = httptest.NewRequest("GET", "/keys.pem", nil)
+	r.Header.Set("Accept", "application/x-pem-file")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+	if block, _ := pem.Decode(rec.Body.Bytes()); block == nil || block.Type != "PUBLIC KEY" {
+		t.Errorf("GET with Accept application/x-pem-file returned %q; expected a PEM public key", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("POST", "/.well-known/jwks.json", nil))
+	if rec.Code != 405 {
+		t.Errorf("POST = %d; expected 405", rec.Code)
+	}
+}
+
+func TestJWKRejectsBadExponents(t *testing.T) {
+	issuer := newIssuer(t)
+	for _, e := range [][]byte{{1}, {2}, {1, 0, 0}} {
+		k := NewJWK(issuer.PublicKey())
+		k.E = base64.RawURLEncoding.EncodeToString(e)
+		if _, err := k.PublicKey(); err != errBadJWK {
+			t.Errorf("PublicKey() with exponent %x returned %v; expected %s", e, err, errBadJWK)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+// Package blob seals small files, such as attachments, together with
+// their metadata, so that applications do not need to invent their own
+// envelopes inside the opaque payload of a token.
+//
+// A blob is encoded as a format version byte followed by length-prefixed
+// fields: the content type, the filename, the creation time and the
+// payload. Each length is a uvarint. The whole encoding is sealed by a
+// securetoken.Sealer, so the metadata is as confidential and tamper-proof
+// as the payload. Tokens expire after the ttl of the Tokener, so a Tokener
+// for blobs should have a ttl at least as long as blobs are kept.
+package blob
+
+import (
+	"encoding/binary"
+	"errors"
+	"mime"
+	"time"
+
+	"github.com/nicksnyder/go-securetoken/securetoken"
+)
+
+// formatVersion is the version of the encoding of blobs.
+const formatVersion = 1
+
+// ErrMalformed is returned by Unseal when a token unseals to something
+// that is not a blob, such as a token sealed by the same Tokener for
+// another use.
+var ErrMalformed = errors.New("blob: malformed blob")
+
+// A Blob is a file and its metadata.
+type Blob struct {
+	// ContentType is the MIME type of Payload, e.g. "image/png".
+	ContentType string
+
+	// Filename is the name of the file, without a directory.
+	Filename string
+
+	// Created is the time the file was created.
+	// The zero time is not encoded and unseals as the zero time.
+	Created time.Time
+
+	// Payload is the content of the file.
+	Payload []byte
+}
+
+// MediaType parses ContentType as in mime.ParseMediaType.
+func (b *Blob) MediaType() (mediatype string, params map[string]string, err error) {
+	return mime.ParseMediaType(b.ContentType)
+}
+
+// Seal seals b.
+func Seal(s securetoken.Sealer, b *Blob) ([]byte, error) {
+	return s.Seal(b.encode())
+}
+
+// Unseal unseals a blob sealed by Seal.
+// The Payload of the blob aliases the unsealed plaintext.
+func Unseal(u securetoken.Unsealer, sealed []byte, opts ...securetoken.UnsealOption) (*Blob, error) {
+	plaintext, err := u.Unseal(sealed, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return decode(plaintext)
+}
+
+func (b *Blob) encode() []byte {
+	var created []byte
+	if !b.Created.IsZero() {
+		created = binary.BigEndian.AppendUint64(nil, uint64(b.Created.UnixNano()))
+	}
+	n := 1 + 4*binary.MaxVarintLen64 + len(b.ContentType) + len(b.Filename) + len(created) + len(b.Payload)
+	dst := append(make([]byte, 0, n), formatVersion)
+	dst = appendField(dst, []byte(b.ContentType))
+	dst = appendField(dst, []byte(b.Filename))
+	dst = appendField(dst, created)
+	return appendField(dst, b.Payload)
+}
+
+func appendField(dst, field []byte) []byte {
+	dst = binary.AppendUvarint(dst, uint64(len(field)))
+	return append(dst, field...)
+}
+
+func decode(src []byte) (*Blob, error) {
+	if len(src) == 0 || src[0] != formatVersion {
+		return nil, ErrMalformed
+	}
+	src = src[1:]
+	var fields [4][]byte
+	for i := range fields {
+		n, k := binary.Uvarint(src)
+		if k <= 0 || n > uint64(len(src)-k) {
+			return nil, ErrMalformed
+		}
+		fields[i], src = src[k:k+int(n):k+int(n)], src[k+int(n):]
+	}
+	if len(src) > 0 {
+		return nil, ErrMalformed
+	}
+	b := &Blob{
+		ContentType: string(fields[0]),
+		Filename:    string(fields[1]),
+		Payload:     fields[3],
+	}
+	switch len(fields[2]) {
+	case 0:
+	case 8:
+		b.Created = time.Unix(0, int64(binary.BigEndian.Uint64(fields[2])))
+	default:
+		return nil, ErrMalformed
+	}
+	return b, nil
+}
@@ -0,0 +1,58 @@
+package blob
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/nicksnyder/go-securetoken/securetoken/securetokentest"
+)
+
+func TestSealUnseal(t *testing.T) {
+	tok := securetokentest.NewTokener(t)
+	created := time.Date(2024, time.May, 1, 12, 0, 0, 5, time.UTC)
+	tests := []*Blob{
+		{ContentType: "text/plain; charset=utf-8", Filename: "notes.txt", Created: created, Payload: []byte("hello")},
+		{},
+		{ContentType: "image/png", Payload: bytes.Repeat([]byte{0xff}, 300)},
+	}
+	for _, b := range tests {
+		sealed, err := Seal(tok, b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := Unseal(tok, sealed)
+		if err != nil {
+			t.Fatalf("Unseal() returned %v; expected <nil>", err)
+		}
+		if got.ContentType != b.ContentType || got.Filename != b.Filename || !got.Created.Equal(b.Created) || !bytes.Equal(got.Payload, b.Payload) {
+			t.Errorf("Unseal(Seal(%+v)) = %+v; expected the same blob", b, got)
+		}
+	}
+
+	b := &Blob{ContentType: "text/plain; charset=utf-8"}
+	if mt, params, err := b.MediaType(); mt != "text/plain" || params["charset"] != "utf-8" || err != nil {
+		t.Errorf("MediaType() = %q, %v, %v; expected text/plain, utf-8, <nil>", mt, params, err)
+	}
+}
+
+func TestUnsealMalformed(t *testing.T) {
+	tok := securetokentest.NewTokener(t)
+	valid := (&Blob{Filename: "a", Payload: []byte("b")}).encode()
+	tests := [][]byte{
+		nil,
+		{2},
+		valid[:len(valid)-1],
+		append(valid, 0),
+		{formatVersion, 0, 0, 3, 1, 2, 3, 0},
+	}
+	for _, test := range tests {
+		sealed, err := tok.Seal(test)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b, err := Unseal(tok, sealed); err != ErrMalformed {
+			t.Errorf("Unseal(%x) = %+v, %v; expected %s", test, b, err, ErrMalformed)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package securetoken
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// PurposeBreakGlass is the purpose of break-glass tokens.
+const PurposeBreakGlass = "securetoken.break-glass"
+
+var (
+	// ErrApprovalsRequired is returned by SealBreakGlass unless the request
+	// has valid approvals from two distinct approvers other than its subject.
+	ErrApprovalsRequired = errors.New("securetoken: break-glass token needs two approvals")
+
+	// ErrBreakGlassUnaudited is returned when UnsealClaims or UnsealSubject
+	// unseal a break-glass token with a Tokener without an audit hook
+	// (see WithAuditHook).
+	ErrBreakGlassUnaudited = errors.New("securetoken: break-glass token needs an audit hook")
+
+	errBreakGlassDisabled = errors.New("securetoken: break-glass tokens are not enabled (see WithBreakGlass)")
+)
+
+// breakGlassContext separates approval signatures from other
+// signatures made with the same signing key.
+const breakGlassContext = "securetoken break-glass approval v1\n"
+
+// A BreakGlassRequest asks for a break-glass token: short-lived emergency
+// access, such as for an engineer during an incident, in place of shared
+// long-lived secrets. It must be approved by two approvers
+// (see ApproveBreakGlass) before SealBreakGlass mints the token.
+type BreakGlassRequest struct {
+	// ID identifies the request and becomes the ID of the token,
+	// so revoking it revokes the token. NewBreakGlassRequest sets it.
+	ID string `json:"id"`
+
+	// Subject is who gets access. Subjects can not approve their own requests.
+	Subject string `json:"sub"`
+
+	// Reason explains why access is needed, for the audit trail.
+	Reason string `json:"reason"`
+
+	// Scopes are the scopes of the token.
+	Scopes []string `json:"scp,omitempty"`
+
+	// TTL is how long the token is valid. It can not be longer than
+	// the maximum set by WithBreakGlass.
+	TTL time.Duration `json:"ttl"`
+
+	// ApproveBy is when the approvals of the request expire.
+	ApproveBy time.Time `json:"approve_by"`
+}
+
+// NewBreakGlassRequest returns a request with a new ID whose approvals
+// expire after approveWithin.
+func NewBreakGlassRequest(subject, reason string, ttl, approveWithin time.Duration, scopes ...string) (*BreakGlassRequest, error) {
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+	return &BreakGlassRequest{
+		ID:        id,
+		Subject:   subject,
+		Reason:    reason,
+		Scopes:    scopes,
+		TTL:       ttl,
+		ApproveBy: timeNow().Add(approveWithin),
+	}, nil
+}
+
+// A BreakGlassApproval is the Ed25519 signature of an approver
+// over a BreakGlassRequest.
+type BreakGlassApproval struct {
+	Approver  string `json:"approver"`
+	Signature []byte `json:"sig"`
+}
+
+// ApproveBreakGlass signs r on behalf of approver with priv, the key that
+// is registered for approver with WithBreakGlass.
+func ApproveBreakGlass(priv ed25519.PrivateKey, approver string, r *BreakGlassRequest) (BreakGlassApproval, error) {
+	msg, err := r.message()
+	if err != nil {
+		return BreakGlassApproval{}, err
+	}
+	return BreakGlassApproval{Approver: approver, Signature: ed25519.Sign(priv, msg)}, nil
+}
+
+// message returns the signed encoding of r.
+func (r *BreakGlassRequest) message() ([]byte, error) {
+	buf, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(breakGlassContext), buf...), nil
+}
+
+// BreakGlass is the Data of the claims of a break-glass token,
+// which the audit hook receives.
+type BreakGlass struct {
+	Reason    string   `json:"reason"`
+	Approvers []string `json:"approvers"`
+}
+
+type breakGlassPolicy struct {
+	approvers map[string]ed25519.PublicKey
+	maxTTL    time.Duration
+}
+
+// WithBreakGlass returns an Option that enables SealBreakGlass.
+// approvers maps the names of the people who may approve break-glass
+// requests to their Ed25519 public keys; there must be at least two,
+// and no two approvers may share a key.
+// maxTTL caps the TTL of break-glass tokens.
+//
+// Dual control guards the API of the Tokener: code that holds its keys
+// can always seal arbitrary payloads with Seal. SealClaims refuses
+// claims with PurposeBreakGlass, so the only way to mint one with
+// claims is through SealBreakGlass.
+func WithBreakGlass(approvers map[string]ed25519.PublicKey, maxTTL time.Duration) Option {
+	return func(t *Tokener) error {
+		if len(approvers) < 2 {
+			return errors.New("securetoken: break-glass tokens need at least two approvers")
+		}
+		if maxTTL <= 0 {
+			return errors.New("securetoken: break-glass ttl must be positive")
+		}
+		p := &breakGlassPolicy{approvers: make(map[string]ed25519.PublicKey, len(approvers)), maxTTL: maxTTL}
+		names := make(map[string]string, len(approvers))
+		for name, pub := range approvers {
+			if len(pub) != ed25519.PublicKeySize {
+				return fmt.Errorf("securetoken: invalid public key for approver %q", name)
+			}
+			if other, ok := names[string(pub)]; ok {
+				return fmt.Errorf("securetoken: approvers %q and %q have the same public key", other, name)
+			}
+			names[string(pub)] = name
+			p.approvers[name] = pub
+		}
+		t.breakGlass = p
+		return nil
+	}
+}
+
+// SealBreakGlass seals a break-glass token for r: a claims token with
+// PurposeBreakGlass whose Data is a BreakGlass. It returns
+// ErrApprovalsRequired unless approvals include valid signatures of r
+// by two distinct approvers other than the subject, made before
+// r.ApproveBy. Break-glass tokens are only accepted by UnsealClaims and
+// UnsealSubject of Tokeners with an audit hook, which is called on every
+// use. Unseal treats payloads as opaque and does not check them, so
+// break-glass tokens must only be accepted through UnsealClaims.
+func (t *Tokener) SealBreakGlass(r *BreakGlassRequest, approvals ...BreakGlassApproval) ([]byte, error) {
+	p := t.breakGlass
+	if p == nil {
+		return nil, errBreakGlassDisabled
+	}
+	if r.ID == "" || r.Subject == "" {
+		return nil, fmt.Errorf("%w: break-glass request needs an id and a subject", ErrInvalidClaims)
+	}
+	if r.TTL <= 0 || r.TTL > p.maxTTL {
+		return nil, fmt.Errorf("%w: break-glass ttl %s is not between 0 and %s", ErrInvalidClaims, r.TTL, p.maxTTL)
+	}
+	if t.now().After(r.ApproveBy) {
+		return nil, ErrApprovalsRequired
+	}
+	msg, err := r.message()
+	if err != nil {
+		return nil, err
+	}
+	// Count approvals by key, so that one key cannot approve twice.
+	approved := make(map[string]string)
+	for _, a := range approvals {
+		pub, ok := p.approvers[a.Approver]
+		if ok && a.Approver != r.Subject && ed25519.Verify(pub, msg, a.Signature) {
+			approved[string(pub)] = a.Approver
+		}
+	}
+	if len(approved) < 2 {
+		return nil, ErrApprovalsRequired
+	}
+	bg := BreakGlass{Reason: r.Reason}
+	for _, name := range approved {
+		bg.Approvers = append(bg.Approvers, name)
+	}
+	sort.Strings(bg.Approvers)
+	data, err := json.Marshal(bg)
+	if err != nil {
+		return nil, err
+	}
+	c := &Claims{
+		ID:      r.ID,
+		Subject: r.Subject,
+		Purpose: PurposeBreakGlass,
+		Scopes:  r.Scopes,
+		TTL:     r.TTL,
+		Data:    data,
+	}
+	tok, n, err := t.sealClaims(c)
+	t.reportSeal(c, n, len(tok), err)
+	return tok, err
+}
+
+// checkBreakGlass returns ErrBreakGlassUnaudited if c is a break-glass
+// token and t has no audit hook.
+func (t *Tokener) checkBreakGlass(c *Claims) error {
+	if c.Purpose == PurposeBreakGlass && t.auditHook == nil {
+		return ErrBreakGlassUnaudited
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package securetoken
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBreakGlass(t *testing.T) {
+	setNow(time.Unix(1, 0))
+	defer restoreNow()
+
+	approvers := make(map[string]ed25519.PublicKey)
+	privs := make(map[string]ed25519.PrivateKey)
+	for _, name := range []string{"alice", "bob", "carol"} {
+		pub, priv, err := ed25519.GenerateKey(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		approvers[name], privs[name] = pub, priv
+	}
+	var audited []*Claims
+	tok, err := NewTokener(key, time.Hour, WithBreakGlass(approvers, 30*time.Minute), WithAuditHook(func(c *Claims) {
+		audited = append(audited, c)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := NewBreakGlassRequest("carol", "database outage", 15*time.Minute, 10*time.Minute, "db:admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	approve := func(name string, r *BreakGlassRequest) BreakGlassApproval {
+		a, err := ApproveBreakGlass(privs[name], name, r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return a
+	}
+	alice, bob, carol := approve("alice", r), approve("bob", r), approve("carol", r)
+
+	tests := []struct {
+		name      string
+		approvals []BreakGlassApproval
+	}{
+		{"one approval", []BreakGlassApproval{alice}},
+		{"same approver twice", []BreakGlassApproval{alice, alice}},
+		{"approved by the subject", []BreakGlassApproval{alice, carol}},
+		{"forged approval", []BreakGlassApproval{alice, {Approver: "bob", Signature: alice.Signature}}},
+	}
+	for _, test := range tests {
+		if _, err := tok.SealBreakGlass(r, test.approvals...); err != ErrApprovalsRequired {
+			t.Errorf("SealBreakGlass() with %s returned %v; expected %s", test.name, err, ErrApprovalsRequired)
+		}
+	}
+	other := *r
+	other.TTL = time.Hour
+	if _, err := tok.SealBreakGlass(&other, approve("alice", &other), approve("bob", &other)); !errors.Is(err, ErrInvalidClaims) {
+		t.Errorf("SealBreakGlass() with a ttl above the maximum returned %v; expected %s", err, ErrInvalidClaims)
+	}
+
+	sealed, err := tok.SealBreakGlass(r, bob, alice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := tok.UnsealClaims(sealed)
+	if err != nil || c.Subject != "carol" || c.Purpose != PurposeBreakGlass || c.ID != r.ID {
+		t.Fatalf("UnsealClaims() = %+v, %v; expected a break-glass token for carol", c, err)
+	}
+	if len(audited) != 1 || string(audited[0].Data) != `{"reason":"database outage","approvers":["alice","bob"]}` {
+		t.Errorf("audit hook called with %v; expected the break-glass claims", audited)
+	}
+	unaudited, err := NewTokener(key, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := unaudited.UnsealClaims(sealed); err != ErrBreakGlassUnaudited {
+		t.Errorf("UnsealClaims() without an audit hook returned %v; expected %s", err, ErrBreakGlassUnaudited)
+	}
+	if _, err := unaudited.UnsealSubject(sealed); err != ErrBreakGlassUnaudited {
+		t.Errorf("UnsealSubject() without an audit hook returned %v; expected %s", err, ErrBreakGlassUnaudited)
+	}
+
+	if _, err := tok.SealClaims(&Claims{Subject: "carol", Purpose: PurposeBreakGlass}); !errors.Is(err, ErrInvalidClaims) {
+		t.Errorf("SealClaims() with PurposeBreakGlass returned %v; expected %s", err, ErrInvalidClaims)
+	}
+
+	setNow(timeNow().Add(11 * time.Minute))
+	if _, err := tok.SealBreakGlass(r, alice, bob); err != ErrApprovalsRequired {
+		t.Errorf("SealBreakGlass() after ApproveBy returned %v; expected %s", err, ErrApprovalsRequired)
+	}
+	setNow(timeNow().Add(5 * time.Minute))
+	if _, err := tok.UnsealClaims(sealed); err != ErrTokenExpired {
+		t.Errorf("UnsealClaims() after the ttl returned %v; expected %s", err, ErrTokenExpired)
+	}
+}
+
+func TestWithBreakGlassDuplicateKeys(t *testing.T) {
+	pub, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	approvers := map[string]ed25519.PublicKey{"alice": pub, "alias": pub}
+	if _, err := NewTokener(key, time.Hour, WithBreakGlass(approvers, time.Hour)); err == nil {
+		t.Error("NewTokener(WithBreakGlass()) with two approvers sharing a key returned nil error")
+	}
+}
+
+// TestBreakGlassPayload tests that Unseal accepts an opaque payload
+// that looks like the claims of a break-glass token.
+func TestBreakGlassPayload(t *testing.T) {
+	tok, err := NewTokener(key, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := `{"pur":"` + PurposeBreakGlass + `"}`
+	sealed, err := tok.SealString(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p, err := tok.UnsealString(sealed); p != payload || err != nil {
+		t.Errorf("UnsealString(%q) = %q, %v; expected %q, <nil>", sealed, p, err, payload)
+	}
+}
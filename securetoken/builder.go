@@ -0,0 +1,121 @@
+package securetoken
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// ErrInvalidClaims is returned by ClaimsBuilder when the claims being built
+// are not valid.
+var ErrInvalidClaims = errors.New("securetoken: invalid claims")
+
+// A ClaimsBuilder builds Claims, e.g.
+//
+//	sealed, err := securetoken.NewClaims().Subject(id).TTL(time.Hour).Scope("read").Seal(tok)
+//
+// The first error encountered is returned by Claims or Seal.
+type ClaimsBuilder struct {
+	c   Claims
+	err error
+}
+
+// NewClaims returns a builder of empty Claims.
+func NewClaims() *ClaimsBuilder {
+	return &ClaimsBuilder{}
+}
+
+// ID sets the ID of the claims.
+func (b *ClaimsBuilder) ID(id string) *ClaimsBuilder {
+	b.c.ID = id
+	return b
+}
+
+// Subject sets the Subject of the claims.
+func (b *ClaimsBuilder) Subject(subject string) *ClaimsBuilder {
+	b.c.Subject = subject
+	return b
+}
+
+// Audience sets the Audience of the claims.
+func (b *ClaimsBuilder) Audience(audience string) *ClaimsBuilder {
+	b.c.Audience = audience
+	return b
+}
+
+// Purpose sets the Purpose of the claims.
+func (b *ClaimsBuilder) Purpose(purpose string) *ClaimsBuilder {
+	b.c.Purpose = purpose
+	return b
+}
+
+// Scope adds scopes to the claims.
+// Scopes must be non-empty and must not contain spaces.
+func (b *ClaimsBuilder) Scope(scopes ...string) *ClaimsBuilder {
+	for _, s := range scopes {
+		if s == "" || strings.ContainsAny(s, " \t\r\n") {
+			b.fail(ErrInvalidClaims)
+		}
+	}
+	b.c.Scopes = append(b.c.Scopes, scopes...)
+	return b
+}
+
+// TTL sets the TTL of the claims. It must be positive.
+func (b *ClaimsBuilder) TTL(ttl time.Duration) *ClaimsBuilder {
+	if ttl <= 0 {
+		b.fail(ErrInvalidClaims)
+	}
+	b.c.TTL = ttl
+	return b
+}
+
+// Auth records that the subject authenticated with methods at t (see Claims.SetAuth).
+func (b *ClaimsBuilder) Auth(t time.Time, methods ...string) *ClaimsBuilder {
+	b.c.SetAuth(t, methods...)
+	return b
+}
+
+// Actor records that actor is acting as the subject (see Claims.Impersonate).
+func (b *ClaimsBuilder) Actor(actor string) *ClaimsBuilder {
+	b.c.Actor = &Actor{Subject: actor, Actor: b.c.Actor}
+	return b
+}
+
+// Data sets the Data of the claims to v encoded as JSON.
+func (b *ClaimsBuilder) Data(v interface{}) *ClaimsBuilder {
+	buf, err := json.Marshal(v)
+	if err != nil {
+		b.fail(err)
+	}
+	b.c.Data = buf
+	return b
+}
+
+// Claims returns the claims that have been built.
+func (b *ClaimsBuilder) Claims() (*Claims, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	if b.c.Purpose == PurposeCanary {
+		return nil, ErrInvalidClaims
+	}
+	c := b.c
+	return &c, nil
+}
+
+// Seal seals the claims that have been built with t.
+func (b *ClaimsBuilder) Seal(t ClaimsSealer) ([]byte, error) {
+	c, err := b.Claims()
+	if err != nil {
+		return nil, err
+	}
+	return t.SealClaims(c)
+}
+
+func (b *ClaimsBuilder) fail(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
@@ -0,0 +1,55 @@
+package securetoken
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestClaimsBuilder(t *testing.T) {
+	now := time.Unix(1000, 0)
+	setNow(now)
+	defer restoreNow()
+	tok, err := NewTokener(key, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := NewClaims().
+		Subject("alice").
+		TTL(ttl/2).
+		Scope("orders:read", "orders:write").
+		Data(map[string]int{"n": 1}).
+		Seal(tok)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := tok.UnsealClaims(sealed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Subject != "alice" || c.TTL != ttl/2 || !reflect.DeepEqual(c.Scopes, []string{"orders:read", "orders:write"}) || string(c.Data) != `{"n":1}` {
+		t.Errorf("UnsealClaims() = %+v; expected the built claims", c)
+	}
+
+	setNow(now.Add(ttl * 3 / 4))
+	if c, err := tok.UnsealClaims(sealed); err != ErrTokenExpired {
+		t.Errorf("UnsealClaims() after claims TTL = %+v, %v; expected %s", c, err, ErrTokenExpired)
+	}
+}
+
+func TestClaimsBuilderInvalid(t *testing.T) {
+	tests := []*ClaimsBuilder{
+		NewClaims().TTL(-time.Second),
+		NewClaims().Scope(""),
+		NewClaims().Scope("a b"),
+		NewClaims().Purpose(PurposeCanary),
+	}
+	for i, b := range tests {
+		if c, err := b.Claims(); err != ErrInvalidClaims {
+			t.Errorf("%d: Claims() = %+v, %v; expected %s", i, c, err, ErrInvalidClaims)
+		}
+	}
+	if _, err := NewClaims().Data(func() {}).Claims(); err == nil {
+		t.Error("Claims() with unencodable Data returned no error")
+	}
+}
@@ -0,0 +1,117 @@
+package securetoken
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// PurposeCanary is the purpose of canary tokens.
+const PurposeCanary = "securetoken.canary"
+
+// ErrCanary is returned by UnsealClaims and UnsealSubject for canary tokens.
+var ErrCanary = errors.New("securetoken: canary token")
+
+// canaryMarker is how the purpose of a canary token appears in its payload.
+var canaryMarker = []byte(`"pur":"` + PurposeCanary + `"`)
+
+// SealCanary seals a canary token: a token that is never accepted,
+// meant to be planted where only an attacker would find it, such as in
+// backups or logs. c identifies where the canary was planted;
+// its Purpose is overwritten with PurposeCanary.
+// UnsealClaims and UnsealSubject call the canary hook of the Tokener and
+// return ErrCanary when they unseal a canary token, even an expired one.
+// Unseal does not look for canaries: its payloads are opaque and may be
+// JSON that a user controls, who must not be able to trip the hook.
+func (t *Tokener) SealCanary(c Claims) ([]byte, error) {
+	c.Purpose = PurposeCanary
+	return t.SealClaims(&c)
+}
+
+// WithCanaryHook returns an Option that makes UnsealClaims and UnsealSubject call
+// hook with the claims of every canary token they unseal.
+// The use of a canary token is a strong sign of a breach.
+func WithCanaryHook(hook func(*Claims)) Option {
+	return func(t *Tokener) error {
+		t.canaryHook = hook
+		return nil
+	}
+}
+
+// tripCanary reports whether plaintext is the payload of a canary token,
+// and if so calls the canary hook.
+func (t *Tokener) tripCanary(plaintext []byte, raw *RawToken) bool {
+	if !bytes.Contains(plaintext, canaryMarker) {
+		return false
+	}
+	c := &Claims{}
+	if err := json.Unmarshal(plaintext, c); err != nil || c.Purpose != PurposeCanary {
+		return false
+	}
+	c.IssuedAt = raw.Timestamp
+	if t.canaryHook != nil {
+		t.canaryHook(c)
+	}
+	return true
+}
+
+// A HoneytokenFactory mints honeytokens: canary tokens that look like the
+// credentials of real users, to be seeded as decoys in places that
+// attackers look, such as configuration files, wikis and code repositories.
+// Each honeytoken has a random ID and a fake subject.
+type HoneytokenFactory struct {
+	Tokener *Tokener
+
+	// Subject returns the fake subject of each honeytoken.
+	// If nil, subjects are random numeric user ids.
+	Subject func() string
+
+	// Audience and Scopes are copied into every honeytoken
+	// to make it look like a real token.
+	Audience string
+	Scopes   []string
+}
+
+// Honeytoken is the Data of the claims of a honeytoken,
+// which the canary hook receives.
+type Honeytoken struct {
+	Location string `json:"loc"`
+}
+
+// Mint returns a honeytoken to plant at location,
+// which is passed back to the canary hook if the honeytoken is used.
+func (f *HoneytokenFactory) Mint(location string) ([]byte, error) {
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+	subject, err := f.subject()
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(Honeytoken{Location: location})
+	if err != nil {
+		return nil, err
+	}
+	return f.Tokener.SealCanary(Claims{
+		ID:       id,
+		Subject:  subject,
+		Audience: f.Audience,
+		Scopes:   f.Scopes,
+		Data:     data,
+	})
+}
+
+func (f *HoneytokenFactory) subject() (string, error) {
+	if f.Subject != nil {
+		return f.Subject(), nil
+	}
+	n, err := rand.Int(rand.Reader, big.NewInt(1e9))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprint(n.Int64() + 1e9), nil
+}
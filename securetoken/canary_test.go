@@ -0,0 +1,90 @@
+package securetoken
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestCanary(t *testing.T) {
+	var tripped []*Claims
+	tok, err := NewTokener(key, ttl, WithCanaryHook(func(c *Claims) {
+		tripped = append(tripped, c)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := tok.SealCanary(Claims{Subject: "backup-2024-01"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c, err := tok.UnsealClaims(sealed); c != nil || err != ErrCanary {
+		t.Errorf("UnsealClaims(%q) = %+v, %v; expected <nil>, %s", sealed, c, err, ErrCanary)
+	}
+	if len(tripped) != 1 || tripped[0].Subject != "backup-2024-01" {
+		t.Errorf("canary hook called with %+v; expected one call for backup-2024-01", tripped)
+	}
+}
+
+func TestHoneytokenFactory(t *testing.T) {
+	setNow(time.Unix(1000, 0))
+	defer restoreNow()
+
+	var tripped []*Claims
+	tok, err := NewTokener(key, ttl, WithCanaryHook(func(c *Claims) {
+		tripped = append(tripped, c)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &HoneytokenFactory{Tokener: tok, Scopes: []string{"admin"}}
+	sealed, err := f.Mint("wiki/deploy-notes")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Detected even once expired.
+	setNow(time.Unix(1000, 0).Add(2 * ttl))
+	if c, err := tok.UnsealClaims(sealed); c != nil || err != ErrCanary {
+		t.Errorf("UnsealClaims(%q) = %+v, %v; expected <nil>, %s", sealed, c, err, ErrCanary)
+	}
+	if len(tripped) != 1 {
+		t.Fatalf("canary hook called %d times; expected 1", len(tripped))
+	}
+	c := tripped[0]
+	var h Honeytoken
+	if err := json.Unmarshal(c.Data, &h); err != nil || h.Location != "wiki/deploy-notes" {
+		t.Errorf("honeytoken data = %s, %v; expected location wiki/deploy-notes", c.Data, err)
+	}
+	if c.ID == "" || len(c.Subject) != 10 || len(c.Scopes) != 1 || !c.IssuedAt.Equal(time.Unix(1000, 0)) {
+		t.Errorf("honeytoken claims = %+v; expected an ID, a 10 digit subject, the scopes and the seal time", c)
+	}
+
+	// A token whose data merely contains the marker is not a canary.
+	data := json.RawMessage(`{` + string(canaryMarker) + `}`)
+	sealed, err = tok.SealClaims(&Claims{Subject: "42", Data: data})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tok.UnsealClaims(sealed); err != nil {
+		t.Errorf("UnsealClaims(%q) = %v; expected <nil>", sealed, err)
+	}
+}
+
+// TestCanaryPayload tests that Unseal does not trip the canary hook on an
+// opaque payload that looks like a canary.
+func TestCanaryPayload(t *testing.T) {
+	tripped := false
+	tok, err := NewTokener(key, ttl, WithCanaryHook(func(*Claims) { tripped = true }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := `{"pur":"` + PurposeCanary + `"}`
+	sealed, err := tok.SealString(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p, err := tok.UnsealString(sealed); p != payload || err != nil || tripped {
+		t.Errorf("UnsealString(%q) = %q, %v with hook called %t; expected %q, <nil> without the hook", sealed, p, err, tripped, payload)
+	}
+}
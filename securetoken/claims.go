@@ -0,0 +1,186 @@
+package securetoken
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Claims are the structured contents of a token sealed by SealClaims.
+type Claims struct {
+	// ID uniquely identifies the token.
+	ID string `json:"jti,omitempty"`
+
+	// Subject identifies the principal that the token is about, such as a user id.
+	Subject string `json:"sub,omitempty"`
+
+	// Audience identifies the recipient that the token is intended for.
+	Audience string `json:"aud,omitempty"`
+
+	// Purpose identifies what the token is for.
+	Purpose string `json:"pur,omitempty"`
+
+	// Scopes lists what the token allows its bearer to do, e.g. "orders:read".
+	Scopes []string `json:"scp,omitempty"`
+
+	// TTL, if positive, is how long the token is valid for.
+	// It can only shorten the ttl of the Tokener, unless the Tokener
+	// was created WithEmbeddedTTL.
+	TTL time.Duration `json:"ttl,omitempty"`
+
+	// SessionVersion is the version of the subject's sessions
+	// that the token belongs to (see package sessions).
+	SessionVersion uint64 `json:"sv,omitempty"`
+
+	// AuthMethods lists how the subject authenticated (amr),
+	// e.g. AuthPassword and AuthMFA.
+	AuthMethods []string `json:"amr,omitempty"`
+
+	// AuthTime is when the subject authenticated, in seconds since the Unix epoch.
+	AuthTime int64 `json:"auth_time,omitempty"`
+
+	// Parents lists the IDs of the tokens that the token was derived from
+	// by DeriveChild, nearest first. The token is revoked with any of them.
+	Parents []string `json:"par,omitempty"`
+
+	// Actor, if not nil, is the principal acting on behalf of Subject (act),
+	// such as a support agent impersonating a user.
+	Actor *Actor `json:"act,omitempty"`
+
+	// Confirmation, if not nil, binds the token to a key held by the client
+	// (cnf), which must prove possession of it (see VerifyProof).
+	Confirmation *Confirmation `json:"cnf,omitempty"`
+
+	// Context is a small map of metadata, such as the deployment or region
+	// that sealed the token, in the style of a KMS encryption context.
+	// It is authenticated along with the rest of the claims, can be checked
+	// by WithExpectedContext and is passed to the audit hook.
+	// See WithEncryptionContext.
+	Context map[string]string `json:"ctx,omitempty"`
+
+	// Data is application defined JSON.
+	Data json.RawMessage `json:"dat,omitempty"`
+
+	// IssuedAt is the time that the token was sealed.
+	// It is set by UnsealClaims and is not part of the payload.
+	IssuedAt time.Time `json:"-"`
+
+	// Stale reports that the token is older than the soft ttl of the
+	// Tokener (see WithSoftTTL). It is set by UnsealClaims and is not
+	// part of the payload.
+	Stale bool `json:"-"`
+}
+
+// A ClaimsSealer seals Claims.
+type ClaimsSealer interface {
+	SealClaims(c *Claims) ([]byte, error)
+}
+
+// A ClaimsUnsealer unseals Claims.
+type ClaimsUnsealer interface {
+	UnsealClaims(sealed []byte, opts ...UnsealOption) (*Claims, error)
+}
+
+// A ClaimsSealUnsealer seals and unseals Claims.
+// It is implemented by *Tokener.
+type ClaimsSealUnsealer interface {
+	ClaimsSealer
+	ClaimsUnsealer
+}
+
+var _ ClaimsSealUnsealer = (*Tokener)(nil)
+
+// SealClaims seals c as JSON.
+// It returns the error of the first WithBeforeSeal hook that rejects c,
+// or of the schema of its purpose (see WithClaimsSchema).
+func (t *Tokener) SealClaims(c *Claims) ([]byte, error) {
+	if c.Purpose == PurposeBreakGlass {
+		err := fmt.Errorf("%w: break-glass tokens are sealed by SealBreakGlass", ErrInvalidClaims)
+		t.reportSeal(c, 0, 0, err)
+		return nil, err
+	}
+	tok, n, err := t.sealClaims(c)
+	t.reportSeal(c, n, len(tok), err)
+	return tok, err
+}
+
+// sealClaims is SealClaims without the metrics hook.
+// It also returns the length of the payload.
+func (t *Tokener) sealClaims(c *Claims) ([]byte, int, error) {
+	if err := t.runBeforeSeal(c); err != nil {
+		return nil, 0, err
+	}
+	c, err := t.addContext(c)
+	if err != nil {
+		return nil, 0, err
+	}
+	c = t.embedTTLIn(c)
+	payload, err := json.Marshal(c)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := t.checkClaims(c, len(payload)); err != nil {
+		return nil, 0, err
+	}
+	tok, err := t.appendSeal(nil, payload, nil)
+	return tok, len(payload), err
+}
+
+// UnsealClaims unseals a token produced by SealClaims.
+// opts adjust the checks made for this call only.
+func (t *Tokener) UnsealClaims(sealed []byte, opts ...UnsealOption) (*Claims, error) {
+	return t.UnsealClaimsFor("", sealed, opts...)
+}
+
+// UnsealClaimsFor is similar to UnsealClaims except failures are
+// counted against caller, as in UnsealFor.
+func (t *Tokener) UnsealClaimsFor(caller string, sealed []byte, opts ...UnsealOption) (*Claims, error) {
+	cfg := newUnsealConfig(opts)
+	c, err := t.unsealClaims(caller, sealed, cfg)
+	t.logUnseal(caller, len(sealed), c, err)
+	t.reportUnseal(c, cfg, len(sealed), err)
+	return c, err
+}
+
+func (t *Tokener) unsealClaims(caller string, sealed []byte, cfg *unsealConfig) (*Claims, error) {
+	cfg.claimsTTL = t.embedTTL
+	cfg.claims = true
+	payload, raw, err := t.unsealFor(caller, sealed, cfg)
+	if err != nil {
+		return nil, err
+	}
+	c := &Claims{}
+	if err := json.Unmarshal(payload, c); err != nil {
+		return nil, ErrTokenInvalid
+	}
+	if err := t.checkBreakGlass(c); err != nil {
+		return nil, err
+	}
+	if err := t.checkClaims(c, len(payload)); err != nil {
+		return nil, err
+	}
+	c.IssuedAt = raw.Timestamp
+	c.Stale = cfg.isStale
+	if !cfg.ignoreExpiry && (t.claimsExpired(c) || t.policyExpired(c)) {
+		return nil, ErrTokenExpired
+	}
+	if cfg.hasAudience && c.Audience != cfg.audience {
+		return nil, ErrWrongAudience
+	}
+	if err := cfg.checkContext(c); err != nil {
+		return nil, err
+	}
+	if err := t.checkRevoked(c); err != nil {
+		return nil, err
+	}
+	if !cfg.ignoreExpiry && c.delegationExpired(t.now()) {
+		return nil, ErrTokenExpired
+	}
+	if err := t.runAfterUnseal(c, caller, cfg); err != nil {
+		return nil, err
+	}
+	if t.auditHook != nil {
+		t.auditHook(c)
+	}
+	return c, nil
+}
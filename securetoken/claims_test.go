@@ -0,0 +1,47 @@
+package securetoken
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestSealUnsealClaims(t *testing.T) {
+	setNow(time.Unix(1, 0))
+	defer restoreNow()
+
+	tok, err := NewTokener(key, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Claims{
+		ID:       "id",
+		Subject:  "alice",
+		Audience: "api",
+		Purpose:  "session",
+		Data:     json.RawMessage(`{"admin":true}`),
+	}
+	sealed, err := tok.SealClaims(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unsealed, err := tok.UnsealClaims(sealed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unsealed.ID != c.ID || unsealed.Subject != c.Subject || unsealed.Audience != c.Audience ||
+		unsealed.Purpose != c.Purpose || string(unsealed.Data) != string(c.Data) {
+		t.Errorf("UnsealClaims() = %+v; expected %+v", unsealed, c)
+	}
+	if !unsealed.IssuedAt.Equal(time.Unix(1, 0)) {
+		t.Errorf("UnsealClaims() IssuedAt = %s; expected %s", unsealed.IssuedAt, time.Unix(1, 0))
+	}
+
+	notClaims, err := tok.Seal([]byte("not json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c, err := tok.UnsealClaims(notClaims); c != nil || err != ErrTokenInvalid {
+		t.Errorf("UnsealClaims(%q) = %+v, %v; expected <nil>, %s", notClaims, c, err, ErrTokenInvalid)
+	}
+}
@@ -0,0 +1,38 @@
+package securetoken
+
+import "time"
+
+// Clone returns a copy of t with opts applied.
+// The copy shares the keyring, nonce source and failure limiter of t,
+// so it is cheap and does not hold another copy of any key.
+// Options that replace one of those (e.g. WithNonceSource) only
+// affect the copy.
+func (t *Tokener) Clone(opts ...Option) (*Tokener, error) {
+	c := *t
+	p := t.policy()
+	c.ttl, c.softTTL, c.leeway, c.minVersion = p.ttl, p.softTTL, p.leeway, p.minVersion
+	c.selfTest = false
+	for _, opt := range opts {
+		if err := opt(&c); err != nil {
+			return nil, err
+		}
+	}
+	if err := c.checkOptions(); err != nil {
+		return nil, err
+	}
+	c.publishPolicy()
+	return &c, nil
+}
+
+// WithTTL returns a copy of t whose tokens are valid for ttl, such as
+// a short lived Tokener for CSRF tokens next to one for sessions.
+// It is a shorthand for Clone with only the ttl changed.
+// Tokens sealed by either Tokener are accepted by the other unless
+// their purposes differ (see WithPurpose).
+func (t *Tokener) WithTTL(ttl time.Duration) *Tokener {
+	c := *t
+	p := t.policy()
+	c.ttl, c.softTTL, c.leeway, c.minVersion = ttl, p.softTTL, p.leeway, p.minVersion
+	c.publishPolicy()
+	return &c
+}
@@ -0,0 +1,51 @@
+package securetoken
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWithTTL(t *testing.T) {
+	setNow(time.Unix(1000, 0))
+	defer restoreNow()
+	tok, err := NewTokener(key, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	short := tok.WithTTL(ttl / 2)
+	sealed, err := short.Seal([]byte("csrf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	setNow(time.Unix(1000, 0).Add(ttl * 3 / 4))
+	if _, err := short.Unseal(sealed); err != ErrTokenExpired {
+		t.Errorf("Unseal(%q) with short ttl returned %v; expected %s", sealed, err, ErrTokenExpired)
+	}
+	if _, err := tok.Unseal(sealed); err != nil {
+		t.Errorf("Unseal(%q) with original ttl returned %v; expected <nil>", sealed, err)
+	}
+	if tok.keys != short.keys {
+		t.Error("WithTTL copied the keyring; expected it to be shared")
+	}
+}
+
+func TestClone(t *testing.T) {
+	tok, err := NewTokener(key, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	csrf, err := tok.Clone(WithPurpose("csrf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := csrf.Seal([]byte("x"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tok.Unseal(sealed); err != ErrTokenInvalid {
+		t.Errorf("Unseal(%q) without purpose returned %v; expected %s", sealed, err, ErrTokenInvalid)
+	}
+	if _, err := tok.Clone(WithMinVersion(9)); err == nil {
+		t.Error("Clone(WithMinVersion(9)) returned no error")
+	}
+}
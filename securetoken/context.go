@@ -0,0 +1,75 @@
+package securetoken
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrWrongContext is returned by UnsealClaims when WithExpectedContext is
+// given and the encryption context of the token does not match it.
+var ErrWrongContext = errors.New("securetoken: token encryption context does not match")
+
+var errContextNeedsClaims = errors.New("securetoken: WithExpectedContext requires UnsealClaims")
+
+// WithEncryptionContext returns an Option that adds the entries of ctx to
+// the Context of every token sealed by SealClaims, e.g. to tag tokens with
+// the deployment or region that sealed them. Claims that already have one
+// of the keys with a different value are rejected with ErrInvalidClaims.
+// Uses of the option accumulate.
+func WithEncryptionContext(ctx map[string]string) Option {
+	return func(t *Tokener) error {
+		merged := make(map[string]string, len(t.encContext)+len(ctx))
+		for k, v := range t.encContext {
+			merged[k] = v
+		}
+		for k, v := range ctx {
+			merged[k] = v
+		}
+		t.encContext = merged
+		return nil
+	}
+}
+
+// WithExpectedContext returns an UnsealOption that makes UnsealClaims return
+// ErrWrongContext unless the Context of the claims has every entry of ctx.
+// Unseal has no claims to check, so it rejects every token when given this option.
+func WithExpectedContext(ctx map[string]string) UnsealOption {
+	return func(cfg *unsealConfig) {
+		cfg.context = ctx
+		if cfg.context == nil {
+			cfg.context = map[string]string{}
+		}
+	}
+}
+
+// addContext returns the claims to seal for c, which have the encryption
+// context of t added to them. c is not modified.
+func (t *Tokener) addContext(c *Claims) (*Claims, error) {
+	if len(t.encContext) == 0 {
+		return c, nil
+	}
+	ctx := make(map[string]string, len(c.Context)+len(t.encContext))
+	for k, v := range c.Context {
+		ctx[k] = v
+	}
+	for k, v := range t.encContext {
+		if cv, ok := ctx[k]; ok && cv != v {
+			return nil, fmt.Errorf("%w: context %q is %q rather than %q", ErrInvalidClaims, k, cv, v)
+		}
+		ctx[k] = v
+	}
+	withContext := *c
+	withContext.Context = ctx
+	return &withContext, nil
+}
+
+// checkContext returns ErrWrongContext if c does not have the context
+// expected by cfg.
+func (cfg *unsealConfig) checkContext(c *Claims) error {
+	for k, v := range cfg.context {
+		if cv, ok := c.Context[k]; !ok || cv != v {
+			return ErrWrongContext
+		}
+	}
+	return nil
+}
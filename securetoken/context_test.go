@@ -0,0 +1,56 @@
+package securetoken
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestEncryptionContext(t *testing.T) {
+	var audited *Claims
+	tok, err := NewTokener(key, ttl,
+		WithEncryptionContext(map[string]string{"region": "eu"}),
+		WithEncryptionContext(map[string]string{"deployment": "blue"}),
+		WithAuditHook(func(c *Claims) { audited = c }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Claims{Subject: "alice", Context: map[string]string{"tenant": "acme"}}
+	sealed, err := tok.SealClaims(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Context) != 1 {
+		t.Errorf("SealClaims() modified the caller's context to %v", c.Context)
+	}
+
+	want := map[string]string{"region": "eu", "deployment": "blue", "tenant": "acme"}
+	got, err := tok.UnsealClaims(sealed, WithExpectedContext(map[string]string{"region": "eu", "tenant": "acme"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Context) != len(want) {
+		t.Errorf("UnsealClaims(%q).Context = %v; expected %v", sealed, got.Context, want)
+	}
+	for k, v := range want {
+		if got.Context[k] != v {
+			t.Errorf("UnsealClaims(%q).Context = %v; expected %v", sealed, got.Context, want)
+		}
+	}
+	if audited == nil || audited.Context["region"] != "eu" {
+		t.Errorf("audit hook got %+v; expected the encryption context", audited)
+	}
+
+	for _, ctx := range []map[string]string{{"region": "us"}, {"zone": "a"}} {
+		if _, err := tok.UnsealClaims(sealed, WithExpectedContext(ctx)); err != ErrWrongContext {
+			t.Errorf("UnsealClaims(%q, WithExpectedContext(%v)) returned %v; expected %s", sealed, ctx, err, ErrWrongContext)
+		}
+	}
+	if _, err := tok.Unseal(sealed, WithExpectedContext(nil)); err != errContextNeedsClaims {
+		t.Errorf("Unseal(%q, WithExpectedContext(nil)) returned %v; expected %s", sealed, err, errContextNeedsClaims)
+	}
+
+	_, err = tok.SealClaims(&Claims{Context: map[string]string{"region": "us"}})
+	if !errors.Is(err, ErrInvalidClaims) {
+		t.Errorf("SealClaims() with a conflicting context returned %v; expected %s", err, ErrInvalidClaims)
+	}
+}
@@ -0,0 +1,51 @@
+package securetoken
+
+import "testing"
+
+func TestCorrelationID(t *testing.T) {
+	corrKey := []byte("correlation key!")
+	tok, err := NewTokener(key, ttl, WithCorrelationKey(corrKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := NewTokener(key2, ttl, WithCorrelationKey(corrKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	seal := func(tok *Tokener, c *Claims) []byte {
+		sealed, err := tok.SealClaims(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sealed
+	}
+
+	a := tok.CorrelationID(seal(tok, &Claims{ID: "1", Subject: "alice"}))
+	if a == "" {
+		t.Fatal("CorrelationID() = \"\"")
+	}
+	if b := other.CorrelationID(seal(other, &Claims{ID: "1", Subject: "bob"})); b != a {
+		t.Errorf("CorrelationID() of tokens with the same ID = %q and %q; expected them to be equal", a, b)
+	}
+	if b := tok.CorrelationID(seal(tok, &Claims{ID: "2", Subject: "alice"})); b == a {
+		t.Errorf("CorrelationID() of tokens with different IDs = %q for both", a)
+	}
+	s := tok.CorrelationID(seal(tok, &Claims{Subject: "alice"}))
+	if s == "" || s == a || s != tok.ClaimsCorrelationID(&Claims{Subject: "alice"}) {
+		t.Errorf("CorrelationID() of a token without an ID = %q; expected the ID of its subject", s)
+	}
+
+	if id := tok.CorrelationID([]byte("garbage")); id != "" {
+		t.Errorf("CorrelationID(garbage) = %q; expected \"\"", id)
+	}
+	plain, err := NewTokener(key, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id := plain.CorrelationID(seal(plain, &Claims{ID: "1"})); id != "" {
+		t.Errorf("CorrelationID() without a correlation key = %q; expected \"\"", id)
+	}
+	if _, err := NewTokener(key, ttl, WithCorrelationKey([]byte("short"))); err == nil {
+		t.Error("NewTokener(WithCorrelationKey(short)) returned nil error")
+	}
+}
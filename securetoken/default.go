@@ -0,0 +1,40 @@
+package securetoken
+
+import (
+	"errors"
+	"time"
+)
+
+// Default is the Tokener used by the package level Seal and Unseal functions.
+// It is set by Init, which should be called once before any token is sealed.
+var Default *Tokener
+
+var errNoDefault = errors.New("securetoken: Init has not been called")
+
+// Init sets Default to a new Tokener created by NewTokener.
+// It is meant for small programs; larger ones should create
+// and pass around their own Tokeners.
+func Init(key []byte, ttl time.Duration, opts ...Option) error {
+	t, err := NewTokener(key, ttl, opts...)
+	if err != nil {
+		return err
+	}
+	Default = t
+	return nil
+}
+
+// Seal seals plaintext with Default.
+func Seal(plaintext []byte) ([]byte, error) {
+	if Default == nil {
+		return nil, errNoDefault
+	}
+	return Default.Seal(plaintext)
+}
+
+// Unseal unseals a token sealed by Seal with Default.
+func Unseal(sealed []byte, opts ...UnsealOption) ([]byte, error) {
+	if Default == nil {
+		return nil, errNoDefault
+	}
+	return Default.Unseal(sealed, opts...)
+}
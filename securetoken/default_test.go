@@ -0,0 +1,25 @@
+package securetoken
+
+import "testing"
+
+func TestDefault(t *testing.T) {
+	defer func(d *Tokener) { Default = d }(Default)
+
+	Default = nil
+	if _, err := Seal([]byte("x")); err != errNoDefault {
+		t.Errorf("Seal() before Init returned %v; expected %s", err, errNoDefault)
+	}
+	if err := Init([]byte("short"), ttl); err == nil {
+		t.Error("Init() with an invalid key returned no error")
+	}
+	if err := Init(key, ttl); err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := Seal([]byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plaintext, err := Unseal(sealed); string(plaintext) != "hello" || err != nil {
+		t.Errorf("Unseal(%q) = %q, %v; expected \"hello\", <nil>", sealed, plaintext, err)
+	}
+}
@@ -0,0 +1,44 @@
+package securetoken
+
+import "time"
+
+// Delegate unseals token and seals a copy in which actor acts on behalf of
+// the current holder until ttl from now, e.g. when service B calls service A
+// on behalf of the user that called B. The new token records the whole chain
+// (see Claims.Delegations), and it stops being accepted once any hop
+// has expired, as well as when the token itself does.
+// Like DeriveChild, the copy never outlives token, so ttl is capped at
+// the remaining lifetime of token.
+func (t *Tokener) Delegate(token []byte, actor string, ttl time.Duration) ([]byte, error) {
+	c, err := t.UnsealClaims(token)
+	if err != nil {
+		return nil, err
+	}
+	d, err := t.narrow(c, Restrictions{TTL: ttl})
+	if err != nil {
+		return nil, err
+	}
+	d.Actor = &Actor{Subject: actor, Expires: t.now().Add(d.TTL).Unix(), Actor: c.Actor}
+	return t.SealClaims(d)
+}
+
+// Delegations returns the actors of c, from the most recent to the original one.
+func (c *Claims) Delegations() []Actor {
+	var chain []Actor
+	for a := c.Actor; a != nil; a = a.Actor {
+		hop := *a
+		hop.Actor = nil
+		chain = append(chain, hop)
+	}
+	return chain
+}
+
+// delegationExpired reports whether any actor of c has expired at now.
+func (c *Claims) delegationExpired(now time.Time) bool {
+	for a := c.Actor; a != nil; a = a.Actor {
+		if a.Expires != 0 && now.Unix() >= a.Expires {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package securetoken
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDelegate(t *testing.T) {
+	now := time.Unix(1000, 0)
+	setNow(now)
+	defer restoreNow()
+	tok, err := NewTokener(key, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user, err := tok.SealClaims(&Claims{Subject: "carol"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	viaB, err := tok.Delegate(user, "service-b", 10*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	viaA, err := tok.Delegate(viaB, "service-a", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := tok.UnsealClaims(viaA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Actor{
+		{Subject: "service-a", Expires: now.Add(time.Minute).Unix()},
+		{Subject: "service-b", Expires: now.Add(10 * time.Minute).Unix()},
+	}
+	if c.Subject != "carol" || !reflect.DeepEqual(c.Delegations(), expected) {
+		t.Errorf("UnsealClaims() = %q with delegations %+v; expected carol with %+v", c.Subject, c.Delegations(), expected)
+	}
+
+	setNow(now.Add(2 * time.Minute))
+	if _, err := tok.UnsealClaims(viaA); err != ErrTokenExpired {
+		t.Errorf("UnsealClaims() after a hop expired returned %v; expected %s", err, ErrTokenExpired)
+	}
+	if _, err := tok.UnsealClaims(viaB); err != nil {
+		t.Errorf("UnsealClaims() of the shorter chain returned %v; expected <nil>", err)
+	}
+}
+
+// TestDelegateDoesNotOutliveToken tests that delegating a token can not
+// extend its lifetime.
+func TestDelegateDoesNotOutliveToken(t *testing.T) {
+	now := time.Unix(1000, 0)
+	setNow(now)
+	defer restoreNow()
+	tok, err := NewTokener(key, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := tok.SealClaims(&Claims{Subject: "carol"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		setNow(timeNow().Add(50 * time.Minute / 3))
+		if token, err = tok.Delegate(token, "service", time.Hour); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c, err := tok.UnsealClaims(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deadline := now.Add(time.Hour); c.IssuedAt.Add(c.TTL).After(deadline) || c.Actor.Expires > deadline.Unix() {
+		t.Errorf("delegated token expires at %s, actor at %d; expected neither after %s", c.IssuedAt.Add(c.TTL), c.Actor.Expires, deadline)
+	}
+	setNow(now.Add(time.Hour))
+	if _, err := tok.UnsealClaims(token); err != ErrTokenExpired {
+		t.Errorf("UnsealClaims() of a delegated token after the original expired returned %v; expected %s", err, ErrTokenExpired)
+	}
+	if _, err := tok.Delegate(token, "service", time.Hour); err != ErrTokenExpired {
+		t.Errorf("Delegate() of an expired token returned %v; expected %s", err, ErrTokenExpired)
+	}
+}
@@ -0,0 +1,84 @@
+package securetoken
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrNotDerivable is returned by DeriveChild when the parent token has no ID
+// or the restrictions would widen what the parent allows.
+var ErrNotDerivable = errors.New("securetoken: child would not be narrower than parent")
+
+// Restrictions narrow a child token derived by DeriveChild.
+type Restrictions struct {
+	// Scopes of the child, which must all be scopes of the parent.
+	// If it is nil, the child has the scopes of the parent.
+	Scopes []string
+
+	// Audience of the child. It can only be set if the parent has
+	// no audience or the same one.
+	Audience string
+
+	// TTL of the child. The child never outlives the parent,
+	// and a TTL of 0 makes it expire with the parent.
+	TTL time.Duration
+}
+
+// DeriveChild unseals parent and seals a child token for the same subject
+// that is narrowed by r, such as a per-request or per-job token.
+// The child records the ID of the parent (and its ancestors) in Parents,
+// so revoking the parent in the RevocationStore of the Tokener
+// (see WithRevocationStore) revokes the child too.
+func (t *Tokener) DeriveChild(parent []byte, r Restrictions) ([]byte, error) {
+	p, err := t.UnsealClaims(parent)
+	if err != nil {
+		return nil, err
+	}
+	if p.ID == "" {
+		return nil, ErrNotDerivable
+	}
+	c, err := t.narrow(p, r)
+	if err != nil {
+		return nil, err
+	}
+	return t.SealClaims(c)
+}
+
+// narrow returns a copy of p with a new ID, p as its nearest parent
+// (if p has an ID) and r applied.
+func (t *Tokener) narrow(p *Claims, r Restrictions) (*Claims, error) {
+	c := *p
+	var err error
+	c.ID, err = newID()
+	if err != nil {
+		return nil, err
+	}
+	if p.ID != "" {
+		c.Parents = append([]string{p.ID}, p.Parents...)
+	}
+	if r.Scopes != nil {
+		if p.RequireScopes(r.Scopes...) != nil {
+			return nil, ErrNotDerivable
+		}
+		c.Scopes = r.Scopes
+	}
+	if r.Audience != "" {
+		if p.Audience != "" && p.Audience != r.Audience {
+			return nil, ErrNotDerivable
+		}
+		c.Audience = r.Audience
+	}
+	ttl := t.policy().ttl
+	remaining := ttl - t.now().Sub(p.IssuedAt)
+	if p.TTL > 0 && p.TTL < ttl {
+		remaining = p.TTL - t.now().Sub(p.IssuedAt)
+	}
+	c.TTL = remaining
+	if r.TTL > 0 && r.TTL < remaining {
+		c.TTL = r.TTL
+	}
+	if c.TTL <= 0 {
+		return nil, ErrTokenExpired
+	}
+	return &c, nil
+}
@@ -0,0 +1,67 @@
+package securetoken
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDeriveChild(t *testing.T) {
+	now := time.Unix(1000, 0)
+	setNow(now)
+	defer restoreNow()
+	store := NewMemoryRevocationStore()
+	tok, err := NewTokener(key, ttl, WithRevocationStore(store))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parent, err := NewClaims().ID("p1").Subject("alice").Scope("read", "write").Seal(tok)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	setNow(now.Add(ttl / 2))
+	child, err := tok.DeriveChild(parent, Restrictions{Scopes: []string{"read"}, TTL: 2 * ttl})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := tok.UnsealClaims(child)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Subject != "alice" || c.ID == "p1" || !reflect.DeepEqual(c.Parents, []string{"p1"}) ||
+		!reflect.DeepEqual(c.Scopes, []string{"read"}) || c.TTL != ttl/2 {
+		t.Errorf("UnsealClaims(child) = %+v; expected a narrowed child of p1 expiring with it", c)
+	}
+
+	grandchild, err := tok.DeriveChild(child, Restrictions{TTL: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tok.DeriveChild(child, Restrictions{Scopes: []string{"write"}}); err != ErrNotDerivable {
+		t.Errorf("DeriveChild() widening scopes returned %v; expected %s", err, ErrNotDerivable)
+	}
+
+	if err := store.Revoke("p1", now.Add(ttl)); err != nil {
+		t.Fatal(err)
+	}
+	for _, sealed := range [][]byte{parent, child, grandchild} {
+		if _, err := tok.UnsealClaims(sealed); err != ErrTokenRevoked {
+			t.Errorf("UnsealClaims(%q) after revoking the parent returned %v; expected %s", sealed, err, ErrTokenRevoked)
+		}
+	}
+}
+
+func TestDeriveChildWithoutID(t *testing.T) {
+	tok, err := NewTokener(key, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parent, err := tok.SealClaims(&Claims{Subject: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tok.DeriveChild(parent, Restrictions{}); err != ErrNotDerivable {
+		t.Errorf("DeriveChild() of a parent without ID returned %v; expected %s", err, ErrNotDerivable)
+	}
+}
@@ -0,0 +1,47 @@
+package securetoken
+
+// WithEmbeddedTTL returns an Option that freezes the ttl of tokens sealed
+// by SealClaims into their claims, so that changing the ttl of the Tokener
+// (e.g. by Reconfigure) only affects tokens sealed afterwards rather than
+// extending or shortening the tokens that are already outstanding.
+//
+// SealClaims sets the TTL of the sealed claims to the ttl of the Tokener,
+// or to Claims.TTL if it is shorter, and UnsealClaims expires tokens by
+// their TTL alone. Tokens without a TTL, such as those sealed before the
+// option was used, still expire by the current ttl of the Tokener.
+// Seal and Unseal have no claims to embed a ttl in and are not affected.
+func WithEmbeddedTTL() Option {
+	return func(t *Tokener) error {
+		t.embedTTL = true
+		return nil
+	}
+}
+
+// embedTTLIn returns the claims to seal for c, which have the ttl of t
+// frozen into them if t was created WithEmbeddedTTL. c is not modified.
+func (t *Tokener) embedTTLIn(c *Claims) *Claims {
+	if !t.embedTTL {
+		return c
+	}
+	if ttl := t.policy().ttl; c.TTL <= 0 || c.TTL > ttl {
+		embedded := *c
+		embedded.TTL = ttl
+		return &embedded
+	}
+	return c
+}
+
+// claimsExpired reports whether c, which has IssuedAt set, has expired
+// by its TTL, or also by the ttl of t if t was created WithEmbeddedTTL
+// and c has no TTL (the Tokener ttl is otherwise checked by unseal).
+func (t *Tokener) claimsExpired(c *Claims) bool {
+	p := t.policy()
+	ttl := c.TTL
+	if ttl <= 0 {
+		if !t.embedTTL {
+			return false
+		}
+		ttl = p.ttl
+	}
+	return t.now().Sub(c.IssuedAt) > ttl+p.leeway
+}
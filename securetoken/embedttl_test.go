@@ -0,0 +1,75 @@
+package securetoken
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEmbeddedTTL(t *testing.T) {
+	setNow(time.Unix(1000, 0))
+	defer restoreNow()
+
+	tok, err := NewTokener(key, ttl, WithEmbeddedTTL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Claims{Subject: "alice"}
+	sealed, err := tok.SealClaims(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.TTL != 0 {
+		t.Errorf("SealClaims() set TTL of the caller's claims to %s", c.TTL)
+	}
+	short, err := tok.SealClaims(&Claims{Subject: "bob", TTL: ttl / 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tok.Reconfigure(WithTokenTTL(ttl / 4)); err != nil {
+		t.Fatal(err)
+	}
+	setNow(time.Unix(1000, 0).Add(ttl / 3))
+	if c, err := tok.UnsealClaims(sealed); err != nil || c.TTL != ttl {
+		t.Errorf("UnsealClaims(%q) after shortening the ttl = %+v, %v; expected TTL %s, <nil>", sealed, c, err, ttl)
+	}
+	if _, err := tok.UnsealClaims(short); err != nil {
+		t.Errorf("UnsealClaims(%q) after shortening the ttl returned %v", short, err)
+	}
+	if _, err := tok.Unseal(sealed); err != ErrTokenExpired {
+		t.Errorf("Unseal(%q) after shortening the ttl returned %v; expected %s", sealed, err, ErrTokenExpired)
+	}
+
+	if err := tok.Reconfigure(WithTokenTTL(2 * ttl)); err != nil {
+		t.Fatal(err)
+	}
+	setNow(time.Unix(1000, 0).Add(ttl + time.Second))
+	if _, err := tok.UnsealClaims(sealed); err != ErrTokenExpired {
+		t.Errorf("UnsealClaims(%q) after extending the ttl returned %v; expected %s", sealed, err, ErrTokenExpired)
+	}
+	if _, err := tok.UnsealClaims(sealed, WithIgnoreExpiry()); err != nil {
+		t.Errorf("UnsealClaims(%q, WithIgnoreExpiry()) returned %v", sealed, err)
+	}
+}
+
+func TestEmbeddedTTLLegacyClaims(t *testing.T) {
+	setNow(time.Unix(1000, 0))
+	defer restoreNow()
+
+	old, err := NewTokener(key, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := old.SealClaims(&Claims{Subject: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tok, err := NewTokener(key, ttl/2, WithEmbeddedTTL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	setNow(time.Unix(1000, 0).Add(ttl/2 + time.Second))
+	if _, err := tok.UnsealClaims(sealed); err != ErrTokenExpired {
+		t.Errorf("UnsealClaims(%q) of claims without a TTL returned %v; expected %s", sealed, err, ErrTokenExpired)
+	}
+}
@@ -0,0 +1,305 @@
+package securetoken
+
+import (
+	"encoding/base32"
+	"encoding/base64"
+	"errors"
+	"math"
+)
+
+// An Encoding converts sealed tokens to and from text.
+// EncodedLen must return the exact length of the encoding of n bytes, and
+// DecodedLen must return at least the number of bytes that n bytes decode to.
+// *base64.Encoding implements Encoding.
+//
+// Encode and Decode are called once per token with the whole token,
+// and dst never overlaps src, so an Encoding can wrap a vectorized
+// (e.g. SIMD) implementation of the same alphabet to speed up Seal and
+// Unseal; the encoding is a large part of their cost for small payloads.
+type Encoding interface {
+	EncodedLen(n int) int
+	Encode(dst, src []byte)
+	DecodedLen(n int) int
+	Decode(dst, src []byte) (n int, err error)
+}
+
+var _ Encoding = base64.URLEncoding
+
+// WithEncoding returns an Option that makes the Tokener encode tokens with enc
+// instead of base64url. Tokens are only unsealed by a Tokener with the same encoding.
+// With Base58, the maximum length defaults to the longest token Base58 decodes
+// (see WithMaxLength).
+func WithEncoding(enc Encoding) Option {
+	return func(t *Tokener) error {
+		t.encoding = enc
+		if enc == Base58 && t.maxLength == 0 {
+			t.maxLength = base58MaxDigits
+		}
+		return nil
+	}
+}
+
+var errBase58 = errors.New("securetoken: invalid base58")
+
+// Base58 is an Encoding with the Bitcoin alphabet, which leaves out 0, O, I and l,
+// for tokens that people read aloud or type.
+// The input is encoded as one big-endian number padded with leading 1s
+// (the zero digit) to a length that only depends on the input length,
+// so leading zero bytes are not encoded as in Bitcoin addresses.
+// Encoding and decoding take time quadratic in the length, so Decode
+// rejects input of more than 2048 digits (about 1.5 KB decoded):
+// Base58 is only meant for short tokens.
+var Base58 Encoding = base58{}
+
+// base58MaxDigits bounds the work of decoding unauthenticated input.
+const base58MaxDigits = 2048
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+var base58Digits = func() (digits [256]byte) {
+	for i := range digits {
+		digits[i] = 0xff
+	}
+	for i := 0; i < len(base58Alphabet); i++ {
+		digits[base58Alphabet[i]] = byte(i)
+	}
+	return
+}()
+
+type base58 struct{}
+
+// base58BitsPerDigit is log2(58).
+var base58BitsPerDigit = math.Log2(58)
+
+func (base58) EncodedLen(n int) int {
+	return int(math.Ceil(float64(n) * 8 / base58BitsPerDigit))
+}
+
+// DecodedLen returns the length of the input that encodes to n digits,
+// or 0 if there is none.
+func (e base58) DecodedLen(n int) int {
+	m := int(float64(n) * base58BitsPerDigit / 8)
+	if e.EncodedLen(m) == n {
+		return m
+	}
+	return 0
+}
+
+func (e base58) Encode(dst, src []byte) {
+	n := e.EncodedLen(len(src))
+	digits := dst[:n]
+	for i := range digits {
+		digits[i] = 0
+	}
+	// Multiply the digits, least significant last, by 256 and add each byte.
+	for _, b := range src {
+		carry := int(b)
+		for i := n - 1; i >= 0; i-- {
+			carry += int(digits[i]) << 8
+			digits[i] = byte(carry % 58)
+			carry /= 58
+		}
+	}
+	for i, d := range digits {
+		digits[i] = base58Alphabet[d]
+	}
+}
+
+func (e base58) Decode(dst, src []byte) (int, error) {
+	if len(src) > base58MaxDigits {
+		return 0, errBase58
+	}
+	n := e.DecodedLen(len(src))
+	if n == 0 && len(src) > 0 {
+		return 0, errBase58
+	}
+	out := dst[:n]
+	for i := range out {
+		out[i] = 0
+	}
+	for _, c := range src {
+		carry := int(base58Digits[c])
+		if carry == 0xff {
+			return 0, errBase58
+		}
+		for i := n - 1; i >= 0; i-- {
+			carry += int(out[i]) * 58
+			out[i] = byte(carry)
+			carry >>= 8
+		}
+		if carry != 0 {
+			return 0, errBase58
+		}
+	}
+	return n, nil
+}
+
+var errCrockford = errors.New("securetoken: invalid Crockford base32")
+
+// Crockford32 is an Encoding with Douglas Crockford's base32 alphabet
+// followed by its check symbol, for codes that people transcribe, such as
+// vouchers and invites. Decoding ignores case and hyphens, reads O as 0
+// and I and L as 1, and rejects codes whose check symbol does not match.
+var Crockford32 Encoding = crockford32{}
+
+const crockfordSymbols = "0123456789ABCDEFGHJKMNPQRSTVWXYZ*~$=U"
+
+var crockfordBase32 = base32.NewEncoding(crockfordSymbols[:32]).WithPadding(base32.NoPadding)
+
+// crockfordValues maps each symbol to its value, folding case and
+// ambiguous letters, or to 0xff.
+var crockfordValues = func() (values [256]byte) {
+	for i := range values {
+		values[i] = 0xff
+	}
+	for i := 0; i < len(crockfordSymbols); i++ {
+		c := crockfordSymbols[i]
+		values[c] = byte(i)
+		if 'A' <= c && c <= 'Z' {
+			values[c+'a'-'A'] = byte(i)
+		}
+	}
+	for _, c := range "Oo" {
+		values[c] = 0
+	}
+	for _, c := range "IiLl" {
+		values[c] = 1
+	}
+	return
+}()
+
+type crockford32 struct{}
+
+func (crockford32) EncodedLen(n int) int {
+	return crockfordBase32.EncodedLen(n) + 1
+}
+
+func (crockford32) DecodedLen(n int) int {
+	if n < 1 {
+		return 0
+	}
+	return crockfordBase32.DecodedLen(n - 1)
+}
+
+func (e crockford32) Encode(dst, src []byte) {
+	n := crockfordBase32.EncodedLen(len(src))
+	crockfordBase32.Encode(dst, src)
+	dst[n] = crockfordSymbols[crockfordCheck(dst[:n])]
+}
+
+// crockfordCheck returns the value of the number that the symbols
+// represent modulo 37.
+func crockfordCheck(symbols []byte) int {
+	check := 0
+	for _, c := range symbols {
+		check = (check*32 + int(crockfordValues[c])) % 37
+	}
+	return check
+}
+
+func (e crockford32) Decode(dst, src []byte) (int, error) {
+	symbols := make([]byte, 0, len(src))
+	for _, c := range src {
+		if c == '-' {
+			continue
+		}
+		v := crockfordValues[c]
+		if v == 0xff {
+			return 0, errCrockford
+		}
+		symbols = append(symbols, crockfordSymbols[v])
+	}
+	if len(symbols) == 0 {
+		return 0, errCrockford
+	}
+	digits, check := symbols[:len(symbols)-1], symbols[len(symbols)-1]
+	if crockfordSymbols[crockfordCheck(digits)] != check {
+		return 0, errCrockford
+	}
+	// Check symbols other than the last are rejected here.
+	n, err := crockfordBase32.Decode(dst, digits)
+	if err != nil {
+		return 0, errCrockford
+	}
+	return n, nil
+}
+
+var errBase45 = errors.New("securetoken: invalid base45")
+
+// Base45 is the Encoding of RFC 9285, whose alphabet is the alphanumeric
+// character set of QR codes. QR codes encode it in 5.5 bits per character
+// instead of the 8 bits per character that base64 needs, so tokens for
+// tickets and badges fit in noticeably smaller codes.
+// Its alphabet includes a space, so it is not suitable for URLs or cookies.
+var Base45 Encoding = base45{}
+
+const base45Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ $%*+-./:"
+
+var base45Values = func() (values [256]byte) {
+	for i := range values {
+		values[i] = 0xff
+	}
+	for i := 0; i < len(base45Alphabet); i++ {
+		values[base45Alphabet[i]] = byte(i)
+	}
+	return
+}()
+
+type base45 struct{}
+
+func (base45) EncodedLen(n int) int {
+	return n/2*3 + n%2*2
+}
+
+func (base45) DecodedLen(n int) int {
+	return n/3*2 + n%3/2
+}
+
+func (base45) Encode(dst, src []byte) {
+	for len(src) > 0 {
+		n, size := int(src[0]), 2
+		if len(src) > 1 {
+			n, size = n<<8|int(src[1]), 3
+		}
+		for i := 0; i < size; i++ {
+			dst[i] = base45Alphabet[n%45]
+			n /= 45
+		}
+		dst = dst[size:]
+		src = src[size-1:]
+	}
+}
+
+func (base45) Decode(dst, src []byte) (int, error) {
+	if len(src)%3 == 1 {
+		return 0, errBase45
+	}
+	written := 0
+	for len(src) > 0 {
+		size := min(len(src), 3)
+		n, mul := 0, 1
+		for _, c := range src[:size] {
+			v := base45Values[c]
+			if v == 0xff {
+				return 0, errBase45
+			}
+			n += int(v) * mul
+			mul *= 45
+		}
+		if size == 3 {
+			if n > 0xffff {
+				return 0, errBase45
+			}
+			dst[written], dst[written+1] = byte(n>>8), byte(n)
+			written += 2
+		} else {
+			if n > 0xff {
+				return 0, errBase45
+			}
+			dst[written] = byte(n)
+			written++
+		}
+		src = src[size:]
+	}
+	return written, nil
+}
@@ -0,0 +1,197 @@
+package securetoken
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestBase58(t *testing.T) {
+	for n := 0; n < 100; n++ {
+		for _, fill := range []byte{0x00, 0x01, 0xa5, 0xff} {
+			src := bytes.Repeat([]byte{fill}, n)
+			enc := make([]byte, Base58.EncodedLen(n))
+			Base58.Encode(enc, src)
+			if strings.Trim(string(enc), base58Alphabet) != "" {
+				t.Errorf("Base58.Encode(%x) = %q; expected only base58 digits", src, enc)
+			}
+			dec := make([]byte, Base58.DecodedLen(len(enc)))
+			if m, err := Base58.Decode(dec, enc); !bytes.Equal(dec[:m], src) || err != nil {
+				t.Errorf("Base58.Decode(%q) = %x, %v; expected %x, <nil>", enc, dec[:m], err, src)
+			}
+		}
+	}
+	for _, s := range []string{"0OIl", "zzzz", "1"} {
+		dec := make([]byte, 8)
+		if _, err := Base58.Decode(dec, []byte(s)); err != errBase58 {
+			t.Errorf("Base58.Decode(%q) returned %v; expected %s", s, err, errBase58)
+		}
+	}
+}
+
+func TestWithEncoding(t *testing.T) {
+	tok, err := NewTokener(key, ttl, WithEncoding(Base58))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := tok.SealString("hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sealed) != tok.sealedLength([]byte("hello"), true) || strings.Trim(sealed, base58Alphabet) != "" {
+		t.Errorf("SealString(%q) = %q; expected %d base58 digits", "hello", sealed, tok.sealedLength([]byte("hello"), true))
+	}
+	if p, err := tok.UnsealString(sealed); p != "hello" || err != nil {
+		t.Errorf("UnsealString(%q) = %q, %v; expected %q, <nil>", sealed, p, err, "hello")
+	}
+	b64, err := NewTokener(key, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p, err := b64.UnsealString(sealed); p != "" || err != ErrTokenInvalid {
+		t.Errorf("UnsealString(%q) with base64 = %q, %v; expected \"\", %s", sealed, p, err, ErrTokenInvalid)
+	}
+
+	// Long input is rejected before the quadratic decoding.
+	long := strings.Repeat("z", base58MaxDigits+1)
+	if _, err := tok.UnsealString(long); err != ErrTokenTooLong {
+		t.Errorf("UnsealString() of %d digits returned %v; expected %s", len(long), err, ErrTokenTooLong)
+	}
+	dec := make([]byte, Base58.DecodedLen(len(long)))
+	if _, err := Base58.Decode(dec, []byte(long)); err != errBase58 {
+		t.Errorf("Base58.Decode() of %d digits returned %v; expected %s", len(long), err, errBase58)
+	}
+}
+
+// countingEncoding records the calls to an Encoding.
+type countingEncoding struct {
+	Encoding
+	encoded, decoded []int
+}
+
+func (e *countingEncoding) Encode(dst, src []byte) {
+	e.encoded = append(e.encoded, len(src))
+	e.Encoding.Encode(dst, src)
+}
+
+func (e *countingEncoding) Decode(dst, src []byte) (int, error) {
+	e.decoded = append(e.decoded, len(src))
+	return e.Encoding.Decode(dst, src)
+}
+
+func TestEncodingWholeBuffers(t *testing.T) {
+	enc := &countingEncoding{Encoding: base64.URLEncoding}
+	tok, err := NewTokener(key, ttl, WithEncoding(enc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := tok.Seal([]byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tok.Unseal(sealed); err != nil {
+		t.Fatal(err)
+	}
+	var buf []byte
+	if buf, err = tok.AppendSeal(buf, []byte("hello"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tok.AppendUnseal(make([]byte, 0, 64), buf); err != nil {
+		t.Fatal(err)
+	}
+	raw, n := tok.sealedLength([]byte("hello"), false), len(sealed)
+	if fmt.Sprint(enc.encoded, enc.decoded) != fmt.Sprint([]int{raw, raw}, []int{n, n}) {
+		t.Errorf("Encode and Decode called with %v and %v bytes; expected %v and %v", enc.encoded, enc.decoded, []int{raw, raw}, []int{n, n})
+	}
+}
+
+func TestCrockford32(t *testing.T) {
+	tok, err := NewTokener(key, ttl, WithEncoding(Crockford32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := tok.SealString("invite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Trim(sealed, crockfordSymbols) != "" {
+		t.Errorf("SealString(%q) = %q; expected only Crockford symbols", "invite", sealed)
+	}
+	// Transcribed by hand: lower case, grouped with hyphens, 0 and 1 misread.
+	var b strings.Builder
+	for i, c := range strings.ToLower(sealed) {
+		if i > 0 && i%4 == 0 {
+			b.WriteByte('-')
+		}
+		switch c {
+		case '0':
+			c = 'o'
+		case '1':
+			c = 'l'
+		}
+		b.WriteRune(c)
+	}
+	for _, s := range []string{sealed, b.String()} {
+		if p, err := tok.UnsealString(s); p != "invite" || err != nil {
+			t.Errorf("UnsealString(%q) = %q, %v; expected %q, <nil>", s, p, err, "invite")
+		}
+	}
+
+	// A typo is caught by the check symbol before the token is opened.
+	typo := []byte(sealed)
+	typo[3] = crockfordSymbols[(strings.IndexByte(crockfordSymbols, typo[3])+1)%32]
+	dec := make([]byte, Crockford32.DecodedLen(len(typo)))
+	if _, err := Crockford32.Decode(dec, typo); err != errCrockford {
+		t.Errorf("Crockford32.Decode(%q) returned %v; expected %s", typo, err, errCrockford)
+	}
+	for _, s := range []string{"", "*0", "U"} {
+		if _, err := Crockford32.Decode(dec, []byte(s)); err != errCrockford {
+			t.Errorf("Crockford32.Decode(%q) returned %v; expected %s", s, err, errCrockford)
+		}
+	}
+}
+
+func TestBase45(t *testing.T) {
+	// Examples from RFC 9285.
+	for _, test := range []struct {
+		decoded, encoded string
+	}{
+		{"AB", "BB8"},
+		{"Hello!!", "%69 VD92EX0"},
+		{"base-45", "UJCLQE7W581"},
+		{"ietf!", "QED8WEX0"},
+	} {
+		enc := make([]byte, Base45.EncodedLen(len(test.decoded)))
+		Base45.Encode(enc, []byte(test.decoded))
+		if string(enc) != test.encoded {
+			t.Errorf("Base45.Encode(%q) = %q; expected %q", test.decoded, enc, test.encoded)
+		}
+		dec := make([]byte, Base45.DecodedLen(len(test.encoded)))
+		if n, err := Base45.Decode(dec, []byte(test.encoded)); string(dec[:n]) != test.decoded || err != nil {
+			t.Errorf("Base45.Decode(%q) = %q, %v; expected %q, <nil>", test.encoded, dec[:n], err, test.decoded)
+		}
+	}
+	for _, s := range []string{"GGW", "ZZ", "A", "ab"} {
+		dec := make([]byte, 8)
+		if _, err := Base45.Decode(dec, []byte(s)); err != errBase45 {
+			t.Errorf("Base45.Decode(%q) returned %v; expected %s", s, err, errBase45)
+		}
+	}
+
+	tok, err := NewTokener(key, ttl, WithEncoding(Base45))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := tok.SealString("ticket 42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Trim(sealed, base45Alphabet) != "" {
+		t.Errorf("SealString(%q) = %q; expected only QR alphanumeric characters", "ticket 42", sealed)
+	}
+	if p, err := tok.UnsealString(sealed); p != "ticket 42" || err != nil {
+		t.Errorf("UnsealString(%q) = %q, %v; expected %q, <nil>", sealed, p, err, "ticket 42")
+	}
+}
@@ -26,3 +26,16 @@ func Example() {
 	// Output:
 	// hello world
 }
+
+func ExampleTokener_UnsealStringOr() {
+	key := []byte("1111111111111111")
+	tok, err := securetoken.NewTokener(key, 1*time.Minute)
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Println(tok.UnsealStringOr("not a token", "light"))
+
+	// Output:
+	// light
+}
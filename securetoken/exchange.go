@@ -0,0 +1,38 @@
+package securetoken
+
+// Exchange implements the core of an RFC 8693 token exchange, for security
+// token services: it unseals subjectToken and, if it is not nil, actorToken,
+// and seals a new token for the same subject narrowed by want.
+//
+// With an actor token, the subject of the actor token is recorded as the
+// Actor of the new token, on top of any actors of the subject token.
+// The new token has the subject token as its nearest parent (see DeriveChild),
+// so revoking the subject token revokes it, and it never outlives the subject
+// token or the actor token. Unlike DeriveChild, the subject token does not
+// need an ID.
+func (t *Tokener) Exchange(subjectToken, actorToken []byte, want Restrictions) ([]byte, error) {
+	s, err := t.UnsealClaims(subjectToken)
+	if err != nil {
+		return nil, err
+	}
+	c, err := t.narrow(s, want)
+	if err != nil {
+		return nil, err
+	}
+	if actorToken != nil {
+		a, err := t.UnsealClaims(actorToken)
+		if err != nil {
+			return nil, err
+		}
+		if a.Subject == "" {
+			return nil, ErrNotDerivable
+		}
+		c.Actor = &Actor{Subject: a.Subject, Actor: s.Actor}
+		if limit, err := t.narrow(a, Restrictions{}); err != nil {
+			return nil, err
+		} else if limit.TTL < c.TTL {
+			c.TTL = limit.TTL
+		}
+	}
+	return t.SealClaims(c)
+}
@@ -0,0 +1,54 @@
+package securetoken
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestExchange(t *testing.T) {
+	now := time.Unix(1000, 0)
+	setNow(now)
+	defer restoreNow()
+	tok, err := NewTokener(key, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, err := NewClaims().ID("s1").Subject("carol").Scope("read", "write").Seal(tok)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actor, err := NewClaims().Subject("billing-svc").TTL(10 * time.Minute).Seal(tok)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exchanged, err := tok.Exchange(subject, actor, Restrictions{Scopes: []string{"read"}, Audience: "ledger"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := tok.UnsealClaims(exchanged)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Subject != "carol" || c.Audience != "ledger" || !reflect.DeepEqual(c.Scopes, []string{"read"}) ||
+		!reflect.DeepEqual(c.ActorChain(), []string{"billing-svc"}) || !reflect.DeepEqual(c.Parents, []string{"s1"}) ||
+		c.TTL != 10*time.Minute {
+		t.Errorf("UnsealClaims(exchanged) = %+v; expected carol for ledger, acted on by billing-svc for 10m", c)
+	}
+
+	tests := []struct {
+		subject, actor []byte
+		want           Restrictions
+		err            error
+	}{
+		{subject, nil, Restrictions{Scopes: []string{"admin"}}, ErrNotDerivable},
+		{[]byte("forged"), actor, Restrictions{}, ErrTokenInvalid},
+		{subject, []byte("forged"), Restrictions{}, ErrTokenInvalid},
+	}
+	for i, test := range tests {
+		if _, err := tok.Exchange(test.subject, test.actor, test.want); err != test.err {
+			t.Errorf("%d: Exchange() returned %v; expected %s", i, err, test.err)
+		}
+	}
+}
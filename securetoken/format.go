@@ -0,0 +1,113 @@
+package securetoken
+
+import (
+	"encoding/binary"
+	"time"
+)
+
+// Token format versions.
+//
+// Tokens are encoded with base64url (base64.URLEncoding) by default, or with
+// the Encoding given to WithEncoding. Decoded, a token is laid out as
+//
+//	header | nonce | ciphertext
+//
+// where ciphertext includes the AEAD tag. Every nonce begins with the
+// seal time followed by random bytes.
+const (
+	// Version1 tokens have a header that is only the version byte.
+	// They are unsealed with key id 0, and the header is not authenticated.
+	Version1 uint8 = 1
+
+	// Version2 tokens have a header that is the version byte followed by
+	// a big endian key id. The header is authenticated as additional data.
+	Version2 uint8 = 2
+)
+
+// Field offsets and lengths.
+const (
+	// VersionOffset is the offset of the version byte.
+	VersionOffset = 0
+
+	// KeyIDOffset is the offset of the key id in Version2 tokens.
+	KeyIDOffset = 1
+
+	// KeyIDLength is the length of the key id in Version2 tokens.
+	KeyIDLength = 4
+
+	// TimestampLength is the length of the little endian number of
+	// nanoseconds since the Unix epoch at the start of every nonce.
+	TimestampLength = 8
+
+	// MinNonceLength is the smallest nonce length of any token.
+	// AES-GCM tokens use exactly this length.
+	MinNonceLength = 12
+)
+
+// A RawToken holds the structural fields of a decoded token.
+// The slices alias the input to ParseRaw.
+type RawToken struct {
+	Version    uint8
+	KeyID      uint32
+	Header     []byte
+	Nonce      []byte
+	Timestamp  time.Time
+	Ciphertext []byte
+}
+
+// HeaderLength returns the length of the header of version ver tokens,
+// or 0 if ver is unknown.
+func HeaderLength(ver uint8) int {
+	switch ver {
+	case Version1:
+		return 1
+	case Version2:
+		return 1 + KeyIDLength
+	}
+	return 0
+}
+
+// ParseRaw splits a decoded token into its structural fields without
+// verifying it. nonceSize is the nonce size of the AEAD that sealed the token,
+// which is MinNonceLength for AES-GCM.
+// It returns an error if decoded is too short or has an unknown version.
+func ParseRaw(decoded []byte, nonceSize int) (*RawToken, error) {
+	ver, id, err := parseHeader(decoded)
+	if err != nil {
+		return nil, err
+	}
+	if nonceSize < MinNonceLength {
+		return nil, ErrTokenInvalid
+	}
+	hdr := HeaderLength(ver)
+	if len(decoded) < hdr+nonceSize {
+		return nil, ErrTokenInvalid
+	}
+	nonce := decoded[hdr : hdr+nonceSize]
+	ts := int64(binary.LittleEndian.Uint64(nonce[:TimestampLength]))
+	return &RawToken{
+		Version:    ver,
+		KeyID:      id,
+		Header:     decoded[:hdr],
+		Nonce:      nonce,
+		Timestamp:  time.Unix(0, ts),
+		Ciphertext: decoded[hdr+nonceSize:],
+	}, nil
+}
+
+// parseHeader returns the version and key id of a decoded token.
+func parseHeader(decoded []byte) (uint8, uint32, error) {
+	if len(decoded) <= VersionOffset {
+		return 0, 0, ErrTokenInvalid
+	}
+	ver := decoded[VersionOffset]
+	hdr := HeaderLength(ver)
+	if hdr == 0 || len(decoded) < hdr {
+		return 0, 0, ErrTokenInvalid
+	}
+	var id uint32
+	if ver >= Version2 {
+		id = binary.BigEndian.Uint32(decoded[KeyIDOffset : KeyIDOffset+KeyIDLength])
+	}
+	return ver, id, nil
+}
@@ -0,0 +1,65 @@
+package securetoken
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestParseRaw(t *testing.T) {
+	setNow(time.Unix(1, 0))
+	defer restoreNow()
+
+	kr := NewKeyring()
+	if err := kr.AddKey(9, key); err != nil {
+		t.Fatal(err)
+	}
+	tok, err := NewKeyringTokener(kr, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext := []byte("data")
+	sealed, err := tok.Seal(plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := tok.decode(sealed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := ParseRaw(decoded, MinNonceLength)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if raw.Version != Version2 || raw.KeyID != 9 {
+		t.Errorf("ParseRaw() = version %d, key id %d; expected %d, 9", raw.Version, raw.KeyID, Version2)
+	}
+	if !raw.Timestamp.Equal(time.Unix(1, 0)) {
+		t.Errorf("ParseRaw() = timestamp %s; expected %s", raw.Timestamp, time.Unix(1, 0))
+	}
+	if !bytes.Equal(raw.Header, decoded[:HeaderLength(Version2)]) {
+		t.Errorf("ParseRaw() = header %x; expected %x", raw.Header, decoded[:HeaderLength(Version2)])
+	}
+	if len(raw.Ciphertext) != len(plaintext)+16 {
+		t.Errorf("ParseRaw() = ciphertext length %d; expected %d", len(raw.Ciphertext), len(plaintext)+16)
+	}
+}
+
+func TestParseRawInvalid(t *testing.T) {
+	tests := []struct {
+		decoded   []byte
+		nonceSize int
+	}{
+		{nil, MinNonceLength},
+		{[]byte{0}, MinNonceLength},
+		{[]byte{Version1}, MinNonceLength},
+		{[]byte{Version2, 0, 0}, MinNonceLength},
+		{make([]byte, 1+MinNonceLength), MinNonceLength},
+		{append([]byte{Version1}, make([]byte, 8)...), 8},
+	}
+	for _, test := range tests {
+		if raw, err := ParseRaw(test.decoded, test.nonceSize); err == nil {
+			t.Errorf("ParseRaw(%x, %d) = %+v; expected error", test.decoded, test.nonceSize, raw)
+		}
+	}
+}
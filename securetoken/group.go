@@ -0,0 +1,44 @@
+package securetoken
+
+// A Group unseals tokens with the first of several Unsealers that accepts them,
+// e.g. while merging token systems that used different keys or formats.
+// Seal with the Tokener of the system that is being kept.
+type Group struct {
+	unsealers []Unsealer
+}
+
+var _ Unsealer = (*Group)(nil)
+
+// NewGroup returns a Group that tries unsealers in order.
+func NewGroup(unsealers ...Unsealer) *Group {
+	return &Group{unsealers: unsealers}
+}
+
+// Unseal implements Unsealer.
+func (g *Group) Unseal(sealed []byte, opts ...UnsealOption) ([]byte, error) {
+	plaintext, _, err := g.UnsealIndex(sealed, opts...)
+	return plaintext, err
+}
+
+// UnsealIndex is similar to Unseal except it also returns the index of the
+// Unsealer that unsealed the token, or -1 if none did.
+//
+// If no Unsealer accepts the token, the first error other than
+// ErrTokenInvalid is returned, since it comes from an Unsealer that
+// recognized the token (e.g. ErrTokenExpired); otherwise ErrTokenInvalid.
+func (g *Group) UnsealIndex(sealed []byte, opts ...UnsealOption) ([]byte, int, error) {
+	var first error
+	for i, u := range g.unsealers {
+		plaintext, err := u.Unseal(sealed, opts...)
+		if err == nil {
+			return plaintext, i, nil
+		}
+		if first == nil && err != ErrTokenInvalid {
+			first = err
+		}
+	}
+	if first == nil {
+		first = ErrTokenInvalid
+	}
+	return nil, -1, first
+}
@@ -0,0 +1,41 @@
+package securetoken
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGroup(t *testing.T) {
+	now := time.Unix(1000, 0)
+	setNow(now)
+	defer restoreNow()
+	a, err := NewTokener(key, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewTokener(key2, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := NewTokener([]byte("0123456789abcdef"), ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := NewGroup(a, b)
+
+	sealed := [][]byte{a.MustSeal([]byte("a")), b.MustSeal([]byte("b")), other.MustSeal([]byte("x"))}
+	if plaintext, i, err := g.UnsealIndex(sealed[0]); string(plaintext) != "a" || i != 0 || err != nil {
+		t.Errorf("UnsealIndex(%q) = %q, %d, %v; expected \"a\", 0, <nil>", sealed[0], plaintext, i, err)
+	}
+	if plaintext, i, err := g.UnsealIndex(sealed[1]); string(plaintext) != "b" || i != 1 || err != nil {
+		t.Errorf("UnsealIndex(%q) = %q, %d, %v; expected \"b\", 1, <nil>", sealed[1], plaintext, i, err)
+	}
+	if _, i, err := g.UnsealIndex(sealed[2]); i != -1 || err != ErrTokenInvalid {
+		t.Errorf("UnsealIndex(%q) = %d, %v; expected -1, %s", sealed[2], i, err, ErrTokenInvalid)
+	}
+
+	setNow(now.Add(2 * ttl))
+	if _, err := g.Unseal(sealed[1]); err != ErrTokenExpired {
+		t.Errorf("Unseal(%q) returned %v; expected %s", sealed[1], err, ErrTokenExpired)
+	}
+}
@@ -0,0 +1,50 @@
+package grpctoken
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nicksnyder/go-securetoken/securetoken/securetokentest"
+)
+
+// perRPCCredentials mirrors credentials.PerRPCCredentials.
+type perRPCCredentials interface {
+	GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error)
+	RequireTransportSecurity() bool
+}
+
+var _ perRPCCredentials = (*Credentials)(nil)
+
+func TestRoundTrip(t *testing.T) {
+	tok := securetokentest.NewTokener(t)
+	creds := SealingCredentials(tok, []byte("service-a"))
+	if !creds.RequireTransportSecurity() {
+		t.Error("RequireTransportSecurity() = false; expected true")
+	}
+	out, err := creds.GetRequestMetadata(context.Background(), "/pkg.Service/Method")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// gRPC lower cases keys and delivers them as a metadata.MD.
+	md := map[string][]string{}
+	for k, v := range out {
+		md[k] = append(md[k], v)
+	}
+	auth := &Authenticator{Unsealer: tok}
+	ctx, err := auth.Authenticate(context.Background(), md)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if payload, ok := FromContext(ctx); string(payload) != "service-a" || !ok {
+		t.Errorf("FromContext() = %q, %t; expected \"service-a\", true", payload, ok)
+	}
+
+	if _, err := auth.Authenticate(context.Background(), nil); err != ErrNoToken {
+		t.Errorf("Authenticate() without metadata returned %v; expected %s", err, ErrNoToken)
+	}
+	md[authorizationKey] = []string{"Bearer forged"}
+	if _, err := auth.Authenticate(context.Background(), md); err == nil {
+		t.Error("Authenticate() with a forged token returned no error")
+	}
+}
@@ -0,0 +1,65 @@
+//go:build grpc
+
+package grpctoken
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/nicksnyder/go-securetoken/securetoken/securetokentest"
+)
+
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *fakeServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestInterceptors(t *testing.T) {
+	tok := securetokentest.NewTokener(t)
+	auth := &Authenticator{Unsealer: tok}
+	token, err := tok.Seal([]byte("service-a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	valid := metadata.NewIncomingContext(context.Background(), metadata.Pairs(authorizationKey, bearerPrefix+string(token)))
+	forged := metadata.NewIncomingContext(context.Background(), metadata.Pairs(authorizationKey, "Bearer forged"))
+
+	unary := auth.UnaryServerInterceptor()
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		payload, _ := FromContext(ctx)
+		return string(payload), nil
+	}
+	info := &grpc.UnaryServerInfo{FullMethod: "/pkg.Service/Method"}
+	if resp, err := unary(valid, nil, info, handler); resp != "service-a" || err != nil {
+		t.Errorf("unary interceptor = %v, %v; expected \"service-a\", <nil>", resp, err)
+	}
+	for _, ctx := range []context.Context{context.Background(), forged} {
+		if _, err := unary(ctx, nil, info, handler); status.Code(err) != codes.Unauthenticated {
+			t.Errorf("unary interceptor returned %v; expected code %s", err, codes.Unauthenticated)
+		}
+	}
+
+	stream := auth.StreamServerInterceptor()
+	var got string
+	streamHandler := func(srv interface{}, ss grpc.ServerStream) error {
+		payload, _ := FromContext(ss.Context())
+		got = string(payload)
+		return nil
+	}
+	sinfo := &grpc.StreamServerInfo{FullMethod: "/pkg.Service/Stream"}
+	if err := stream(nil, &fakeServerStream{ctx: valid}, sinfo, streamHandler); err != nil || got != "service-a" {
+		t.Errorf("stream interceptor = %v with payload %q; expected <nil> with \"service-a\"", err, got)
+	}
+	if err := stream(nil, &fakeServerStream{ctx: forged}, sinfo, streamHandler); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("stream interceptor returned %v; expected code %s", err, codes.Unauthenticated)
+	}
+}
@@ -0,0 +1,57 @@
+package securetoken
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+)
+
+// A HealthChecker reports whether a dependency of a Tokener, such as a
+// remote key provider, is working. It is implemented by key providers
+// that can fail after a Tokener has been created.
+type HealthChecker interface {
+	Healthy(ctx context.Context) error
+}
+
+// WithHealthCheck returns an Option that makes Healthy also call c,
+// e.g. to check the connection to the KMS that keys are fetched from.
+func WithHealthCheck(c HealthChecker) Option {
+	return func(t *Tokener) error {
+		t.health = append(t.health[:len(t.health):len(t.health)], c)
+		return nil
+	}
+}
+
+var healthPayload = []byte("securetoken health check")
+
+// Healthy returns an error if t can not currently seal and unseal tokens,
+// such as when its primary key is exhausted or expired, or if a
+// HealthChecker given to WithHealthCheck fails.
+// It seals and unseals a token, which counts against the limits of the
+// primary key, so it should be called every few seconds at most,
+// e.g. by a readiness probe.
+func (t *Tokener) Healthy(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	sealed, err := t.Seal(healthPayload)
+	if err != nil {
+		return fmt.Errorf("securetoken: health check seal failed: %w", err)
+	}
+	plaintext, _, err := t.unseal(sealed, newUnsealConfig(nil))
+	if err != nil {
+		return fmt.Errorf("securetoken: health check unseal failed: %w", err)
+	}
+	if !bytes.Equal(plaintext, healthPayload) {
+		return fmt.Errorf("securetoken: health check round trip failed")
+	}
+	for _, c := range t.health {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := c.Healthy(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package securetoken
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type healthFunc func(ctx context.Context) error
+
+func (f healthFunc) Healthy(ctx context.Context) error {
+	return f(ctx)
+}
+
+func TestHealthy(t *testing.T) {
+	errKMS := errors.New("kms unreachable")
+	kmsErr := error(nil)
+	kr := NewKeyring()
+	if err := kr.AddKey(0, key); err != nil {
+		t.Fatal(err)
+	}
+	tok, err := NewKeyringTokener(kr, ttl, WithHealthCheck(healthFunc(func(ctx context.Context) error {
+		return kmsErr
+	})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tok.Healthy(context.Background()); err != nil {
+		t.Errorf("Healthy() = %v; expected <nil>", err)
+	}
+
+	kmsErr = errKMS
+	if err := tok.Healthy(context.Background()); err != errKMS {
+		t.Errorf("Healthy() with a failing check = %v; expected %s", err, errKMS)
+	}
+	kmsErr = nil
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := tok.Healthy(ctx); err != context.Canceled {
+		t.Errorf("Healthy() with a canceled context = %v; expected %s", err, context.Canceled)
+	}
+
+	kr.SetSealLimit(kr.SealCount(0), 0, nil)
+	if err := tok.Healthy(context.Background()); !errors.Is(err, ErrKeyExhausted) {
+		t.Errorf("Healthy() with an exhausted key = %v; expected %s", err, ErrKeyExhausted)
+	}
+}
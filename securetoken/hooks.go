@@ -0,0 +1,124 @@
+package securetoken
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// WithBeforeSeal returns an Option that makes SealClaims call each hook,
+// in order, before sealing claims, and return the first error instead of
+// sealing. Hooks enforce policies centrally at mint time, such as
+// RequireSubject, MaxTTL and ForbidScopes. Hooks must not modify the claims.
+// Hooks accumulate: each use of WithBeforeSeal adds to the hooks of the Tokener.
+func WithBeforeSeal(hooks ...func(c *Claims) error) Option {
+	return func(t *Tokener) error {
+		t.beforeSeal = append(t.beforeSeal[:len(t.beforeSeal):len(t.beforeSeal)], hooks...)
+		return nil
+	}
+}
+
+// runBeforeSeal calls the before seal hooks of t with c.
+func (t *Tokener) runBeforeSeal(c *Claims) error {
+	for _, hook := range t.beforeSeal {
+		if err := hook(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RequireSubject is a WithBeforeSeal hook that rejects claims without
+// a Subject with ErrInvalidClaims.
+func RequireSubject(c *Claims) error {
+	if c.Subject == "" {
+		return fmt.Errorf("%w: no subject", ErrInvalidClaims)
+	}
+	return nil
+}
+
+// MaxTTL returns a WithBeforeSeal hook that rejects claims whose TTL is
+// not set or is longer than max with ErrInvalidClaims.
+func MaxTTL(max time.Duration) func(c *Claims) error {
+	return func(c *Claims) error {
+		if c.TTL <= 0 || c.TTL > max {
+			return fmt.Errorf("%w: ttl %s is not between 0 and %s", ErrInvalidClaims, c.TTL, max)
+		}
+		return nil
+	}
+}
+
+// ForbidScopes returns a WithBeforeSeal hook that rejects claims with any
+// of the given scopes with ErrInvalidClaims.
+func ForbidScopes(scopes ...string) func(c *Claims) error {
+	return func(c *Claims) error {
+		for _, s := range c.Scopes {
+			for _, forbidden := range scopes {
+				if s == forbidden {
+					return fmt.Errorf("%w: scope %q is forbidden", ErrInvalidClaims, s)
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// ErrPolicyDenied may be wrapped by WithAfterUnseal hooks to deny a token
+// that is valid but not allowed for the request, which httptoken
+// reports as 403 Forbidden rather than 401 Unauthorized.
+var ErrPolicyDenied = errors.New("securetoken: denied by policy")
+
+// RequestInfo describes the request that a token was presented with,
+// for WithAfterUnseal hooks. Fields that are unknown are empty.
+type RequestInfo struct {
+	// Caller identifies the client, as passed to UnsealClaimsFor.
+	Caller string
+
+	// Method, Host and Path describe an HTTP request, or the equivalent
+	// for other protocols (e.g. the full method name of a gRPC call).
+	Method string
+	Host   string
+	Path   string
+
+	// Attributes holds any other input for policies.
+	Attributes map[string]string
+}
+
+// WithRequestInfo returns an UnsealOption that passes info to the
+// WithAfterUnseal hooks of the Tokener.
+func WithRequestInfo(info RequestInfo) UnsealOption {
+	return func(cfg *unsealConfig) {
+		cfg.info = info
+	}
+}
+
+// WithAfterUnseal returns an Option that makes UnsealClaims call each hook,
+// in order, once a token has been verified, is unexpired and is not
+// revoked, and return the first error instead of the claims.
+// Hooks plug in policy checks, such as a query to a policy engine,
+// before the claims reach handlers. info is never nil; its Caller is set
+// by UnsealClaimsFor if WithRequestInfo did not set it.
+// Hooks accumulate: each use of WithAfterUnseal adds to the hooks of the Tokener.
+func WithAfterUnseal(hooks ...func(c *Claims, info *RequestInfo) error) Option {
+	return func(t *Tokener) error {
+		t.afterUnseal = append(t.afterUnseal[:len(t.afterUnseal):len(t.afterUnseal)], hooks...)
+		return nil
+	}
+}
+
+// runAfterUnseal calls the after unseal hooks of t with c.
+func (t *Tokener) runAfterUnseal(c *Claims, caller string, cfg *unsealConfig) error {
+	if len(t.afterUnseal) == 0 {
+		return nil
+	}
+	info := cfg.info
+	if info.Caller == "" {
+		info.Caller = caller
+	}
+	for _, hook := range t.afterUnseal {
+		if err := hook(c, &info); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package securetoken
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBeforeSeal(t *testing.T) {
+	errCustom := errors.New("custom")
+	var calls int
+	tok, err := NewTokener(key, ttl,
+		WithBeforeSeal(RequireSubject, MaxTTL(time.Hour)),
+		WithBeforeSeal(ForbidScopes("admin"), func(c *Claims) error {
+			calls++
+			if c.Subject == "blocked" {
+				return errCustom
+			}
+			return nil
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		c   Claims
+		err error
+	}{
+		{Claims{Subject: "42", TTL: time.Minute, Scopes: []string{"read"}}, nil},
+		{Claims{TTL: time.Minute}, ErrInvalidClaims},
+		{Claims{Subject: "42"}, ErrInvalidClaims},
+		{Claims{Subject: "42", TTL: 2 * time.Hour}, ErrInvalidClaims},
+		{Claims{Subject: "42", TTL: time.Minute, Scopes: []string{"read", "admin"}}, ErrInvalidClaims},
+		{Claims{Subject: "blocked", TTL: time.Minute}, errCustom},
+	}
+	for _, test := range tests {
+		sealed, err := tok.SealClaims(&test.c)
+		if !errors.Is(err, test.err) || (err == nil) != (sealed != nil) {
+			t.Errorf("SealClaims(%+v) = %q, %v; expected %v", test.c, sealed, err, test.err)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("last hook called %d times; expected 2, once the other hooks accepted the claims", calls)
+	}
+}
+
+func TestAfterUnseal(t *testing.T) {
+	var audited int
+	tok, err := NewTokener(key, ttl,
+		WithAuditHook(func(c *Claims) { audited++ }),
+		WithAfterUnseal(func(c *Claims, info *RequestInfo) error {
+			if info.Attributes["tenant"] != c.Audience {
+				return ErrPolicyDenied
+			}
+			return nil
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := tok.SealClaims(&Claims{Subject: "42", Audience: "acme"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		tenant string
+		err    error
+	}{
+		{"acme", nil},
+		{"globex", ErrPolicyDenied},
+	} {
+		info := WithRequestInfo(RequestInfo{Attributes: map[string]string{"tenant": test.tenant}})
+		if c, err := tok.UnsealClaims(sealed, info); err != test.err || (c == nil) != (err != nil) {
+			t.Errorf("UnsealClaims(%q) for tenant %s = %+v, %v; expected %v", sealed, test.tenant, c, err, test.err)
+		}
+	}
+	if audited != 1 {
+		t.Errorf("audit hook called %d times; expected 1, for the allowed token only", audited)
+	}
+
+	var caller string
+	tok, err = NewTokener(key, ttl, WithAfterUnseal(func(c *Claims, info *RequestInfo) error {
+		caller = info.Caller
+		return nil
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tok.UnsealClaimsFor("10.0.0.1", sealed); err != nil || caller != "10.0.0.1" {
+		t.Errorf("UnsealClaimsFor() = %v with hook caller %q; expected <nil> and 10.0.0.1", err, caller)
+	}
+}
@@ -0,0 +1,25 @@
+package httptoken
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/nicksnyder/go-securetoken/securetoken"
+)
+
+// KeyAttestationHandler returns a handler that serves the attestations of
+// the keys in kr as a JSON array, so that auditors can check the provenance
+// of the keys that seal production tokens (see securetoken.KeyAttestation).
+// Attestations contain no key material, but the handler should still only
+// be reachable by auditors.
+func KeyAttestationHandler(kr *securetoken.Keyring) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attestations := kr.Attestations()
+		if attestations == nil {
+			attestations = []securetoken.SignedKeyAttestation{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		json.NewEncoder(w).Encode(attestations)
+	})
+}
@@ -0,0 +1,53 @@
+package httptoken_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nicksnyder/go-securetoken/securetoken"
+	"github.com/nicksnyder/go-securetoken/securetoken/httptoken"
+)
+
+func TestKeyAttestationHandler(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := []byte("0123456789abcdef")
+	kr := securetoken.NewKeyring()
+	if err := kr.AddKey(1, key); err != nil {
+		t.Fatal(err)
+	}
+	kcv, err := securetoken.KeyCheckValue(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := httptoken.KeyAttestationHandler(kr)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/keys/attestations", nil))
+	if rec.Body.String() != "[]\n" {
+		t.Errorf("GET without attestations returned %q; expected []", rec.Body.String())
+	}
+
+	s, err := securetoken.SignKeyAttestation(priv, securetoken.KeyAttestation{KeyID: 1, KeyCheck: kcv, Created: time.Now(), Creator: "ceremony"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := kr.SetAttestation(s); err != nil {
+		t.Fatal(err)
+	}
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/keys/attestations", nil))
+	var got []securetoken.SignedKeyAttestation
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Verify(pub) != nil {
+		t.Errorf("GET returned %+v; expected one attestation that verifies", got)
+	}
+}
@@ -0,0 +1,177 @@
+package httptoken
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/nicksnyder/go-securetoken/securetoken"
+)
+
+// Cookie name prefixes that browsers enforce.
+const (
+	// SecurePrefix requires the Secure attribute.
+	SecurePrefix = "__Secure-"
+
+	// HostPrefix requires the Secure attribute, no Domain, and the Path "/",
+	// which locks the cookie to a single origin.
+	HostPrefix = "__Host-"
+)
+
+// A CookieManager seals payloads into cookies and unseals them from requests.
+// Cookies are always HttpOnly.
+type CookieManager struct {
+	// Tokener seals and unseals cookie values.
+	Tokener securetoken.SealUnsealer
+
+	// Name is the cookie name. If it starts with SecurePrefix or HostPrefix,
+	// the other fields must satisfy the requirements of the prefix.
+	Name string
+
+	// Path, Domain, MaxAge, Secure, and SameSite
+	// are copied to the attributes of every cookie.
+	// A cookie with a Domain is sent to every subdomain of it.
+	Path     string
+	Domain   string
+	MaxAge   int
+	Secure   bool
+	SameSite http.SameSite
+
+	// HostOnly asserts that the cookie must only be sent to the host that set it,
+	// which requires Domain to be empty.
+	HostOnly bool
+
+	// Partitioned stores the cookie in a separate jar for each top-level site
+	// (CHIPS), which lets embedded third-party pages keep state
+	// where unpartitioned third-party cookies are blocked. It requires Secure.
+	Partitioned bool
+
+	// Duplicates decides which cookie Token uses when a request carries
+	// several cookies named Name. It defaults to FirstCookie.
+	Duplicates DuplicatePolicy
+}
+
+// A Preset is a combination of SameSite, Secure, and Partitioned
+// attributes that suits a common architecture.
+type Preset int
+
+// Presets.
+const (
+	// StrictAuth cookies are only sent on same-site requests.
+	// They are the safest choice for session cookies of applications that
+	// users never enter through a link or redirect from another site,
+	// since such navigations arrive without the cookie.
+	StrictAuth Preset = iota + 1
+
+	// LaxNavigation cookies are also sent on top-level cross-site navigations,
+	// such as links from email and OAuth or SSO callbacks,
+	// but not on cross-site subrequests or POSTs.
+	LaxNavigation
+
+	// CrossSiteEmbedded cookies are sent on every request, including from
+	// pages that embed the application in an iframe on another site,
+	// and are partitioned by top-level site.
+	CrossSiteEmbedded
+)
+
+// Apply sets the SameSite, Secure, and Partitioned attributes of m to p.
+// Every preset requires HTTPS.
+func (m *CookieManager) Apply(p Preset) {
+	m.Secure = true
+	m.Partitioned = false
+	switch p {
+	case StrictAuth:
+		m.SameSite = http.SameSiteStrictMode
+	case LaxNavigation:
+		m.SameSite = http.SameSiteLaxMode
+	case CrossSiteEmbedded:
+		m.SameSite = http.SameSiteNoneMode
+		m.Partitioned = true
+	}
+}
+
+// Validate returns an error if the cookie attributes are a combination
+// that browsers reject or that does not do what the fields say.
+// Call it at startup so that misconfigurations fail early;
+// SetToken returns the same error.
+func (m *CookieManager) Validate() error {
+	if m.Name == "" || strings.ContainsAny(m.Name, "()<>@,;:\\\"/[]?={} \t") {
+		return fmt.Errorf("httptoken: invalid cookie name %q", m.Name)
+	}
+	if m.Path != "" && !strings.HasPrefix(m.Path, "/") {
+		return fmt.Errorf("httptoken: cookie path %q must start with /", m.Path)
+	}
+	if strings.ContainsAny(m.Domain, ":/ ") {
+		return fmt.Errorf("httptoken: invalid cookie domain %q", m.Domain)
+	}
+	if m.HostOnly && m.Domain != "" {
+		return errors.New("httptoken: host-only cookies can not have a domain")
+	}
+	if m.SameSite == http.SameSiteNoneMode && !m.Secure {
+		return errors.New("httptoken: SameSite=None cookies must be Secure")
+	}
+	if m.Partitioned && !m.Secure {
+		return errors.New("httptoken: partitioned cookies must be Secure")
+	}
+	switch {
+	case strings.HasPrefix(m.Name, HostPrefix):
+		if !m.Secure || m.Domain != "" || m.Path != "/" {
+			return fmt.Errorf("httptoken: %s cookies must be Secure, have no Domain, and have Path /", HostPrefix)
+		}
+	case strings.HasPrefix(m.Name, SecurePrefix):
+		if !m.Secure {
+			return fmt.Errorf("httptoken: %s cookies must be Secure", SecurePrefix)
+		}
+	}
+	return nil
+}
+
+// SetToken seals payload and sets it as the cookie value.
+func (m *CookieManager) SetToken(w http.ResponseWriter, payload []byte) error {
+	if err := m.Validate(); err != nil {
+		return err
+	}
+	token, err := m.Tokener.Seal(payload)
+	if err != nil {
+		return err
+	}
+	http.SetCookie(w, m.cookie(string(token)))
+	return nil
+}
+
+// Token unseals the cookie of r.
+// It returns ErrNoToken if r does not have the cookie.
+func (m *CookieManager) Token(r *http.Request) ([]byte, error) {
+	v, err := selectCookie(r, m.Name, m.Duplicates, func(token []byte) (interface{}, time.Time, error) {
+		payload, err := m.Tokener.Unseal(token)
+		return payload, time.Time{}, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]byte), nil
+}
+
+// Clear instructs the client to delete the cookie.
+func (m *CookieManager) Clear(w http.ResponseWriter) {
+	c := m.cookie("")
+	c.MaxAge = -1
+	c.Expires = time.Unix(1, 0)
+	http.SetCookie(w, c)
+}
+
+func (m *CookieManager) cookie(value string) *http.Cookie {
+	return &http.Cookie{
+		Name:        m.Name,
+		Value:       value,
+		Path:        m.Path,
+		Domain:      m.Domain,
+		MaxAge:      m.MaxAge,
+		Secure:      m.Secure,
+		HttpOnly:    true,
+		SameSite:    m.SameSite,
+		Partitioned: m.Partitioned,
+	}
+}
@@ -0,0 +1,61 @@
+package httptoken
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestCookieManagerValidate(t *testing.T) {
+	tests := []struct {
+		m     CookieManager
+		valid bool
+	}{
+		{CookieManager{Name: "session"}, true},
+		{CookieManager{Name: ""}, false},
+		{CookieManager{Name: "a b"}, false},
+		{CookieManager{Name: "session", Path: "app"}, false},
+		{CookieManager{Name: "session", Domain: "example.com:8080"}, false},
+		{CookieManager{Name: "session", Domain: "example.com", HostOnly: true}, false},
+		{CookieManager{Name: "session", HostOnly: true}, true},
+		{CookieManager{Name: "session", SameSite: http.SameSiteNoneMode}, false},
+		{CookieManager{Name: "session", SameSite: http.SameSiteNoneMode, Secure: true}, true},
+		{CookieManager{Name: "__Secure-session"}, false},
+		{CookieManager{Name: "__Secure-session", Secure: true, Domain: "example.com"}, true},
+		{CookieManager{Name: "__Host-session", Secure: true}, false},
+		{CookieManager{Name: "__Host-session", Secure: true, Path: "/", Domain: "example.com"}, false},
+		{CookieManager{Name: "__Host-session", Secure: true, Path: "/"}, true},
+	}
+	for _, test := range tests {
+		if err := test.m.Validate(); (err == nil) != test.valid {
+			t.Errorf("Validate(%+v) returned %v; expected valid %t", test.m, err, test.valid)
+		}
+	}
+}
+
+func TestCookieManagerApply(t *testing.T) {
+	tests := []struct {
+		p           Preset
+		sameSite    http.SameSite
+		partitioned bool
+	}{
+		{StrictAuth, http.SameSiteStrictMode, false},
+		{LaxNavigation, http.SameSiteLaxMode, false},
+		{CrossSiteEmbedded, http.SameSiteNoneMode, true},
+	}
+	for _, test := range tests {
+		m := CookieManager{Name: "__Host-session", Path: "/"}
+		m.Apply(test.p)
+		if !m.Secure || m.SameSite != test.sameSite || m.Partitioned != test.partitioned {
+			t.Errorf("Apply(%d) = %+v; expected Secure, SameSite %d, Partitioned %t", test.p, m, test.sameSite, test.partitioned)
+		}
+		if err := m.Validate(); err != nil {
+			t.Errorf("Validate() after Apply(%d) returned %s", test.p, err)
+		}
+		if c := m.cookie("value"); c.Partitioned != test.partitioned || c.SameSite != test.sameSite {
+			t.Errorf("cookie() = %+v; expected SameSite %d, Partitioned %t", c, test.sameSite, test.partitioned)
+		}
+	}
+	if err := (&CookieManager{Name: "session", Partitioned: true}).Validate(); err == nil {
+		t.Errorf("Validate() of insecure partitioned cookie returned nil error")
+	}
+}
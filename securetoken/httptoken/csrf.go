@@ -0,0 +1,91 @@
+package httptoken
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+
+	"github.com/nicksnyder/go-securetoken/securetoken"
+)
+
+// PurposeCSRF is the purpose of CSRF tokens.
+const PurposeCSRF = "securetoken.csrf"
+
+// ErrCSRF is returned by CSRF.Check when a request does not carry a valid
+// CSRF token for its session.
+var ErrCSRF = errors.New("httptoken: missing or invalid CSRF token")
+
+// CSRF issues and checks tokens that protect forms against cross-site
+// request forgery. Each token is bound to a session, identified by
+// any string that an attacker cannot learn, such as the session token itself.
+type CSRF struct {
+	// Tokener seals CSRF tokens. Its ttl is how long a form can be left
+	// open before it must be reloaded.
+	Tokener securetoken.SealUnsealer
+
+	// FieldName is the name of the form field that carries the token.
+	// It defaults to "csrf_token".
+	FieldName string
+
+	// HeaderName is the name of the header that carries the token in
+	// requests made by scripts. It defaults to "X-CSRF-Token".
+	HeaderName string
+}
+
+// Token returns a CSRF token for session.
+func (c *CSRF) Token(session string) ([]byte, error) {
+	return c.Tokener.Seal([]byte(PurposeCSRF + ":" + session))
+}
+
+// Check returns ErrCSRF unless r carries a CSRF token for session
+// in its form or header.
+func (c *CSRF) Check(r *http.Request, session string) error {
+	token := r.Header.Get(c.headerName())
+	if token == "" {
+		token = r.PostFormValue(c.fieldName())
+	}
+	if token == "" {
+		return ErrCSRF
+	}
+	plaintext, err := c.Tokener.Unseal([]byte(token))
+	if err != nil {
+		return ErrCSRF
+	}
+	if subtle.ConstantTimeCompare(plaintext, []byte(PurposeCSRF+":"+session)) != 1 {
+		return ErrCSRF
+	}
+	return nil
+}
+
+// Protect returns middleware that rejects requests with methods other than
+// GET, HEAD, OPTIONS and TRACE with 403 Forbidden unless they pass Check
+// for the session returned by session.
+func (c *CSRF) Protect(session func(r *http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case "GET", "HEAD", "OPTIONS", "TRACE":
+			default:
+				if err := c.Check(r, session(r)); err != nil {
+					http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func (c *CSRF) fieldName() string {
+	if c.FieldName != "" {
+		return c.FieldName
+	}
+	return "csrf_token"
+}
+
+func (c *CSRF) headerName() string {
+	if c.HeaderName != "" {
+		return c.HeaderName
+	}
+	return "X-CSRF-Token"
+}
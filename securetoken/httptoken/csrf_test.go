@@ -0,0 +1,81 @@
+package httptoken_test
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/nicksnyder/go-securetoken/securetoken"
+	"github.com/nicksnyder/go-securetoken/securetoken/httptoken"
+	"github.com/nicksnyder/go-securetoken/securetoken/securetokentest"
+)
+
+func TestCSRF(t *testing.T) {
+	tok := securetokentest.NewTokener(t, securetoken.WithPurpose(httptoken.PurposeCSRF))
+	csrf := &httptoken.CSRF{Tokener: tok}
+	h := csrf.Protect(func(r *http.Request) string { return "session-1" })(echo)
+
+	token, err := csrf.Token("session-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := csrf.Token("session-2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	post := func(form url.Values) *http.Request {
+		r := httptest.NewRequest("POST", "/", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return r
+	}
+	header := httptest.NewRequest("DELETE", "/", nil)
+	header.Header.Set("X-CSRF-Token", string(token))
+
+	tests := []struct {
+		r    *http.Request
+		code int
+	}{
+		{httptest.NewRequest("GET", "/", nil), 200},
+		{post(url.Values{"csrf_token": {string(token)}}), 200},
+		{header, 200},
+		{post(nil), 403},
+		{post(url.Values{"csrf_token": {string(other)}}), 403},
+		{post(url.Values{"csrf_token": {"forged"}}), 403},
+	}
+	for _, test := range tests {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, test.r)
+		if rec.Code != test.code {
+			t.Errorf("%s request returned %d; expected %d", test.r.Method, rec.Code, test.code)
+		}
+	}
+}
+
+func TestFuncMap(t *testing.T) {
+	tok := securetokentest.NewTokener(t)
+	csrf := &httptoken.CSRF{Tokener: tok}
+	tmpl := template.Must(template.New("").Funcs(httptoken.FuncMap(tok, csrf)).Parse(
+		`<form>{{csrfField .Session}}<a href="/x?t={{sealedValue .Data}}"></a></form>`))
+	var b strings.Builder
+	if err := tmpl.Execute(&b, map[string]string{"Session": "s", "Data": "d"}); err != nil {
+		t.Fatal(err)
+	}
+	m := regexp.MustCompile(`name="csrf_token" value="([^"]+)">.*t=([^"]+)"`).FindStringSubmatch(b.String())
+	if m == nil {
+		t.Fatalf("template rendered %q; expected a CSRF field and a sealed link", b.String())
+	}
+	r := httptest.NewRequest("POST", "/", nil)
+	r.Header.Set("X-CSRF-Token", m[1])
+	if err := csrf.Check(r, "s"); err != nil {
+		t.Errorf("Check() with rendered token returned %v", err)
+	}
+	sealed, err := url.QueryUnescape(m[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	securetokentest.AssertUnseals(t, tok, []byte(sealed), []byte("d"))
+}
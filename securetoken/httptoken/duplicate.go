@@ -0,0 +1,95 @@
+package httptoken
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+// ErrDuplicateCookie is returned when a request carries several different
+// cookies with the token name and the DuplicatePolicy is RejectDuplicateCookies.
+var ErrDuplicateCookie = errors.New("httptoken: duplicate cookies")
+
+// A DuplicatePolicy decides which of several cookies with the same name
+// authenticates a request. Browsers send one cookie per name, domain and
+// path, so a cookie set for a parent domain or path shadows or is shadowed
+// by the cookie of the application, and proxies can add another copy.
+// Cookies with identical values are always treated as one.
+type DuplicatePolicy int
+
+// Duplicate policies.
+const (
+	// FirstCookie uses the first cookie and ignores the others.
+	// Browsers send cookies with longer paths first,
+	// but otherwise the order is not specified.
+	FirstCookie DuplicatePolicy = iota
+
+	// FirstValidCookie uses the first cookie that unseals.
+	FirstValidCookie
+
+	// NewestCookie uses the valid claims token with the latest IssuedAt.
+	// Tokens that are not claims tokens carry no issue time,
+	// so for them it behaves like FirstValidCookie.
+	NewestCookie
+
+	// RejectDuplicateCookies rejects requests with ErrDuplicateCookie.
+	RejectDuplicateCookies
+)
+
+// unsealFunc unseals token and returns its payload or claims
+// and the time it was issued, if known.
+type unsealFunc func(token []byte) (interface{}, time.Time, error)
+
+// selectCookie unseals the cookies named name of r with unseal and returns
+// the result that p selects. It returns ErrNoToken if there are none.
+// If no cookie unseals, the error of the first one is returned.
+func selectCookie(r *http.Request, name string, p DuplicatePolicy, unseal unsealFunc) (interface{}, error) {
+	var values []string
+	for _, c := range r.CookiesNamed(name) {
+		if !containsString(values, c.Value) {
+			values = append(values, c.Value)
+		}
+	}
+	switch {
+	case len(values) == 0:
+		return nil, ErrNoToken
+	case len(values) == 1 || p == FirstCookie:
+		v, _, err := unseal([]byte(values[0]))
+		return v, err
+	case p == RejectDuplicateCookies:
+		return nil, ErrDuplicateCookie
+	}
+	var (
+		best     interface{}
+		bestTime time.Time
+		firstErr error
+	)
+	for _, value := range values {
+		v, issued, err := unseal([]byte(value))
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if p == FirstValidCookie {
+			return v, nil
+		}
+		if best == nil || issued.After(bestTime) {
+			best, bestTime = v, issued
+		}
+	}
+	if best == nil {
+		return nil, firstErr
+	}
+	return best, nil
+}
+
+func containsString(s []string, v string) bool {
+	for _, x := range s {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
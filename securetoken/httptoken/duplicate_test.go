@@ -0,0 +1,57 @@
+package httptoken_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nicksnyder/go-securetoken/securetoken"
+	"github.com/nicksnyder/go-securetoken/securetoken/httptoken"
+	"github.com/nicksnyder/go-securetoken/securetoken/securetokentest"
+)
+
+func TestDuplicateCookies(t *testing.T) {
+	tok := securetokentest.NewTokener(t)
+	seal := func(subject string) string {
+		sealed, err := tok.SealClaims(&securetoken.Claims{Subject: subject})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(sealed)
+	}
+	older := seal("older")
+	tok.Clock.Advance(time.Second)
+	newer := seal("newer")
+
+	r := httptest.NewRequest("GET", "/", nil)
+	for _, v := range []string{"invalid", older, newer, older} {
+		r.AddCookie(&http.Cookie{Name: "session", Value: v})
+	}
+	tests := []struct {
+		policy  httptoken.DuplicatePolicy
+		subject string
+		err     error
+	}{
+		{httptoken.FirstCookie, "", securetoken.ErrTokenInvalid},
+		{httptoken.FirstValidCookie, "older", nil},
+		{httptoken.NewestCookie, "newer", nil},
+		{httptoken.RejectDuplicateCookies, "", httptoken.ErrDuplicateCookie},
+	}
+	for _, test := range tests {
+		m := &httptoken.Middleware{ClaimsUnsealer: tok, CookieName: "session", Duplicates: test.policy}
+		c, err := m.UnsealClaims(r)
+		if err != test.err || (err == nil && c.Subject != test.subject) {
+			t.Errorf("UnsealClaims() with policy %d = %+v, %v; expected subject %q, %v", test.policy, c, err, test.subject, test.err)
+		}
+	}
+
+	// Identical copies are not duplicates.
+	r = httptest.NewRequest("GET", "/", nil)
+	r.AddCookie(&http.Cookie{Name: "session", Value: newer})
+	r.AddCookie(&http.Cookie{Name: "session", Value: newer})
+	m := &httptoken.Middleware{ClaimsUnsealer: tok, CookieName: "session", Duplicates: httptoken.RejectDuplicateCookies}
+	if c, err := m.UnsealClaims(r); err != nil || c.Subject != "newer" {
+		t.Errorf("UnsealClaims() with identical cookies = %+v, %v; expected subject %q, <nil>", c, err, "newer")
+	}
+}
@@ -0,0 +1,77 @@
+package httptoken
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// RequireStrength returns middleware that only calls the next handler if the
+// claims in the request context show that the subject authenticated with
+// one of methods within maxAge (see securetoken.Claims.RequireStrength).
+// It must be used inside a Middleware with a ClaimsUnsealer, whose clock
+// it uses if it has one, such as a *securetoken.Tokener.
+// Other requests get a 401 Unauthorized response whose WWW-Authenticate header
+// asks for stronger authentication as in RFC 9470, so that the client can
+// send the user through step-up authentication.
+func RequireStrength(maxAge time.Duration, methods ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			c, ok := ClaimsFromContext(r.Context())
+			if !ok || c.RequireStrength(now(r), maxAge, methods...) != nil {
+				w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_user_authentication"`)
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireScopes returns middleware that only calls the next handler if the
+// claims in the request context have every one of scopes.
+// It must be used inside a Middleware with a ClaimsUnsealer.
+// Other requests get a 403 Forbidden response whose WWW-Authenticate header
+// names the required scopes as in RFC 6750.
+func RequireScopes(scopes ...string) func(http.Handler) http.Handler {
+	challenge := `Bearer error="insufficient_scope", scope="` + strings.Join(scopes, " ") + `"`
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			c, ok := ClaimsFromContext(r.Context())
+			if !ok || c.RequireScopes(scopes...) != nil {
+				w.Header().Set("WWW-Authenticate", challenge)
+				http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireFresh returns middleware that only calls the next handler if the
+// claims in the request context are not stale (see securetoken.WithSoftTTL).
+// It must be used inside a Middleware with a ClaimsUnsealer.
+// Other requests get a 401 Unauthorized response whose WWW-Authenticate header
+// says that the token is stale, so that the client can refresh it.
+func RequireFresh() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			c, ok := ClaimsFromContext(r.Context())
+			if !ok || c.Stale {
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token", error_description="stale"`)
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// now returns the current time according to the clock that the Middleware
+// stored in the context of r, or time.Now if there is none.
+func now(r *http.Request) time.Time {
+	if cl, ok := r.Context().Value(clockContextKey{}).(clock); ok {
+		return cl.Now()
+	}
+	return time.Now()
+}
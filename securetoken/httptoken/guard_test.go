@@ -0,0 +1,151 @@
+package httptoken_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nicksnyder/go-securetoken/securetoken"
+	"github.com/nicksnyder/go-securetoken/securetoken/httptoken"
+	"github.com/nicksnyder/go-securetoken/securetoken/httptoken/httptokentest"
+	"github.com/nicksnyder/go-securetoken/securetoken/securetokentest"
+)
+
+func TestRequireStrength(t *testing.T) {
+	tok := securetokentest.NewTokener(t)
+	m := &httptoken.Middleware{ClaimsUnsealer: tok, Bearer: true}
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, _ := httptoken.ClaimsFromContext(r.Context())
+		w.Write([]byte(c.Subject))
+	})
+	h := m.Handler(httptoken.RequireStrength(10*time.Minute, securetoken.AuthMFA)(ok))
+
+	request := func(methods ...string) *http.Request {
+		c := &securetoken.Claims{Subject: "alice"}
+		c.SetAuth(tok.Now(), methods...)
+		payload, err := json.Marshal(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return httptokentest.NewBearerRequest(t, tok, payload, "GET", "/")
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, request(securetoken.AuthPassword, securetoken.AuthMFA))
+	if rec.Code != 200 || rec.Body.String() != "alice" {
+		t.Errorf("ServeHTTP() = %d %q; expected 200 \"alice\"", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, request(securetoken.AuthPassword))
+	if rec.Code != 401 || rec.Header().Get("WWW-Authenticate") == "" {
+		t.Errorf("ServeHTTP() = %d with WWW-Authenticate %q; expected 401 with a challenge",
+			rec.Code, rec.Header().Get("WWW-Authenticate"))
+	}
+
+	// Authentication ages on the clock of the Tokener.
+	r := request(securetoken.AuthPassword, securetoken.AuthMFA)
+	tok.Clock.Advance(11 * time.Minute)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+	if rec.Code != 401 {
+		t.Errorf("ServeHTTP() after 11 minutes = %d; expected 401", rec.Code)
+	}
+}
+
+func TestRequireScopes(t *testing.T) {
+	tok := securetokentest.NewTokener(t)
+	m := &httptoken.Middleware{ClaimsUnsealer: tok, Bearer: true}
+	h := m.Handler(httptoken.RequireScopes("orders:read")(echo))
+
+	request := func(scopes ...string) *http.Request {
+		payload, err := json.Marshal(&securetoken.Claims{Subject: "svc", Scopes: scopes})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return httptokentest.NewBearerRequest(t, tok, payload, "GET", "/")
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, request("orders:read"))
+	if rec.Code != 200 {
+		t.Errorf("ServeHTTP() with scope = %d; expected 200", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, request("orders:write"))
+	if expected := `Bearer error="insufficient_scope", scope="orders:read"`; rec.Code != 403 || rec.Header().Get("WWW-Authenticate") != expected {
+		t.Errorf("ServeHTTP() without scope = %d with WWW-Authenticate %q; expected 403 with %q",
+			rec.Code, rec.Header().Get("WWW-Authenticate"), expected)
+	}
+}
+
+func TestMiddlewareAfterUnseal(t *testing.T) {
+	var got securetoken.RequestInfo
+	tok := securetokentest.NewTokener(t, securetoken.WithAfterUnseal(func(c *securetoken.Claims, info *securetoken.RequestInfo) error {
+		got = *info
+		if info.Method != "GET" && c.Subject != "admin" {
+			return fmt.Errorf("%w: %s may only read", securetoken.ErrPolicyDenied, c.Subject)
+		}
+		return nil
+	}))
+	h := (&httptoken.Middleware{ClaimsUnsealer: tok, Bearer: true}).Handler(echo)
+	request := func(subject, method string) *http.Request {
+		payload, err := json.Marshal(&securetoken.Claims{Subject: subject})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return httptokentest.NewBearerRequest(t, tok, payload, method, "/orders/1")
+	}
+
+	for _, test := range []struct {
+		subject, method string
+		code            int
+	}{
+		{"alice", "GET", 200},
+		{"alice", "DELETE", 403},
+		{"admin", "DELETE", 200},
+	} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, request(test.subject, test.method))
+		if rec.Code != test.code {
+			t.Errorf("%s by %s = %d; expected %d", test.method, test.subject, rec.Code, test.code)
+		}
+		if got.Method != test.method || got.Path != "/orders/1" || got.Caller == "" {
+			t.Errorf("hook called with %+v; expected the method, path and caller of the request", got)
+		}
+	}
+}
+
+func TestRequireFresh(t *testing.T) {
+	var now time.Time
+	tok := securetokentest.NewTokener(t,
+		securetoken.WithClock(func() time.Time { return now }),
+		securetoken.WithSoftTTL(securetokentest.TTL/4))
+	m := &httptoken.Middleware{ClaimsUnsealer: tok, Bearer: true}
+	h := m.Handler(httptoken.RequireFresh()(echo))
+
+	now = time.Unix(1000, 0)
+	payload, err := json.Marshal(&securetoken.Claims{Subject: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := httptokentest.NewBearerRequest(t, tok, payload, "GET", "/")
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+	if rec.Code != 200 {
+		t.Errorf("ServeHTTP() = %d; expected 200", rec.Code)
+	}
+
+	now = now.Add(securetokentest.TTL / 2)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+	if rec.Code != 401 || rec.Header().Get("WWW-Authenticate") == "" {
+		t.Errorf("ServeHTTP() with a stale token = %d with WWW-Authenticate %q; expected 401 with a challenge",
+			rec.Code, rec.Header().Get("WWW-Authenticate"))
+	}
+}
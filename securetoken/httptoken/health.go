@@ -0,0 +1,35 @@
+package httptoken
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/nicksnyder/go-securetoken/securetoken"
+)
+
+// A HealthChecker reports whether it is ready to serve traffic.
+// It is implemented by *securetoken.Tokener.
+type HealthChecker interface {
+	Healthy(ctx context.Context) error
+}
+
+var _ HealthChecker = (*securetoken.Tokener)(nil)
+
+// ReadinessHandler returns a handler for readiness probes that responds
+// with 200 OK if every checker is healthy and with 503 Service Unavailable
+// and the first error otherwise, so that an instance whose key provider
+// is broken does not receive traffic.
+func ReadinessHandler(checkers ...HealthChecker) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		for _, c := range checkers {
+			if err := c.Healthy(r.Context()); err != nil {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				w.Write([]byte(err.Error() + "\n"))
+				return
+			}
+		}
+		w.Write([]byte("ok\n"))
+	})
+}
@@ -0,0 +1,37 @@
+package httptoken_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/nicksnyder/go-securetoken/securetoken"
+	"github.com/nicksnyder/go-securetoken/securetoken/httptoken"
+	"github.com/nicksnyder/go-securetoken/securetoken/securetokentest"
+)
+
+func TestReadinessHandler(t *testing.T) {
+	kr := securetoken.NewKeyring()
+	if err := kr.AddKey(1, make([]byte, 16)); err != nil {
+		t.Fatal(err)
+	}
+	tok, err := securetoken.NewKeyringTokener(kr, securetokentest.TTL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := httptoken.ReadinessHandler(tok)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/ready", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("GET /ready = %d %q; expected %d", w.Code, w.Body, http.StatusOK)
+	}
+
+	kr.SetSealLimit(kr.SealCount(1), 0, nil)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/ready", nil))
+	if w.Code != http.StatusServiceUnavailable || !strings.Contains(w.Body.String(), "exhausted") {
+		t.Errorf("GET /ready with an exhausted key = %d %q; expected %d", w.Code, w.Body, http.StatusServiceUnavailable)
+	}
+}
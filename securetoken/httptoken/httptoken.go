@@ -0,0 +1,59 @@
+// Package httptoken carries sealed tokens in HTTP cookies, Authorization
+// headers and URL query strings.
+package httptoken
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/nicksnyder/go-securetoken/securetoken"
+)
+
+// ErrNoToken is returned when a request does not carry a token.
+var ErrNoToken = errors.New("httptoken: no token")
+
+type contextKey struct{}
+
+type claimsContextKey struct{}
+
+type clockContextKey struct{}
+
+// NewContext returns a copy of ctx that carries the unsealed payload.
+func NewContext(ctx context.Context, payload []byte) context.Context {
+	return context.WithValue(ctx, contextKey{}, payload)
+}
+
+// FromContext returns the unsealed payload stored in ctx by Middleware.
+func FromContext(ctx context.Context) ([]byte, bool) {
+	payload, ok := ctx.Value(contextKey{}).([]byte)
+	return payload, ok
+}
+
+// NewClaimsContext returns a copy of ctx that carries c.
+func NewClaimsContext(ctx context.Context, c *securetoken.Claims) context.Context {
+	return context.WithValue(ctx, claimsContextKey{}, c)
+}
+
+// ClaimsFromContext returns the claims stored in ctx by Middleware.
+func ClaimsFromContext(ctx context.Context) (*securetoken.Claims, bool) {
+	c, ok := ctx.Value(claimsContextKey{}).(*securetoken.Claims)
+	return c, ok
+}
+
+// BearerToken returns the token in the request's
+// "Authorization: Bearer" header or ErrNoToken if there is none.
+func BearerToken(r *http.Request) ([]byte, error) {
+	const prefix = "Bearer "
+	auth := r.Header.Get("Authorization")
+	if len(auth) <= len(prefix) || !strings.EqualFold(auth[:len(prefix)], prefix) {
+		return nil, ErrNoToken
+	}
+	return []byte(strings.TrimSpace(auth[len(prefix):])), nil
+}
+
+// SetBearerToken sets the request's Authorization header to carry token.
+func SetBearerToken(r *http.Request, token []byte) {
+	r.Header.Set("Authorization", "Bearer "+string(token))
+}
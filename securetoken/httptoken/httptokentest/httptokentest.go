@@ -0,0 +1,82 @@
+// Package httptokentest provides utilities for testing handlers
+// that use httptoken.
+package httptokentest
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nicksnyder/go-securetoken/securetoken"
+	"github.com/nicksnyder/go-securetoken/securetoken/httptoken"
+)
+
+// NewCookieRequest returns a request for method and target
+// that carries payload sealed by s in the cookie named name.
+func NewCookieRequest(tb testing.TB, s securetoken.Sealer, name string, payload []byte, method, target string) *http.Request {
+	tb.Helper()
+	r := httptest.NewRequest(method, target, nil)
+	r.AddCookie(&http.Cookie{Name: name, Value: string(seal(tb, s, payload))})
+	return r
+}
+
+// NewBearerRequest returns a request for method and target
+// that carries payload sealed by s in its Authorization header.
+func NewBearerRequest(tb testing.TB, s securetoken.Sealer, payload []byte, method, target string) *http.Request {
+	tb.Helper()
+	r := httptest.NewRequest(method, target, nil)
+	httptoken.SetBearerToken(r, seal(tb, s, payload))
+	return r
+}
+
+// AssertSetCookie fails the test unless rec sets the cookie named name
+// to a token that u unseals to expected.
+func AssertSetCookie(tb testing.TB, rec *httptest.ResponseRecorder, name string, u securetoken.Unsealer, expected []byte) {
+	tb.Helper()
+	c := findCookie(rec, name)
+	if c == nil {
+		tb.Errorf("response does not set cookie %q", name)
+		return
+	}
+	data, err := u.Unseal([]byte(c.Value))
+	if err != nil {
+		tb.Errorf("Unseal(%q) of cookie %q returned error: %s", c.Value, name, err)
+		return
+	}
+	if !bytes.Equal(data, expected) {
+		tb.Errorf("cookie %q unsealed to %q; expected %q", name, data, expected)
+	}
+}
+
+// AssertClearedCookie fails the test unless rec deletes the cookie named name.
+func AssertClearedCookie(tb testing.TB, rec *httptest.ResponseRecorder, name string) {
+	tb.Helper()
+	c := findCookie(rec, name)
+	if c == nil {
+		tb.Errorf("response does not set cookie %q", name)
+		return
+	}
+	if c.MaxAge >= 0 || c.Value != "" {
+		tb.Errorf("cookie %q = %q with MaxAge %d; expected deleted cookie", name, c.Value, c.MaxAge)
+	}
+}
+
+func findCookie(rec *httptest.ResponseRecorder, name string) *http.Cookie {
+	var found *http.Cookie
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == name {
+			found = c
+		}
+	}
+	return found
+}
+
+func seal(tb testing.TB, s securetoken.Sealer, payload []byte) []byte {
+	tb.Helper()
+	token, err := s.Seal(payload)
+	if err != nil {
+		tb.Fatalf("Seal(%q) returned error: %s", payload, err)
+	}
+	return token
+}
@@ -0,0 +1,41 @@
+package httptokentest
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nicksnyder/go-securetoken/securetoken/httptoken"
+	"github.com/nicksnyder/go-securetoken/securetoken/securetokentest"
+)
+
+func TestNewRequests(t *testing.T) {
+	tok := securetokentest.NewTokener(t)
+
+	r := NewCookieRequest(t, tok, "session", []byte("alice"), "GET", "/")
+	c, err := r.Cookie("session")
+	if err != nil {
+		t.Fatal(err)
+	}
+	securetokentest.AssertUnseals(t, tok, []byte(c.Value), []byte("alice"))
+
+	r = NewBearerRequest(t, tok, []byte("bob"), "GET", "/")
+	token, err := httptoken.BearerToken(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	securetokentest.AssertUnseals(t, tok, token, []byte("bob"))
+}
+
+func TestAssertSetCookie(t *testing.T) {
+	tok := securetokentest.NewTokener(t)
+	m := &httptoken.CookieManager{Tokener: tok, Name: "session"}
+	rec := httptest.NewRecorder()
+	if err := m.SetToken(rec, []byte("alice")); err != nil {
+		t.Fatal(err)
+	}
+	AssertSetCookie(t, rec, "session", tok, []byte("alice"))
+
+	rec = httptest.NewRecorder()
+	m.Clear(rec)
+	AssertClearedCookie(t, rec, "session")
+}
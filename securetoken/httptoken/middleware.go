@@ -0,0 +1,211 @@
+package httptoken
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/nicksnyder/go-securetoken/securetoken"
+)
+
+// Middleware unseals the token carried by a request and stores
+// its payload in the request context, where FromContext can read it.
+type Middleware struct {
+	// Unsealer unseals tokens.
+	Unsealer securetoken.Unsealer
+
+	// ClaimsUnsealer, if not nil, is used instead of Unsealer to unseal
+	// claims tokens, which are stored in the request context
+	// where ClaimsFromContext can read them.
+	ClaimsUnsealer securetoken.ClaimsUnsealer
+
+	// CookieName is the name of the cookie that carries the token.
+	// If it is empty, cookies are ignored.
+	CookieName string
+
+	// Bearer enables reading tokens from "Authorization: Bearer" headers.
+	// A bearer token takes precedence over a cookie.
+	Bearer bool
+
+	// Duplicates decides which cookie is used when a request carries
+	// several cookies named CookieName. It defaults to FirstCookie.
+	Duplicates DuplicatePolicy
+
+	// Audience, if not empty, rejects claims tokens that were not minted
+	// for it (see securetoken.WithAudience), e.g. the name of this service
+	// for tokens minted by a ServiceTransport. It requires a ClaimsUnsealer.
+	Audience string
+
+	// Optional passes requests without a token to the next handler
+	// instead of rejecting them. Requests with invalid tokens are always rejected.
+	Optional bool
+
+	// ErrorHandler is called when a request is rejected.
+	// If it is nil, rejected requests get a 401 Unauthorized response,
+	// or 429 Too Many Requests if the error is securetoken.ErrRateLimited.
+	ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
+
+	// OnVersionRejected, if not nil, is called instead of ErrorHandler
+	// when a request is rejected because its token has a format version
+	// that is no longer accepted (see securetoken.WithMinVersion), e.g. to
+	// clear the cookie and send the user to sign in again rather than
+	// show the error of an invalid token.
+	OnVersionRejected func(w http.ResponseWriter, r *http.Request)
+}
+
+// Handler returns a handler that authenticates requests before calling next.
+func (m *Middleware) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, err := m.authenticate(r)
+		if err == ErrNoToken && m.Optional {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if err != nil {
+			m.reject(w, r, err)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// authenticate returns the context of r with the unsealed token added.
+func (m *Middleware) authenticate(r *http.Request) (context.Context, error) {
+	if m.ClaimsUnsealer == nil {
+		payload, err := m.Unseal(r)
+		if err != nil {
+			return nil, err
+		}
+		return NewContext(r.Context(), payload), nil
+	}
+	c, err := m.UnsealClaims(r)
+	if err != nil {
+		return nil, err
+	}
+	ctx := NewClaimsContext(r.Context(), c)
+	// Let guards such as RequireStrength use the clock of the Tokener.
+	if cl, ok := m.ClaimsUnsealer.(clock); ok {
+		ctx = context.WithValue(ctx, clockContextKey{}, cl)
+	}
+	return ctx, nil
+}
+
+// callerUnsealer is implemented by Unsealers that rate limit failures per caller,
+// such as *securetoken.Tokener.
+type callerUnsealer interface {
+	UnsealFor(caller string, sealed []byte, opts ...securetoken.UnsealOption) ([]byte, error)
+}
+
+var _ callerUnsealer = (*securetoken.Tokener)(nil)
+
+// callerClaimsUnsealer is the claims equivalent of callerUnsealer.
+type callerClaimsUnsealer interface {
+	UnsealClaimsFor(caller string, sealed []byte, opts ...securetoken.UnsealOption) (*securetoken.Claims, error)
+}
+
+var _ callerClaimsUnsealer = (*securetoken.Tokener)(nil)
+
+// Unseal returns the payload of the token carried by r.
+// It returns ErrNoToken if r does not carry a token.
+// If the Unsealer has an UnsealFor method, it is called with
+// the IP address of the client.
+func (m *Middleware) Unseal(r *http.Request) ([]byte, error) {
+	v, err := m.unseal(r, func(token []byte) (interface{}, time.Time, error) {
+		var payload []byte
+		var err error
+		if u, ok := m.Unsealer.(callerUnsealer); ok {
+			payload, err = u.UnsealFor(clientIP(r), token)
+		} else {
+			payload, err = m.Unsealer.Unseal(token)
+		}
+		return payload, time.Time{}, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]byte), nil
+}
+
+// UnsealClaims returns the claims of the token carried by r.
+// It returns ErrNoToken if r does not carry a token, and
+// securetoken.ErrTokenInvalid for tickets (see Tickets).
+// The method, host and path of r are passed to the WithAfterUnseal hooks
+// of the ClaimsUnsealer.
+func (m *Middleware) UnsealClaims(r *http.Request) (*securetoken.Claims, error) {
+	opts := []securetoken.UnsealOption{securetoken.WithRequestInfo(securetoken.RequestInfo{
+		Caller: clientIP(r),
+		Method: r.Method,
+		Host:   r.Host,
+		Path:   r.URL.Path,
+	})}
+	if m.Audience != "" {
+		opts = append(opts, securetoken.WithAudience(m.Audience))
+	}
+	v, err := m.unseal(r, func(token []byte) (interface{}, time.Time, error) {
+		var c *securetoken.Claims
+		var err error
+		if u, ok := m.ClaimsUnsealer.(callerClaimsUnsealer); ok {
+			c, err = u.UnsealClaimsFor(clientIP(r), token, opts...)
+		} else {
+			c, err = m.ClaimsUnsealer.UnsealClaims(token, opts...)
+		}
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		// A ticket only authenticates the WebSocket handshake it was issued for.
+		if c.Purpose == PurposeTicket {
+			return nil, time.Time{}, securetoken.ErrTokenInvalid
+		}
+		return c, c.IssuedAt, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*securetoken.Claims), nil
+}
+
+// clientIP returns the IP address of the client that sent r.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// unseal unseals the token carried by r with unseal.
+// Several cookies are resolved by the Duplicates policy.
+func (m *Middleware) unseal(r *http.Request, unseal unsealFunc) (interface{}, error) {
+	if m.Bearer {
+		if token, err := BearerToken(r); err == nil {
+			v, _, err := unseal(token)
+			return v, err
+		}
+	}
+	if m.CookieName != "" {
+		return selectCookie(r, m.CookieName, m.Duplicates, unseal)
+	}
+	return nil, ErrNoToken
+}
+
+func (m *Middleware) reject(w http.ResponseWriter, r *http.Request, err error) {
+	if err == securetoken.ErrVersionRejected && m.OnVersionRejected != nil {
+		m.OnVersionRejected(w, r)
+		return
+	}
+	if m.ErrorHandler != nil {
+		m.ErrorHandler(w, r, err)
+		return
+	}
+	if err == securetoken.ErrRateLimited {
+		http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+		return
+	}
+	if errors.Is(err, securetoken.ErrPolicyDenied) {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+	http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+}
@@ -0,0 +1,136 @@
+package httptoken_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nicksnyder/go-securetoken/securetoken"
+	"github.com/nicksnyder/go-securetoken/securetoken/httptoken"
+	"github.com/nicksnyder/go-securetoken/securetoken/httptoken/httptokentest"
+	"github.com/nicksnyder/go-securetoken/securetoken/securetokentest"
+)
+
+// echo writes the payload stored in the request context.
+var echo = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	payload, ok := httptoken.FromContext(r.Context())
+	if !ok {
+		w.Write([]byte("anonymous"))
+		return
+	}
+	w.Write(payload)
+})
+
+func TestMiddleware(t *testing.T) {
+	tok := securetokentest.NewTokener(t)
+	m := &httptoken.Middleware{Unsealer: tok, CookieName: "session", Bearer: true}
+	h := m.Handler(echo)
+
+	invalid := httptest.NewRequest("GET", "/", nil)
+	invalid.AddCookie(&http.Cookie{Name: "session", Value: "invalid"})
+
+	tests := []struct {
+		r      *http.Request
+		code   int
+		body   string
+		option bool
+	}{
+		{httptokentest.NewCookieRequest(t, tok, "session", []byte("alice"), "GET", "/"), 200, "alice", false},
+		{httptokentest.NewBearerRequest(t, tok, []byte("bob"), "GET", "/"), 200, "bob", false},
+		{httptokentest.NewCookieRequest(t, tok, "other", []byte("alice"), "GET", "/"), 401, "", false},
+		{httptest.NewRequest("GET", "/", nil), 200, "anonymous", true},
+		{invalid, 401, "", true},
+	}
+	for _, test := range tests {
+		m.Optional = test.option
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, test.r)
+		if rec.Code != test.code {
+			t.Errorf("ServeHTTP() code = %d; expected %d", rec.Code, test.code)
+			continue
+		}
+		if test.code == 200 && rec.Body.String() != test.body {
+			t.Errorf("ServeHTTP() body = %q; expected %q", rec.Body.String(), test.body)
+		}
+	}
+}
+
+func TestCookieManager(t *testing.T) {
+	tok := securetokentest.NewTokener(t)
+	m := &httptoken.CookieManager{Tokener: tok, Name: "session", Path: "/"}
+
+	rec := httptest.NewRecorder()
+	if err := m.SetToken(rec, []byte("alice")); err != nil {
+		t.Fatal(err)
+	}
+	httptokentest.AssertSetCookie(t, rec, "session", tok, []byte("alice"))
+
+	r := httptest.NewRequest("GET", "/", nil)
+	if _, err := m.Token(r); err != httptoken.ErrNoToken {
+		t.Errorf("Token() returned %v; expected %s", err, httptoken.ErrNoToken)
+	}
+	r.AddCookie(rec.Result().Cookies()[0])
+	if payload, err := m.Token(r); err != nil || string(payload) != "alice" {
+		t.Errorf("Token() = %q, %v; expected \"alice\", <nil>", payload, err)
+	}
+
+	rec = httptest.NewRecorder()
+	m.Clear(rec)
+	httptokentest.AssertClearedCookie(t, rec, "session")
+}
+
+func TestMiddlewareRateLimited(t *testing.T) {
+	l := securetoken.NewFailureLimiter(securetoken.RateLimit{}, securetoken.RateLimit{Rate: 1, Burst: 1})
+	tok := securetokentest.NewTokener(t, securetoken.WithFailureLimiter(l))
+	h := (&httptoken.Middleware{Unsealer: tok, Bearer: true}).Handler(echo)
+
+	codes := []int{401, 429}
+	for _, code := range codes {
+		r := httptest.NewRequest("GET", "/", nil)
+		httptoken.SetBearerToken(r, []byte("invalid"))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, r)
+		if rec.Code != code {
+			t.Errorf("ServeHTTP() code = %d; expected %d", rec.Code, code)
+		}
+	}
+
+	// Other clients are not affected.
+	r := httptokentest.NewBearerRequest(t, tok, []byte("alice"), "GET", "/")
+	r.RemoteAddr = "192.0.2.2:1234"
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+	if rec.Code != 200 {
+		t.Errorf("ServeHTTP() code = %d; expected 200", rec.Code)
+	}
+}
+
+func TestMiddlewareVersionRejected(t *testing.T) {
+	key := []byte("0123456789abcdef")
+	old, err := securetoken.NewTokener(key, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kr := securetoken.NewKeyring()
+	if err := kr.AddKey(0, key); err != nil {
+		t.Fatal(err)
+	}
+	tok, err := securetoken.NewKeyringTokener(kr, time.Hour, securetoken.WithMinVersion(securetoken.Version2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &httptoken.Middleware{Unsealer: tok, Bearer: true, OnVersionRejected: func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/login", http.StatusFound)
+	}}
+	rec := httptest.NewRecorder()
+	m.Handler(echo).ServeHTTP(rec, httptokentest.NewBearerRequest(t, old, []byte("alice"), "GET", "/"))
+	if rec.Code != http.StatusFound {
+		t.Errorf("ServeHTTP() of a rejected version code = %d; expected %d", rec.Code, http.StatusFound)
+	}
+	rec = httptest.NewRecorder()
+	m.Handler(echo).ServeHTTP(rec, httptokentest.NewBearerRequest(t, tok, []byte("alice"), "GET", "/"))
+	if rec.Code != http.StatusOK {
+		t.Errorf("ServeHTTP() code = %d; expected %d", rec.Code, http.StatusOK)
+	}
+}
@@ -0,0 +1,52 @@
+package httptoken
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/nicksnyder/go-securetoken/securetoken"
+)
+
+// MaxURLLength is the longest URL that AppendToURL returns and the longest
+// token that FromRequestQuery unseals. Longer URLs are cut or rejected by
+// some browsers, proxies and email clients.
+const MaxURLLength = 2000
+
+// ErrURLTooLong is returned by AppendToURL when the URL with the token
+// would be longer than MaxURLLength.
+var ErrURLTooLong = errors.New("httptoken: URL too long")
+
+// AppendToURL seals data with s and sets it as the query parameter param of u,
+// replacing any existing values, for links in emails and webhook callbacks.
+// If the URL would be longer than MaxURLLength, u is left unchanged and
+// ErrURLTooLong is returned.
+func AppendToURL(s securetoken.Sealer, u *url.URL, param string, data []byte) error {
+	token, err := s.Seal(data)
+	if err != nil {
+		return err
+	}
+	q := u.Query()
+	q.Set(param, string(token))
+	c := *u
+	c.RawQuery = q.Encode()
+	if len(c.String()) > MaxURLLength {
+		return ErrURLTooLong
+	}
+	u.RawQuery = c.RawQuery
+	return nil
+}
+
+// FromRequestQuery unseals the query parameter param of r with u.
+// It returns ErrNoToken if r does not have the parameter and
+// securetoken.ErrTokenTooLong if it is longer than MaxURLLength.
+func FromRequestQuery(u securetoken.Unsealer, r *http.Request, param string) ([]byte, error) {
+	token := r.URL.Query().Get(param)
+	if token == "" {
+		return nil, ErrNoToken
+	}
+	if len(token) > MaxURLLength {
+		return nil, securetoken.ErrTokenTooLong
+	}
+	return u.Unseal([]byte(token))
+}
@@ -0,0 +1,46 @@
+package httptoken_test
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/nicksnyder/go-securetoken/securetoken"
+	"github.com/nicksnyder/go-securetoken/securetoken/httptoken"
+	"github.com/nicksnyder/go-securetoken/securetoken/securetokentest"
+)
+
+func TestQuery(t *testing.T) {
+	tok := securetokentest.NewTokener(t)
+	u, err := url.Parse("https://example.com/verify?lang=en&t=old")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := httptoken.AppendToURL(tok, u, "t", []byte("user 42")); err != nil {
+		t.Fatal(err)
+	}
+	if u.Query().Get("lang") != "en" || len(u.Query()["t"]) != 1 {
+		t.Errorf("AppendToURL() = %s; expected one t parameter and the rest of the URL kept", u)
+	}
+
+	r := httptest.NewRequest("GET", u.String(), nil)
+	if data, err := httptoken.FromRequestQuery(tok, r, "t"); string(data) != "user 42" || err != nil {
+		t.Errorf("FromRequestQuery(%s) = %q, %v; expected %q, <nil>", u, data, err, "user 42")
+	}
+	if data, err := httptoken.FromRequestQuery(tok, r, "missing"); data != nil || err != httptoken.ErrNoToken {
+		t.Errorf("FromRequestQuery(%s, missing) = %q, %v; expected <nil>, %s", u, data, err, httptoken.ErrNoToken)
+	}
+	long := "https://example.com/?t=" + strings.Repeat("a", httptoken.MaxURLLength+1)
+	if data, err := httptoken.FromRequestQuery(tok, httptest.NewRequest("GET", long, nil), "t"); data != nil || err != securetoken.ErrTokenTooLong {
+		t.Errorf("FromRequestQuery() of a long token = %q, %v; expected <nil>, %s", data, err, securetoken.ErrTokenTooLong)
+	}
+
+	before := u.String()
+	if err := httptoken.AppendToURL(tok, u, "t", make([]byte, httptoken.MaxURLLength)); err != httptoken.ErrURLTooLong {
+		t.Errorf("AppendToURL() of a large payload returned %v; expected %s", err, httptoken.ErrURLTooLong)
+	}
+	if u.String() != before {
+		t.Errorf("AppendToURL() changed the URL to %s despite failing", u)
+	}
+}
@@ -0,0 +1,106 @@
+package httptoken
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/nicksnyder/go-securetoken/securetoken"
+)
+
+var errRotatorTTL = errors.New("httptoken: Rotator.TTL is required for a Tokener without a TTL method")
+
+// A Rotator replaces the session cookie with a token with a fresh ID
+// whenever the privileges of the session change, such as at login or
+// after MFA, and revokes the ID of the old token. A session token that an
+// attacker planted in the victim's browser, or obtained before the change,
+// therefore never gains the new privileges (session fixation).
+type Rotator struct {
+	// Tokener seals and unseals session tokens. It should use Revocations
+	// (see securetoken.WithRevocationStore), so that revoked IDs are rejected.
+	Tokener securetoken.ClaimsSealUnsealer
+
+	// Cookie holds the session token. Only its name and attributes are used.
+	Cookie *CookieManager
+
+	// Revocations records the IDs of replaced tokens.
+	Revocations securetoken.RevocationStore
+
+	// TTL is how long after it was issued a replaced token stays revoked.
+	// Replaced tokens are revoked until they expire by the ttl and leeway
+	// of the Tokener, if it has TTL and Leeway methods (as a
+	// *securetoken.Tokener does), or by their claims TTL if that is longer;
+	// TTL only extends that. It is required for Tokeners without a TTL method.
+	TTL time.Duration
+}
+
+// RotateOnPrivilegeChange sets the session cookie to newClaims with a fresh
+// ID and revokes the ID of the session token of r, if it has one.
+// The Parents of the new token do not include the old ID, which would
+// revoke it too. newClaims is not modified. If the old ID cannot be revoked,
+// the cookie is left unchanged and the error is returned.
+func (rt *Rotator) RotateOnPrivilegeChange(w http.ResponseWriter, r *http.Request, newClaims *securetoken.Claims) error {
+	if err := rt.Cookie.Validate(); err != nil {
+		return err
+	}
+	var oldID string
+	var until time.Time
+	if cookie, err := r.Cookie(rt.Cookie.Name); err == nil {
+		if old, err := rt.Tokener.UnsealClaims([]byte(cookie.Value), securetoken.WithIgnoreExpiry()); err == nil && old.ID != "" {
+			if until, err = rt.expires(old); err != nil {
+				return err
+			}
+			oldID = old.ID
+		}
+	}
+
+	c := *newClaims
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return err
+	}
+	c.ID = base64.RawURLEncoding.EncodeToString(id)
+	if oldID != "" && len(c.Parents) > 0 {
+		parents := make([]string, 0, len(c.Parents))
+		for _, p := range c.Parents {
+			if p != oldID {
+				parents = append(parents, p)
+			}
+		}
+		c.Parents = parents
+	}
+	token, err := rt.Tokener.SealClaims(&c)
+	if err != nil {
+		return err
+	}
+
+	if oldID != "" {
+		if err := rt.Revocations.Revoke(oldID, until); err != nil {
+			return err
+		}
+	}
+	http.SetCookie(w, rt.Cookie.cookie(string(token)))
+	return nil
+}
+
+// expires returns when the token with claims c expires.
+func (rt *Rotator) expires(c *securetoken.Claims) (time.Time, error) {
+	ttl := rt.TTL
+	if t, ok := rt.Tokener.(interface{ TTL() time.Duration }); ok {
+		d := t.TTL()
+		if c.TTL > d {
+			d = c.TTL // An embedded ttl may be longer (see securetoken.WithEmbeddedTTL).
+		}
+		if l, ok := rt.Tokener.(interface{ Leeway() time.Duration }); ok {
+			d += l.Leeway()
+		}
+		if d > ttl {
+			ttl = d
+		}
+	} else if ttl <= 0 {
+		return time.Time{}, errRotatorTTL
+	}
+	return c.IssuedAt.Add(ttl), nil
+}
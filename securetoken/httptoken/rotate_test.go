@@ -0,0 +1,103 @@
+package httptoken_test
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nicksnyder/go-securetoken/securetoken"
+	"github.com/nicksnyder/go-securetoken/securetoken/httptoken"
+	"github.com/nicksnyder/go-securetoken/securetoken/securetokentest"
+)
+
+func TestRotateOnPrivilegeChange(t *testing.T) {
+	store := securetoken.NewMemoryRevocationStore()
+	tok := securetokentest.NewTokener(t, securetoken.WithRevocationStore(store))
+	rt := &httptoken.Rotator{
+		Tokener:     tok,
+		Cookie:      &httptoken.CookieManager{Name: "session"},
+		Revocations: store,
+	}
+
+	// A fixated anonymous session.
+	old, err := tok.SealClaims(&securetoken.Claims{ID: "planted"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := httptest.NewRequest("POST", "/login", nil)
+	r.Header.Set("Cookie", "session="+string(old))
+	rec := httptest.NewRecorder()
+	login := &securetoken.Claims{Subject: "alice", Parents: []string{"planted"}}
+	if err := rt.RotateOnPrivilegeChange(rec, r, login); err != nil {
+		t.Fatal(err)
+	}
+	if login.ID != "" || len(login.Parents) != 1 {
+		t.Errorf("RotateOnPrivilegeChange() modified newClaims to %+v", login)
+	}
+
+	if _, err := tok.UnsealClaims(old); err != securetoken.ErrTokenRevoked {
+		t.Errorf("UnsealClaims(old) returned %v; expected %s", err, securetoken.ErrTokenRevoked)
+	}
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != "session" {
+		t.Fatalf("RotateOnPrivilegeChange() set cookies %v; expected one session cookie", cookies)
+	}
+	c, err := tok.UnsealClaims([]byte(cookies[0].Value))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Subject != "alice" || c.ID == "" || c.ID == "planted" || len(c.Parents) != 0 {
+		t.Errorf("new session = %+v; expected subject alice with a fresh ID and no parents", c)
+	}
+
+	// Without a session cookie there is nothing to revoke.
+	rec = httptest.NewRecorder()
+	if err := rt.RotateOnPrivilegeChange(rec, httptest.NewRequest("POST", "/login", nil), login); err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.Result().Cookies()) != 1 {
+		t.Error("RotateOnPrivilegeChange() without a session cookie did not set one")
+	}
+}
+
+// untilStore records the expiry of the last revocation.
+type untilStore struct {
+	securetoken.RevocationStore
+	until time.Time
+}
+
+func (s *untilStore) Revoke(id string, until time.Time) error {
+	s.until = until
+	return s.RevocationStore.Revoke(id, until)
+}
+
+func TestRotateRevokesUntilExpiry(t *testing.T) {
+	store := &untilStore{RevocationStore: securetoken.NewMemoryRevocationStore()}
+	tok := securetokentest.NewTokener(t, securetoken.WithRevocationStore(store))
+	rt := &httptoken.Rotator{Tokener: tok, Cookie: &httptoken.CookieManager{Name: "session"}, Revocations: store}
+	old, err := tok.SealClaims(&securetoken.Claims{ID: "old"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tok.Clock.Advance(10 * time.Minute)
+	r := httptest.NewRequest("POST", "/login", nil)
+	r.Header.Set("Cookie", "session="+string(old))
+	if err := rt.RotateOnPrivilegeChange(httptest.NewRecorder(), r, &securetoken.Claims{Subject: "alice"}); err != nil {
+		t.Fatal(err)
+	}
+	if want := securetokentest.Now.Add(securetokentest.TTL); !store.until.Equal(want) {
+		t.Errorf("the old ID was revoked until %s; expected its expiry %s", store.until, want)
+	}
+
+	// A Tokener that does not report its ttl requires Rotator.TTL.
+	rt.Tokener = struct{ securetoken.ClaimsSealUnsealer }{tok}
+	other, err := tok.SealClaims(&securetoken.Claims{ID: "other"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r = httptest.NewRequest("POST", "/login", nil)
+	r.Header.Set("Cookie", "session="+string(other))
+	if err := rt.RotateOnPrivilegeChange(httptest.NewRecorder(), r, &securetoken.Claims{Subject: "alice"}); err == nil {
+		t.Error("RotateOnPrivilegeChange() without a TTL for a Tokener without a TTL method returned <nil>")
+	}
+}
@@ -0,0 +1,107 @@
+package httptoken
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/nicksnyder/go-securetoken/securetoken"
+)
+
+// A ServiceTransport is an http.RoundTripper that authenticates outbound
+// calls to other services with short-lived bearer tokens whose Audience is
+// the destination, so that a token captured by one service cannot be
+// replayed against another. The destination checks the audience with
+// Middleware.Audience. Tokens are minted once per destination and reused
+// until they are close to expiry.
+type ServiceTransport struct {
+	// Tokener seals the tokens. It must share keys with the Tokeners of
+	// the destinations.
+	Tokener securetoken.ClaimsSealer
+
+	// Subject identifies the calling service.
+	Subject string
+
+	// Scopes are the scopes of the tokens.
+	Scopes []string
+
+	// Audience returns the audience of the token for r.
+	// It defaults to the host of the request URL.
+	Audience func(r *http.Request) string
+
+	// TTL is the TTL of the tokens. It defaults to a minute,
+	// and tokens are minted again once less than a quarter of it remains.
+	TTL time.Duration
+
+	// Base performs the requests. It defaults to http.DefaultTransport.
+	Base http.RoundTripper
+
+	mu    sync.Mutex
+	cache map[string]serviceToken
+}
+
+type serviceToken struct {
+	token   []byte
+	refresh time.Time
+}
+
+// RoundTrip implements http.RoundTripper. It does not modify r.
+func (t *ServiceTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	token, err := t.token(t.audience(r))
+	if err != nil {
+		if r.Body != nil {
+			r.Body.Close()
+		}
+		return nil, err
+	}
+	r2 := r.Clone(r.Context())
+	SetBearerToken(r2, token)
+	return t.base().RoundTrip(r2)
+}
+
+// token returns a token for audience, minting one if the cached token
+// is missing or close to expiry.
+func (t *ServiceTransport) token(audience string) ([]byte, error) {
+	now := time.Now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if c, ok := t.cache[audience]; ok && now.Before(c.refresh) {
+		return c.token, nil
+	}
+	ttl := t.ttl()
+	token, err := t.Tokener.SealClaims(&securetoken.Claims{
+		Subject:  t.Subject,
+		Audience: audience,
+		Scopes:   t.Scopes,
+		TTL:      ttl,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if t.cache == nil {
+		t.cache = make(map[string]serviceToken)
+	}
+	t.cache[audience] = serviceToken{token: token, refresh: now.Add(ttl - ttl/4)}
+	return token, nil
+}
+
+func (t *ServiceTransport) audience(r *http.Request) string {
+	if t.Audience != nil {
+		return t.Audience(r)
+	}
+	return r.URL.Host
+}
+
+func (t *ServiceTransport) ttl() time.Duration {
+	if t.TTL > 0 {
+		return t.TTL
+	}
+	return time.Minute
+}
+
+func (t *ServiceTransport) base() http.RoundTripper {
+	if t.Base != nil {
+		return t.Base
+	}
+	return http.DefaultTransport
+}
@@ -0,0 +1,81 @@
+package httptoken_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/nicksnyder/go-securetoken/securetoken/httptoken"
+	"github.com/nicksnyder/go-securetoken/securetoken/securetokentest"
+)
+
+func TestServiceTransport(t *testing.T) {
+	tok := securetokentest.NewTokener(t)
+	orders := &httptoken.Middleware{ClaimsUnsealer: tok, Bearer: true, Audience: "orders"}
+	h := orders.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, _ := httptoken.ClaimsFromContext(r.Context())
+		io.WriteString(w, c.Subject)
+	}))
+
+	var tokens []string
+	st := &httptoken.ServiceTransport{
+		Tokener: tok,
+		Subject: "billing",
+		Base: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			token, _ := httptoken.BearerToken(r)
+			tokens = append(tokens, string(token))
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, r)
+			return rec.Result(), nil
+		}),
+	}
+	client := &http.Client{Transport: st}
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get("http://orders/items")
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != 200 || string(body) != "billing" {
+			t.Errorf("GET http://orders/items = %d %q; expected 200 \"billing\"", resp.StatusCode, body)
+		}
+	}
+	if len(tokens) != 2 || tokens[0] != tokens[1] {
+		t.Errorf("ServiceTransport sent %d tokens that differ; expected one cached token", len(tokens))
+	}
+
+	// A token minted for another service is rejected.
+	resp, err := client.Get("http://payments/charge")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 401 {
+		t.Errorf("GET with a token for another audience = %d; expected 401", resp.StatusCode)
+	}
+	if tokens[2] == tokens[0] {
+		t.Error("ServiceTransport reused the token of another audience")
+	}
+
+	// The request is not modified.
+	r := &http.Request{Method: "GET", URL: &url.URL{Scheme: "http", Host: "orders", Path: "/"}, Header: http.Header{}}
+	if _, err := st.RoundTrip(r); err != nil {
+		t.Fatal(err)
+	}
+	if r.Header.Get("Authorization") != "" {
+		t.Error("RoundTrip() modified the request")
+	}
+
+	c, err := tok.UnsealClaims([]byte(tokens[0]))
+	if err != nil || c.Audience != "orders" || c.TTL <= 0 {
+		t.Errorf("UnsealClaims(token) = %+v, %v; expected audience orders and a TTL", c, err)
+	}
+}
+
+type roundTripFunc func(r *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
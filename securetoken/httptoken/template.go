@@ -0,0 +1,38 @@
+package httptoken
+
+import (
+	"errors"
+	"html/template"
+
+	"github.com/nicksnyder/go-securetoken/securetoken"
+)
+
+var errNoCSRF = errors.New("httptoken: csrfField used without a CSRF")
+
+// FuncMap returns html/template functions that render sealed tokens:
+//
+//	{{csrfField .Session}}  a hidden form field with a CSRF token for the session
+//	{{sealedValue .Data}}   the string .Data sealed by s, e.g. for a form field or link
+//
+// Tokens are URL and attribute safe, and html/template escapes them
+// according to context like any other value. csrf may be nil
+// if csrfField is not used.
+func FuncMap(s securetoken.Sealer, csrf *CSRF) template.FuncMap {
+	return template.FuncMap{
+		"csrfField": func(session string) (template.HTML, error) {
+			if csrf == nil {
+				return "", errNoCSRF
+			}
+			token, err := csrf.Token(session)
+			if err != nil {
+				return "", err
+			}
+			return template.HTML(`<input type="hidden" name="` + template.HTMLEscapeString(csrf.fieldName()) +
+				`" value="` + template.HTMLEscapeString(string(token)) + `">`), nil
+		},
+		"sealedValue": func(value string) (string, error) {
+			sealed, err := s.Seal([]byte(value))
+			return string(sealed), err
+		},
+	}
+}
@@ -0,0 +1,214 @@
+package httptoken
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nicksnyder/go-securetoken/securetoken"
+)
+
+// PurposeTicket is the purpose of WebSocket tickets.
+const PurposeTicket = "securetoken.ws-ticket"
+
+// TicketProtocolPrefix prefixes a ticket sent as a WebSocket subprotocol.
+const TicketProtocolPrefix = "ticket."
+
+// ErrTicketUsed is returned by Tickets.Verify when a ticket has already been used.
+var ErrTicketUsed = errors.New("httptoken: ticket already used")
+
+// Tickets issues and verifies WebSocket tickets.
+//
+// Browsers cannot set headers on WebSocket handshakes, so the page
+// requests a ticket over an authenticated HTTP request first and passes
+// it in the query string or as a subprotocol (see TicketProtocol).
+// A ticket is valid for a few seconds and only once, and Middleware
+// rejects it, but it still carries the identity of the session until it
+// is used or expires: keep query strings with tickets out of access logs,
+// or prefer TicketProtocol.
+type Tickets struct {
+	// Tokener seals tickets. It may be shared with session tokens;
+	// tickets have their own purpose and TTL. If it has Now and Leeway
+	// methods, such as *securetoken.Tokener, they decide how long a
+	// used ticket is remembered.
+	Tokener securetoken.ClaimsSealUnsealer
+
+	// Used records the tickets that have been used. A MemoryUsedTickets
+	// should use the clock of Tokener.
+	Used UsedTickets
+
+	// TTL is how long a ticket is valid. It defaults to 30 seconds.
+	TTL time.Duration
+
+	// QueryParam is the query parameter that carries the ticket.
+	// It defaults to "ticket".
+	QueryParam string
+}
+
+// UsedTickets records which tickets have been used.
+// Implementations must be goroutine safe.
+type UsedTickets interface {
+	// Use marks the ticket id as used until the given time and reports
+	// whether this is the first use.
+	Use(id string, until time.Time) (bool, error)
+}
+
+// Issue returns a ticket for the session with claims c.
+// The ticket carries the subject, session version, authentication
+// and scopes of the session, so the WebSocket handler sees the same
+// identity as the page that requested the ticket.
+func (t *Tickets) Issue(c *securetoken.Claims) ([]byte, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	return t.Tokener.SealClaims(&securetoken.Claims{
+		ID:             base64.RawURLEncoding.EncodeToString(id),
+		Subject:        c.Subject,
+		Purpose:        PurposeTicket,
+		Scopes:         c.Scopes,
+		TTL:            t.ttl(),
+		SessionVersion: c.SessionVersion,
+		AuthMethods:    c.AuthMethods,
+		AuthTime:       c.AuthTime,
+		Actor:          c.Actor,
+	})
+}
+
+// Verify returns the claims of the ticket carried by the WebSocket
+// handshake r and marks it as used. It should be called before upgrading.
+// It returns ErrNoToken if r does not carry a ticket.
+func (t *Tickets) Verify(r *http.Request) (*securetoken.Claims, error) {
+	ticket := t.ticket(r)
+	if ticket == nil {
+		return nil, ErrNoToken
+	}
+	c, err := t.Tokener.UnsealClaims(ticket)
+	if err != nil {
+		return nil, err
+	}
+	if c.Purpose != PurposeTicket || c.ID == "" {
+		return nil, securetoken.ErrTokenInvalid
+	}
+	// Remember the ticket for as long as Tokener accepts it.
+	var leeway time.Duration
+	if cl, ok := t.Tokener.(clock); ok {
+		leeway = cl.Leeway()
+	}
+	first, err := t.Used.Use(c.ID, c.IssuedAt.Add(c.TTL+leeway))
+	if err != nil {
+		return nil, err
+	}
+	if !first {
+		return nil, ErrTicketUsed
+	}
+	return c, nil
+}
+
+// TicketProtocol returns the WebSocket subprotocol that carries ticket.
+// Subprotocols cannot contain '=', so the base64 padding is removed;
+// Verify restores it.
+func TicketProtocol(ticket []byte) string {
+	return TicketProtocolPrefix + strings.TrimRight(string(ticket), "=")
+}
+
+func (t *Tickets) ticket(r *http.Request) []byte {
+	if ticket := r.URL.Query().Get(t.queryParam()); ticket != "" {
+		return []byte(ticket)
+	}
+	for _, h := range r.Header.Values("Sec-WebSocket-Protocol") {
+		for _, p := range strings.Split(h, ",") {
+			p = strings.TrimSpace(p)
+			if strings.HasPrefix(p, TicketProtocolPrefix) {
+				ticket := p[len(TicketProtocolPrefix):]
+				if n := len(ticket) % 4; n != 0 {
+					ticket += strings.Repeat("=", 4-n)
+				}
+				return []byte(ticket)
+			}
+		}
+	}
+	return nil
+}
+
+func (t *Tickets) ttl() time.Duration {
+	if t.TTL > 0 {
+		return t.TTL
+	}
+	return 30 * time.Second
+}
+
+func (t *Tickets) queryParam() string {
+	if t.QueryParam != "" {
+		return t.QueryParam
+	}
+	return "ticket"
+}
+
+// A clock is implemented by Tokeners whose notion of time should be
+// shared, such as *securetoken.Tokener.
+type clock interface {
+	Now() time.Time
+	Leeway() time.Duration
+}
+
+var _ clock = (*securetoken.Tokener)(nil)
+
+// MemoryUsedTickets is a UsedTickets that keeps ticket ids in memory.
+// It is goroutine safe.
+type MemoryUsedTickets struct {
+	mu   sync.Mutex
+	used map[string]time.Time
+	now  func() time.Time
+}
+
+// NewMemoryUsedTickets returns an empty MemoryUsedTickets that forgets
+// tickets once now is past the time they were used until. now should be
+// the clock of the Tokener that seals the tickets, e.g. its Now method;
+// if it is nil, time.Now is used.
+func NewMemoryUsedTickets(now func() time.Time) *MemoryUsedTickets {
+	if now == nil {
+		now = time.Now
+	}
+	return &MemoryUsedTickets{used: make(map[string]time.Time), now: now}
+}
+
+// Use implements UsedTickets.
+func (m *MemoryUsedTickets) Use(id string, until time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	for id, t := range m.used {
+		if now.After(t) {
+			delete(m.used, id)
+		}
+	}
+	if _, ok := m.used[id]; ok {
+		return false, nil
+	}
+	m.used[id] = until
+	return true, nil
+}
+
+var _ securetoken.RevocationLister = (*MemoryUsedTickets)(nil)
+
+// Each implements securetoken.RevocationLister, so that used tickets can be
+// exported with securetoken.ExportRevocations.
+func (m *MemoryUsedTickets) Each(f func(id string, until time.Time) error) error {
+	m.mu.Lock()
+	used := make(map[string]time.Time, len(m.used))
+	for id, until := range m.used {
+		used[id] = until
+	}
+	m.mu.Unlock()
+	for id, until := range used {
+		if err := f(id, until); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package httptoken_test
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/nicksnyder/go-securetoken/securetoken"
+	"github.com/nicksnyder/go-securetoken/securetoken/httptoken"
+	"github.com/nicksnyder/go-securetoken/securetoken/securetokentest"
+)
+
+func TestTickets(t *testing.T) {
+	tok := securetokentest.NewTokener(t, securetoken.WithLeeway(10*time.Second))
+	tickets := &httptoken.Tickets{Tokener: tok, Used: httptoken.NewMemoryUsedTickets(tok.Now)}
+	session := &securetoken.Claims{Subject: "alice", SessionVersion: 3}
+
+	ticket, err := tickets.Issue(session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := httptest.NewRequest("GET", "/ws?ticket="+url.QueryEscape(string(ticket)), nil)
+	c, err := tickets.Verify(r)
+	if err != nil || c.Subject != "alice" || c.SessionVersion != 3 {
+		t.Errorf("Verify() = %+v, %v; expected the session of alice", c, err)
+	}
+	if _, err := tickets.Verify(r); err != httptoken.ErrTicketUsed {
+		t.Errorf("second Verify() returned %v; expected %s", err, httptoken.ErrTicketUsed)
+	}
+	// The ticket is remembered for as long as the leeway lets it unseal.
+	tok.Clock.Advance(35 * time.Second)
+	if _, err := tickets.Verify(r); err != httptoken.ErrTicketUsed {
+		t.Errorf("Verify() within the leeway returned %v; expected %s", err, httptoken.ErrTicketUsed)
+	}
+
+	ticket, err = tickets.Issue(session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r = httptest.NewRequest("GET", "/ws", nil)
+	r.Header.Set("Sec-WebSocket-Protocol", "chat, "+httptoken.TicketProtocol(ticket))
+	if c, err := tickets.Verify(r); err != nil || c.Subject != "alice" {
+		t.Errorf("Verify() with subprotocol = %+v, %v; expected alice, <nil>", c, err)
+	}
+
+	session.Purpose = "session"
+	notTicket, err := tok.SealClaims(session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r = httptest.NewRequest("GET", "/ws?ticket="+url.QueryEscape(string(notTicket)), nil)
+	if _, err := tickets.Verify(r); err != securetoken.ErrTokenInvalid {
+		t.Errorf("Verify() with a session token returned %v; expected %s", err, securetoken.ErrTokenInvalid)
+	}
+	// A ticket is not a session token.
+	m := &httptoken.Middleware{ClaimsUnsealer: tok, Bearer: true}
+	r = httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Authorization", "Bearer "+string(ticket))
+	if _, err := m.UnsealClaims(r); err != securetoken.ErrTokenInvalid {
+		t.Errorf("Middleware.UnsealClaims() of a ticket returned %v; expected %s", err, securetoken.ErrTokenInvalid)
+	}
+
+	if _, err := tickets.Verify(httptest.NewRequest("GET", "/ws", nil)); err != httptoken.ErrNoToken {
+		t.Errorf("Verify() without ticket returned %v; expected %s", err, httptoken.ErrNoToken)
+	}
+}
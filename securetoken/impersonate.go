@@ -0,0 +1,55 @@
+package securetoken
+
+// An Actor is a principal acting on behalf of the subject of Claims,
+// as in the "act" claim of RFC 8693.
+// If the actor is itself acting on behalf of another principal,
+// Actor records that principal, forming a chain from the
+// most recent actor to the original one.
+type Actor struct {
+	// Subject identifies the actor, such as an admin user id.
+	Subject string `json:"sub"`
+
+	// Expires, if not zero, is when this actor stops being allowed to act,
+	// in seconds since the Unix epoch (see Tokener.Delegate).
+	Expires int64 `json:"exp,omitempty"`
+
+	// Actor is the principal that this actor is acting for, if any.
+	Actor *Actor `json:"act,omitempty"`
+}
+
+// Impersonate returns a copy of c for actor acting as the subject of c.
+// If c already has an actor, it is kept at the end of the chain,
+// so a token is never able to hide who originally acted.
+// The copy keeps the authentication methods and time of c,
+// which describe how the subject (not the actor) authenticated.
+func (c *Claims) Impersonate(actor string) *Claims {
+	imp := *c
+	imp.Actor = &Actor{Subject: actor, Actor: c.Actor}
+	return &imp
+}
+
+// Impersonated reports whether c was issued to an actor on behalf of its subject.
+func (c *Claims) Impersonated() bool {
+	return c.Actor != nil
+}
+
+// ActorChain returns the subjects of the actors of c,
+// from the most recent to the original one.
+func (c *Claims) ActorChain() []string {
+	var chain []string
+	for a := c.Actor; a != nil; a = a.Actor {
+		chain = append(chain, a.Subject)
+	}
+	return chain
+}
+
+// WithAuditHook returns an Option that makes UnsealClaims call hook
+// with the claims of every token it accepts, such as to record
+// each use of an impersonation token along with its ActorChain.
+// hook must not modify the claims.
+func WithAuditHook(hook func(*Claims)) Option {
+	return func(t *Tokener) error {
+		t.auditHook = hook
+		return nil
+	}
+}
@@ -0,0 +1,37 @@
+package securetoken
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestImpersonate(t *testing.T) {
+	var audited []*Claims
+	tok, err := NewTokener(key, ttl, WithAuditHook(func(c *Claims) {
+		audited = append(audited, c)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user := &Claims{Subject: "alice"}
+	imp := user.Impersonate("support-bob").Impersonate("admin-carol")
+	if user.Impersonated() {
+		t.Errorf("Impersonate modified the original claims: %+v", user)
+	}
+	sealed, err := tok.SealClaims(imp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := tok.UnsealClaims(sealed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"admin-carol", "support-bob"}
+	if c.Subject != "alice" || !c.Impersonated() || !reflect.DeepEqual(c.ActorChain(), expected) {
+		t.Errorf("UnsealClaims() = subject %q, actors %q; expected alice, %q", c.Subject, c.ActorChain(), expected)
+	}
+	if len(audited) != 1 || audited[0] != c {
+		t.Errorf("audit hook called with %+v; expected one call with the unsealed claims", audited)
+	}
+}
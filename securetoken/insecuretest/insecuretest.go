@@ -0,0 +1,88 @@
+// Package insecuretest provides a Tokener that performs no cryptography.
+//
+// Its tokens are the base64 encoding of Prefix followed by the plaintext,
+// so tests can read payloads straight out of tokens without managing keys.
+// It must never be used outside of tests: New panics unless it is called
+// from a test binary, and a real securetoken.Tokener rejects its tokens.
+package insecuretest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+
+	"github.com/nicksnyder/go-securetoken/securetoken"
+)
+
+// Prefix is prepended to the plaintext of every token before encoding.
+const Prefix = "insecuretest:"
+
+var encoding = base64.URLEncoding
+
+// A Tokener "seals" tokens by encoding them without encryption.
+// It is goroutine safe.
+type Tokener struct{}
+
+var _ securetoken.SealUnsealer = (*Tokener)(nil)
+
+// New returns a Tokener.
+// It panics if it is not called from a test binary.
+func New() *Tokener {
+	if !testing.Testing() {
+		panic("insecuretest: New called outside of a test binary")
+	}
+	return &Tokener{}
+}
+
+// SealString is similar to Seal except its input is a string
+// and it returns a string. The string is empty if and only if
+// the error is not nil.
+func (t *Tokener) SealString(plaintext string) (string, error) {
+	tok, err := t.Seal([]byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+	return string(tok), nil
+}
+
+// Seal returns the base64 encoding of Prefix followed by plaintext.
+func (t *Tokener) Seal(plaintext []byte) ([]byte, error) {
+	raw := append([]byte(Prefix), plaintext...)
+	buf := make([]byte, encoding.EncodedLen(len(raw)))
+	encoding.Encode(buf, raw)
+	return buf, nil
+}
+
+// UnsealString is similar to Unseal except its input is a string
+// and it returns a string. The string is empty if the error is not nil,
+// so it never holds part of an invalid token.
+func (t *Tokener) UnsealString(encoded string, opts ...securetoken.UnsealOption) (string, error) {
+	buf, err := t.Unseal([]byte(encoded), opts...)
+	if err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+// UnsealStringOr is similar to UnsealString except it returns def
+// instead of an error, for callers that treat every invalid token alike,
+// e.g. to fall back to a default preference.
+func (t *Tokener) UnsealStringOr(encoded, def string, opts ...securetoken.UnsealOption) string {
+	s, err := t.UnsealString(encoded, opts...)
+	if err != nil {
+		return def
+	}
+	return s
+}
+
+// Unseal returns the plaintext of a token produced by Seal.
+// It returns securetoken.ErrTokenInvalid if sealed was not produced by Seal.
+// Tokens never expire and carry no additional data, so opts are ignored.
+func (t *Tokener) Unseal(sealed []byte, opts ...securetoken.UnsealOption) ([]byte, error) {
+	buf := make([]byte, encoding.DecodedLen(len(sealed)))
+	n, err := encoding.Decode(buf, sealed)
+	if err != nil || !bytes.HasPrefix(buf[:n], []byte(Prefix)) {
+		return nil, securetoken.ErrTokenInvalid
+	}
+	return buf[len(Prefix):n], nil
+}
@@ -0,0 +1,45 @@
+package insecuretest
+
+import (
+	"testing"
+
+	"github.com/nicksnyder/go-securetoken/securetoken"
+)
+
+func TestSealUnseal(t *testing.T) {
+	tok := New()
+	for _, data := range []string{"", " ", "a.person@some.domain.com"} {
+		sealed, err := tok.SealString(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		unsealed, err := tok.UnsealString(sealed)
+		if err != nil || unsealed != data {
+			t.Errorf("UnsealString(%q) = %q, %v; expected %q, <nil>", sealed, unsealed, err, data)
+		}
+	}
+}
+
+func TestUnsealInvalid(t *testing.T) {
+	tok := New()
+	for _, sealed := range []string{"", "asdf", "aW5zZWN1cmU="} {
+		if data, err := tok.UnsealString(sealed); data != "" || err == nil {
+			t.Errorf("UnsealString(%q) = %q, %v; expected \"\", error", sealed, data, err)
+		}
+	}
+}
+
+// TestRejectedByTokener tests that real Tokeners never accept insecure tokens.
+func TestRejectedByTokener(t *testing.T) {
+	real, err := securetoken.NewTokener([]byte("1111111111111111"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := New().Seal([]byte("data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := real.Unseal(sealed); err != securetoken.ErrTokenInvalid {
+		t.Errorf("Unseal(%q) = %v; expected %s", sealed, err, securetoken.ErrTokenInvalid)
+	}
+}
@@ -0,0 +1,39 @@
+package securetoken
+
+import (
+	"crypto/cipher"
+	"fmt"
+)
+
+// keyCheckLabel is the additional data that key check values authenticate.
+const keyCheckLabel = "securetoken key check value v1"
+
+// KeyCheckValue returns the key check value of the AES-GCM key key:
+// a value that identifies the key without revealing it, so that
+// two parties can confirm that they hold the same key.
+func KeyCheckValue(key []byte) ([]byte, error) {
+	aead, err := newAESGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	return keyCheckValue(aead)
+}
+
+// KeyCheckValue returns the key check value of the key with the given id,
+// which for an AES-GCM key equals KeyCheckValue of its bytes.
+func (k *Keyring) KeyCheckValue(id uint32) ([]byte, error) {
+	e, ok := k.load().keys[id]
+	if !ok {
+		return nil, fmt.Errorf("securetoken: key %d does not exist", id)
+	}
+	return keyCheckValue(e.aead)
+}
+
+// keyCheckValue returns the tag of an empty plaintext with keyCheckLabel
+// as additional data, sealed with the all-zero nonce. Tokens are sealed
+// with nonces that start with the time they were sealed at, so they never
+// use the nonce of the key check value.
+func keyCheckValue(aead cipher.AEAD) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	return sealAEAD(aead, nil, nonce, nil, []byte(keyCheckLabel))
+}
@@ -0,0 +1,39 @@
+package securetoken
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestKeyCheckValue(t *testing.T) {
+	kr := NewKeyring()
+	if err := kr.AddKey(1, key); err != nil {
+		t.Fatal(err)
+	}
+	if err := kr.AddLockedKey(2, append([]byte(nil), key...)); err != nil {
+		t.Fatal(err)
+	}
+	other := bytes.Repeat([]byte{'o'}, 32)
+	if err := kr.AddKey(3, other); err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := KeyCheckValue(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []uint32{1, 2} {
+		if kcv, err := kr.KeyCheckValue(id); !bytes.Equal(kcv, want) || err != nil {
+			t.Errorf("KeyCheckValue(%d) = %x, %v; expected %x, <nil>", id, kcv, err, want)
+		}
+	}
+	if kcv, err := kr.KeyCheckValue(3); bytes.Equal(kcv, want) || err != nil {
+		t.Errorf("KeyCheckValue(3) of another key = %x, %v; expected a different value", kcv, err)
+	}
+	if _, err := kr.KeyCheckValue(4); err == nil {
+		t.Error("KeyCheckValue(4) of a missing key returned <nil>")
+	}
+	if _, err := KeyCheckValue([]byte("short")); err == nil {
+		t.Error("KeyCheckValue() of an invalid key returned <nil>")
+	}
+}
@@ -0,0 +1,97 @@
+package securetoken
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// ErrWeakKey is wrapped by the errors that CheckKey returns.
+var ErrWeakKey = errors.New("securetoken: weak key")
+
+var errKeyCheckKeyring = errors.New("securetoken: WithKeyCheck requires NewTokener; check keyring keys with CheckKey before adding them")
+
+const keyAdvice = "generate keys with crypto/rand (e.g. `head -c 32 /dev/urandom | base64`) and decode them before use"
+
+// CheckKey returns an error wrapping ErrWeakKey if key looks like it was not
+// generated by a cryptographically secure random number generator:
+// if it is base64 or hex text that should have been decoded,
+// if it is printable ASCII such as a password, or if it has very few
+// distinct bytes. A random key fails these checks with negligible probability.
+func CheckKey(key []byte) error {
+	if len(key) == 0 {
+		return fmt.Errorf("%w: key is empty; %s", ErrWeakKey, keyAdvice)
+	}
+	if isEncodedKey(key) {
+		return fmt.Errorf("%w: key looks like base64 or hex text; %s", ErrWeakKey, keyAdvice)
+	}
+	if isPrintable(key) {
+		return fmt.Errorf("%w: key looks like a password; %s", ErrWeakKey, keyAdvice)
+	}
+	if distinctBytes(key) < len(key)/2 {
+		return fmt.Errorf("%w: key has too little entropy; %s", ErrWeakKey, keyAdvice)
+	}
+	return nil
+}
+
+// WithKeyCheck returns an Option that makes NewTokener
+// reject keys that fail CheckKey. A keyring does not keep its keys,
+// so NewKeyringTokener returns an error if it is given this option.
+func WithKeyCheck() Option {
+	return func(t *Tokener) error {
+		t.checkKey = true
+		return nil
+	}
+}
+
+// isEncodedKey reports whether key is the base64 or hex encoding
+// of a valid AES key.
+func isEncodedKey(key []byte) bool {
+	_, ok := decodeKey(string(key))
+	return ok
+}
+
+// decodeKey decodes s as hex or, failing that, as base64 in any of its
+// common variants, and reports whether it decoded to a valid AES key.
+// Hex is tried first because hex text is also valid base64.
+func decodeKey(s string) ([]byte, bool) {
+	decoders := []func(string) ([]byte, error){
+		hex.DecodeString,
+		base64.StdEncoding.DecodeString,
+		base64.URLEncoding.DecodeString,
+		base64.RawStdEncoding.DecodeString,
+		base64.RawURLEncoding.DecodeString,
+	}
+	for _, decode := range decoders {
+		if b, err := decode(s); err == nil && isKeyLength(len(b)) {
+			return b, true
+		}
+	}
+	return nil, false
+}
+
+func isKeyLength(n int) bool {
+	return n == 16 || n == 24 || n == 32
+}
+
+func isPrintable(key []byte) bool {
+	for _, b := range key {
+		if b < 0x20 || b > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func distinctBytes(key []byte) int {
+	var seen [256]bool
+	n := 0
+	for _, b := range key {
+		if !seen[b] {
+			seen[b] = true
+			n++
+		}
+	}
+	return n
+}
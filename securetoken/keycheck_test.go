@@ -0,0 +1,48 @@
+package securetoken
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+)
+
+func TestCheckKey(t *testing.T) {
+	weak := []string{
+		"",
+		"1234567887654321",
+		"correct horse battery staple!!!!",
+		"MDEyMzQ1Njc4OWFiY2RlZg==",
+		"000102030405060708090a0b0c0d0e0f",
+		"\x00\x00\x00\x00\x00\x00\x00\x00\x01\x01\x01\x01\x01\x01\x01\x01",
+	}
+	for _, k := range weak {
+		if err := CheckKey([]byte(k)); !errors.Is(err, ErrWeakKey) {
+			t.Errorf("CheckKey(%q) returned %v; expected %s", k, err, ErrWeakKey)
+		}
+	}
+
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckKey(random); err != nil {
+		t.Errorf("CheckKey(random) returned %s", err)
+	}
+}
+
+func TestWithKeyCheck(t *testing.T) {
+	if _, err := NewTokener([]byte("1234567887654321"), ttl, WithKeyCheck()); !errors.Is(err, ErrWeakKey) {
+		t.Errorf("NewTokener(WithKeyCheck()) returned %v; expected %s", err, ErrWeakKey)
+	}
+	if _, err := NewTokener([]byte("1234567887654321"), ttl); err != nil {
+		t.Errorf("NewTokener() returned %s", err)
+	}
+
+	kr := NewKeyring()
+	if err := kr.AddKey(0, []byte(key)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewKeyringTokener(kr, ttl, WithKeyCheck()); err != errKeyCheckKeyring {
+		t.Errorf("NewKeyringTokener(WithKeyCheck()) returned %v; expected %s", err, errKeyCheckKeyring)
+	}
+}
@@ -0,0 +1,255 @@
+// Package keydist distributes keysets to the Keyrings of every region,
+// so that all of them rotate keys in lockstep.
+//
+// A Publisher seals each keyset with a distribution key and writes it to a
+// Transport, such as an object in a bucket or a pubsub topic. In every
+// region a Consumer reads it back, either by polling the Transport or by
+// being handed each pubsub message, and applies it to its Keyring: new keys
+// are added, the primary key is switched, and keys that were dropped are
+// removed. Keysets carry a version, and Consumers never apply a keyset that
+// is not newer than the last one, so a replayed or delayed keyset can not
+// roll a region back to old keys. The version only lives in memory, so
+// applications persist Version after each Apply and pass it back to
+// NewConsumer when they restart. Keysets also expire after MaxAge, which
+// bounds how old a keyset that is replayed to a new Consumer can be;
+// Publishers publish the current keyset again well within MaxAge.
+//
+// Keysets hold AES-GCM keys. The distribution key must only be known to
+// the Publisher and the Consumers.
+package keydist
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/nicksnyder/go-securetoken/securetoken"
+)
+
+// Purpose is the purpose of the tokens that keysets are sealed in.
+const Purpose = "securetoken.keydist"
+
+// MaxAge is how long after it was sealed a keyset can be applied.
+const MaxAge = 24 * time.Hour
+
+// ErrStale is returned by Consumer.Apply for a keyset whose version is not
+// newer than the version that was last applied.
+var ErrStale = errors.New("keydist: keyset is not newer than the applied keyset")
+
+// ErrKeyMismatch is returned by Consumer.Apply for a keyset with a key
+// whose id is already in the Keyring with different bytes.
+var ErrKeyMismatch = errors.New("keydist: keyset key differs from the keyring key with the same id")
+
+// A Keyset is the set of keys that every region should have.
+type Keyset struct {
+	// Version must increase with every keyset that is published.
+	Version uint64 `json:"version"`
+
+	// Primary is the id of the key that new tokens are sealed with.
+	Primary uint32 `json:"primary"`
+
+	// Keys maps key ids to AES-GCM keys of 16, 24 or 32 bytes.
+	Keys map[uint32][]byte `json:"keys"`
+}
+
+func (ks *Keyset) validate() error {
+	if _, ok := ks.Keys[ks.Primary]; !ok {
+		return fmt.Errorf("keydist: primary key %d is not in the keyset", ks.Primary)
+	}
+	for id, key := range ks.Keys {
+		switch len(key) {
+		case 16, 24, 32:
+		default:
+			return fmt.Errorf("keydist: key %d has invalid length %d", id, len(key))
+		}
+	}
+	return nil
+}
+
+// A Transport stores the latest sealed keyset, such as an object in a bucket.
+// Implementations must be goroutine safe.
+type Transport interface {
+	Put(ctx context.Context, sealed []byte) error
+	Get(ctx context.Context) ([]byte, error)
+}
+
+// A MemoryTransport is a Transport that keeps the keyset in memory.
+// It is goroutine safe.
+type MemoryTransport struct {
+	mu     sync.Mutex
+	sealed []byte
+}
+
+// Put implements Transport.
+func (m *MemoryTransport) Put(ctx context.Context, sealed []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sealed = append([]byte(nil), sealed...)
+	return nil
+}
+
+// Get implements Transport.
+func (m *MemoryTransport) Get(ctx context.Context) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.sealed, nil
+}
+
+// newTokener returns the Tokener that keysets are sealed with.
+func newTokener(distKey []byte) (*securetoken.Tokener, error) {
+	return securetoken.NewTokener(distKey, MaxAge, securetoken.WithPurpose(Purpose))
+}
+
+// A Publisher publishes keysets.
+type Publisher struct {
+	tokener   *securetoken.Tokener
+	transport Transport
+}
+
+// NewPublisher returns a Publisher that seals keysets with distKey
+// and writes them to t.
+func NewPublisher(distKey []byte, t Transport) (*Publisher, error) {
+	tok, err := newTokener(distKey)
+	if err != nil {
+		return nil, err
+	}
+	return &Publisher{tokener: tok, transport: t}, nil
+}
+
+// Seal returns ks sealed with the distribution key, e.g. to send it
+// as a pubsub message.
+func (p *Publisher) Seal(ks *Keyset) ([]byte, error) {
+	if err := ks.validate(); err != nil {
+		return nil, err
+	}
+	buf, err := json.Marshal(ks)
+	if err != nil {
+		return nil, err
+	}
+	return p.tokener.Seal(buf)
+}
+
+// Publish seals ks and writes it to the Transport.
+// Publishing the current keyset again keeps it from expiring.
+func (p *Publisher) Publish(ctx context.Context, ks *Keyset) error {
+	sealed, err := p.Seal(ks)
+	if err != nil {
+		return err
+	}
+	return p.transport.Put(ctx, sealed)
+}
+
+// A Consumer applies published keysets to a Keyring.
+// It is goroutine safe.
+type Consumer struct {
+	tokener   *securetoken.Tokener
+	keyring   *securetoken.Keyring
+	transport Transport
+
+	mu      sync.Mutex
+	version uint64
+}
+
+// NewConsumer returns a Consumer that applies the keysets sealed with
+// distKey to kr. t may be nil if keysets are only passed to Apply.
+// version is the Version of the Consumer before the process restarted,
+// or 0 the first time; keysets that are not newer are never applied.
+func NewConsumer(distKey []byte, kr *securetoken.Keyring, t Transport, version uint64) (*Consumer, error) {
+	tok, err := newTokener(distKey)
+	if err != nil {
+		return nil, err
+	}
+	return &Consumer{tokener: tok, keyring: kr, transport: t, version: version}, nil
+}
+
+// Version returns the version of the last keyset applied, or the version
+// given to NewConsumer if none was.
+func (c *Consumer) Version() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.version
+}
+
+// Poll reads the keyset from the Transport and applies it if it is newer
+// than the last keyset applied. It is meant to be called periodically.
+func (c *Consumer) Poll(ctx context.Context) error {
+	sealed, err := c.transport.Get(ctx)
+	if err != nil {
+		return err
+	}
+	if len(sealed) == 0 {
+		return nil
+	}
+	if err := c.Apply(sealed); err != nil && err != ErrStale {
+		return err
+	}
+	return nil
+}
+
+// Apply unseals a keyset sealed by a Publisher and applies it to the
+// Keyring: keys that the Keyring does not have are added, the primary key
+// is set, and keys that are not in the keyset are removed.
+// It returns ErrStale if the keyset is not newer than the last one applied,
+// securetoken.ErrTokenExpired if it is older than MaxAge, and ErrKeyMismatch
+// if the Keyring has a key with the id of a keyset key but other bytes.
+func (c *Consumer) Apply(sealed []byte) error {
+	buf, err := c.tokener.Unseal(sealed)
+	if err != nil {
+		return err
+	}
+	var ks Keyset
+	if err := json.Unmarshal(buf, &ks); err != nil {
+		return fmt.Errorf("keydist: invalid keyset: %w", err)
+	}
+	if err := ks.validate(); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if ks.Version <= c.version {
+		return ErrStale
+	}
+	have := make(map[uint32]bool)
+	for _, id := range c.keyring.IDs() {
+		have[id] = true
+		key, ok := ks.Keys[id]
+		if !ok {
+			continue
+		}
+		got, err := c.keyring.KeyCheckValue(id)
+		if err != nil {
+			return err
+		}
+		want, err := securetoken.KeyCheckValue(key)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(got, want) {
+			return ErrKeyMismatch
+		}
+	}
+	for id, key := range ks.Keys {
+		if !have[id] {
+			if err := c.keyring.AddKey(id, key); err != nil {
+				return err
+			}
+		}
+	}
+	if err := c.keyring.SetPrimary(ks.Primary); err != nil {
+		return err
+	}
+	for id := range have {
+		if _, ok := ks.Keys[id]; !ok {
+			if err := c.keyring.Remove(id); err != nil {
+				return err
+			}
+		}
+	}
+	c.version = ks.Version
+	return nil
+}
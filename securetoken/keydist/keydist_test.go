@@ -0,0 +1,151 @@
+package keydist
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nicksnyder/go-securetoken/securetoken"
+)
+
+var (
+	distKey = bytes.Repeat([]byte{'d'}, 32)
+	key1    = bytes.Repeat([]byte{1}, 32)
+	key2    = bytes.Repeat([]byte{2}, 32)
+)
+
+func TestKeydist(t *testing.T) {
+	ctx := context.Background()
+	transport := &MemoryTransport{}
+	pub, err := NewPublisher(distKey, transport)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Two regions, each with its own keyring.
+	var keyrings []*securetoken.Keyring
+	var consumers []*Consumer
+	for i := 0; i < 2; i++ {
+		kr := securetoken.NewKeyring()
+		c, err := NewConsumer(distKey, kr, transport, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := c.Poll(ctx); err != nil {
+			t.Fatalf("Poll() before anything was published returned %v", err)
+		}
+		keyrings = append(keyrings, kr)
+		consumers = append(consumers, c)
+	}
+
+	v1 := &Keyset{Version: 1, Primary: 1, Keys: map[uint32][]byte{1: key1}}
+	v2 := &Keyset{Version: 2, Primary: 2, Keys: map[uint32][]byte{1: key1, 2: key2}}
+	v3 := &Keyset{Version: 3, Primary: 2, Keys: map[uint32][]byte{2: key2}}
+	for _, ks := range []*Keyset{v1, v2} {
+		if err := pub.Publish(ctx, ks); err != nil {
+			t.Fatal(err)
+		}
+		for _, c := range consumers {
+			if err := c.Poll(ctx); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// A token sealed in one region unseals in the other.
+	east, err := securetoken.NewKeyringTokener(keyrings[0], time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	west, err := securetoken.NewKeyringTokener(keyrings[1], time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := east.Seal([]byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p, err := west.Unseal(sealed); string(p) != "hello" || err != nil {
+		t.Errorf("Unseal(%q) in another region = %q, %v; expected %q, <nil>", sealed, p, err, "hello")
+	}
+
+	old, err := pub.Seal(v1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := consumers[0].Apply(old); err != ErrStale {
+		t.Errorf("Apply() of version 1 after version 2 returned %v; expected %s", err, ErrStale)
+	}
+
+	// Keysets can also be delivered as pubsub messages.
+	msg, err := pub.Seal(v3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := consumers[1].Apply(msg); err != nil {
+		t.Fatal(err)
+	}
+	if ids := keyrings[1].IDs(); len(ids) != 1 || ids[0] != 2 || consumers[1].Version() != 3 {
+		t.Errorf("after applying version 3, IDs() = %v and Version() = %d; expected [2] and 3", ids, consumers[1].Version())
+	}
+
+	other, err := NewConsumer(bytes.Repeat([]byte{'x'}, 32), securetoken.NewKeyring(), nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := other.Apply(msg); err != securetoken.ErrTokenInvalid {
+		t.Errorf("Apply() with another distribution key returned %v; expected %s", err, securetoken.ErrTokenInvalid)
+	}
+	if err := pub.Publish(ctx, &Keyset{Version: 4, Primary: 9, Keys: map[uint32][]byte{1: key1}}); err == nil {
+		t.Error("Publish() of a keyset without its primary key returned <nil>")
+	}
+}
+
+func TestReplay(t *testing.T) {
+	pub, err := NewPublisher(distKey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v1, err := pub.Seal(&Keyset{Version: 1, Primary: 1, Keys: map[uint32][]byte{1: key1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A Consumer that restarted after applying version 2 keeps rejecting version 1.
+	c, err := NewConsumer(distKey, securetoken.NewKeyring(), nil, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Apply(v1); err != ErrStale {
+		t.Errorf("Apply() of version 1 after restarting at version 2 returned %v; expected %s", err, ErrStale)
+	}
+
+	// Keysets older than MaxAge are rejected.
+	pub.tokener, err = securetoken.NewTokener(distKey, MaxAge, securetoken.WithPurpose(Purpose), securetoken.WithClock(func() time.Time {
+		return time.Now().Add(-MaxAge - time.Minute)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	old, err := pub.Seal(&Keyset{Version: 3, Primary: 1, Keys: map[uint32][]byte{1: key1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Apply(old); err != securetoken.ErrTokenExpired {
+		t.Errorf("Apply() of a keyset older than MaxAge returned %v; expected %s", err, securetoken.ErrTokenExpired)
+	}
+
+	// A key id that the Keyring already has with other bytes is rejected.
+	kr := securetoken.NewKeyring()
+	if err := kr.AddKey(1, key2); err != nil {
+		t.Fatal(err)
+	}
+	c, err = NewConsumer(distKey, kr, nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Apply(v1); err != ErrKeyMismatch || c.Version() != 0 {
+		t.Errorf("Apply() of a keyset with another key 1 returned %v and Version() = %d; expected %s and 0", err, c.Version(), ErrKeyMismatch)
+	}
+}
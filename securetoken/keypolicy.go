@@ -0,0 +1,84 @@
+package securetoken
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrPurposeNotAllowed is returned by Seal when the policy of the primary key
+// does not allow sealing tokens for the purpose of the Tokener.
+var ErrPurposeNotAllowed = errors.New("securetoken: purpose not allowed by key policy")
+
+// A PolicyLimit identifies a limit of a KeyPolicy.
+type PolicyLimit int
+
+// Limits of a KeyPolicy.
+const (
+	LimitSeals PolicyLimit = iota
+	LimitAge
+)
+
+func (l PolicyLimit) String() string {
+	switch l {
+	case LimitSeals:
+		return "seals"
+	case LimitAge:
+		return "age"
+	}
+	return fmt.Sprintf("PolicyLimit(%d)", int(l))
+}
+
+// A KeyPolicy restricts how a key may be used to seal tokens.
+// It does not affect unsealing.
+type KeyPolicy struct {
+	// MaxSeals is the number of tokens that the key may seal in this process.
+	// 0 means unlimited.
+	MaxSeals uint64
+
+	// NotAfter is the time after which the key may no longer seal tokens.
+	// The zero time means never.
+	NotAfter time.Time
+
+	// Purposes lists the purposes that the key may seal tokens for
+	// (see WithPurpose). An empty list allows every purpose.
+	Purposes []string
+
+	// WarnSeals and WarnAfter are the seal count and time
+	// at which the key is considered close to its limits.
+	// Zero values disable the warnings.
+	WarnSeals uint64
+	WarnAfter time.Time
+
+	// Warn is called once per limit when the key is close to it.
+	Warn func(id uint32, limit PolicyLimit)
+}
+
+// SetPolicy sets the policy of the key with the given id.
+// Seal returns ErrKeyExhausted once the key has reached its limits,
+// and ErrPurposeNotAllowed for purposes that the policy does not list.
+func (k *Keyring) SetPolicy(id uint32, p KeyPolicy) error {
+	e, ok := k.load().keys[id]
+	if !ok {
+		return fmt.Errorf("securetoken: key %d does not exist", id)
+	}
+	p.Purposes = append([]string(nil), p.Purposes...)
+	e.policy.Store(&p)
+	return nil
+}
+
+// check returns an error if the policy forbids sealing for purpose at now.
+func (p *KeyPolicy) check(now time.Time, purpose string) error {
+	if !p.NotAfter.IsZero() && now.After(p.NotAfter) {
+		return ErrKeyExhausted
+	}
+	if len(p.Purposes) == 0 {
+		return nil
+	}
+	for _, allowed := range p.Purposes {
+		if allowed == purpose {
+			return nil
+		}
+	}
+	return ErrPurposeNotAllowed
+}
@@ -0,0 +1,100 @@
+package securetoken
+
+import (
+	"testing"
+	"time"
+)
+
+func TestKeyPolicy(t *testing.T) {
+	setNow(time.Unix(100, 0))
+	defer restoreNow()
+
+	kr := NewKeyring()
+	if err := kr.AddKey(1, key); err != nil {
+		t.Fatal(err)
+	}
+	var warnings []PolicyLimit
+	err := kr.SetPolicy(1, KeyPolicy{
+		MaxSeals:  3,
+		WarnSeals: 2,
+		NotAfter:  time.Unix(200, 0),
+		WarnAfter: time.Unix(150, 0),
+		Purposes:  []string{"session"},
+		Warn: func(id uint32, limit PolicyLimit) {
+			warnings = append(warnings, limit)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	csrf, err := NewKeyringTokener(kr, ttl, WithPurpose("csrf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := csrf.Seal([]byte("data")); err != ErrPurposeNotAllowed {
+		t.Errorf("Seal() returned %v; expected %s", err, ErrPurposeNotAllowed)
+	}
+
+	session, err := NewKeyringTokener(kr, ttl, WithPurpose("session"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := session.Seal([]byte("data")); err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("warnings = %v; expected none", warnings)
+	}
+	setNow(time.Unix(160, 0))
+	if _, err := session.Seal([]byte("data")); err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 2 || warnings[0] != LimitSeals || warnings[1] != LimitAge {
+		t.Errorf("warnings = %v; expected [seals age]", warnings)
+	}
+	if _, err := session.Seal([]byte("data")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := session.Seal([]byte("data")); err != ErrKeyExhausted {
+		t.Errorf("Seal() returned %v; expected %s", err, ErrKeyExhausted)
+	}
+	if len(warnings) != 2 {
+		t.Errorf("warnings = %v; expected each limit once", warnings)
+	}
+
+	if err := kr.SetPolicy(1, KeyPolicy{NotAfter: time.Unix(200, 0)}); err != nil {
+		t.Fatal(err)
+	}
+	setNow(time.Unix(201, 0))
+	if _, err := session.Seal([]byte("data")); err != ErrKeyExhausted {
+		t.Errorf("Seal() returned %v; expected %s", err, ErrKeyExhausted)
+	}
+	if err := kr.SetPolicy(2, KeyPolicy{}); err == nil {
+		t.Errorf("SetPolicy(missing id) returned nil error")
+	}
+}
+
+func TestWithPurpose(t *testing.T) {
+	setNow(time.Unix(1, 0))
+	defer restoreNow()
+
+	session, err := NewTokener(key, ttl, WithPurpose("session"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	csrf, err := NewTokener(key, ttl, WithPurpose("csrf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := session.Seal([]byte("data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, err := session.Unseal(sealed); err != nil || string(data) != "data" {
+		t.Errorf("Unseal(%q) = %q, %v; expected \"data\", <nil>", sealed, data, err)
+	}
+	if data, err := csrf.Unseal(sealed); data != nil || err != ErrTokenInvalid {
+		t.Errorf("Unseal(%q) = %q, %v; expected <nil>, %s", sealed, data, err, ErrTokenInvalid)
+	}
+}
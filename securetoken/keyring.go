@@ -0,0 +1,283 @@
+package securetoken
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	errNoPrimaryKey = errors.New("securetoken: keyring has no primary key")
+	errRekeying     = errors.New("securetoken: keyring derives its own keys")
+)
+
+// ErrKeyExhausted is returned by Seal when the primary key has sealed
+// as many tokens as the seal limit allows and must be rotated.
+var ErrKeyExhausted = errors.New("securetoken: key exhausted")
+
+// NISTSealLimit is the number of invocations that NIST SP 800-38D allows
+// for a GCM key with random nonces.
+const NISTSealLimit = 1 << 32
+
+// A Keyring holds the keys that a Tokener seals and unseals with.
+// Each key is identified by an id that is stored in the token header,
+// and each key may use a different AEAD, so both key rotations and
+// algorithm migrations can happen without invalidating existing tokens.
+// The primary key is used to seal; every key is used to unseal.
+// It is goroutine safe.
+//
+// Reads never block: changes copy the keys into a new snapshot that is
+// swapped in atomically, so rotating keys does not stall Seal and Unseal.
+type Keyring struct {
+	mu    sync.Mutex // serializes changes
+	state atomic.Pointer[keyringState]
+
+	rekey *rekeyer
+}
+
+// keyringState is an immutable snapshot of a Keyring.
+type keyringState struct {
+	keys    map[uint32]*keyEntry
+	primary uint32
+	hasPrim bool
+
+	sealLimit uint64
+	warnAt    uint64
+	warn      func(id uint32, count uint64)
+}
+
+var emptyKeyringState keyringState
+
+type keyEntry struct {
+	aead cipher.AEAD
+
+	// newAEAD makes an AEAD of the same kind from a keySize byte key,
+	// so that SelfTest can test it with a throwaway key. It is nil for
+	// AEADs given to Add.
+	newAEAD func(key []byte) (cipher.AEAD, error)
+	keySize int
+
+	region Region // set by AddRegionalKey
+
+	seals  uint64 // accessed atomically
+	policy atomic.Pointer[KeyPolicy]
+	warned [2]uint32 // accessed atomically, indexed by PolicyLimit
+
+	attestation atomic.Pointer[SignedKeyAttestation]
+}
+
+// NewKeyring returns an empty Keyring.
+func NewKeyring() *Keyring {
+	k := &Keyring{}
+	k.state.Store(&keyringState{keys: make(map[uint32]*keyEntry)})
+	return k
+}
+
+// load returns the current snapshot of k.
+func (k *Keyring) load() *keyringState {
+	if s := k.state.Load(); s != nil {
+		return s
+	}
+	return &emptyKeyringState
+}
+
+// update calls f with a copy of the current snapshot and, unless f returns
+// an error, makes the copy current. Entries are shared between snapshots.
+func (k *Keyring) update(f func(s *keyringState) error) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	old := k.load()
+	s := *old
+	s.keys = make(map[uint32]*keyEntry, len(old.keys)+1)
+	for id, e := range old.keys {
+		s.keys[id] = e
+	}
+	if err := f(&s); err != nil {
+		return err
+	}
+	k.state.Store(&s)
+	return nil
+}
+
+// SetSealLimit limits the number of tokens that each key may seal.
+// When a key seals its warnAt'th token, warn is called with the key id
+// and the count. Once a key has sealed limit tokens, Seal returns
+// ErrKeyExhausted until another key is made primary.
+// A limit or warnAt of 0 disables it. Counts are kept in memory,
+// so they only cover the seals of the current process.
+// NISTSealLimit is a reasonable limit for AES-GCM keys.
+func (k *Keyring) SetSealLimit(limit, warnAt uint64, warn func(id uint32, count uint64)) {
+	k.update(func(s *keyringState) error {
+		s.sealLimit, s.warnAt, s.warn = limit, warnAt, warn
+		return nil
+	})
+}
+
+// SealCount returns the number of tokens that the key with the given id
+// has sealed in this process.
+func (k *Keyring) SealCount(id uint32) uint64 {
+	if e, ok := k.load().keys[id]; ok {
+		return atomic.LoadUint64(&e.seals)
+	}
+	return 0
+}
+
+// AddKey adds an AES-GCM key with the given id.
+// key must be either 16, 24, or 32 bytes.
+func (k *Keyring) AddKey(id uint32, key []byte) error {
+	aead, err := newAESGCM(key)
+	if err != nil {
+		return err
+	}
+	return k.add(id, &keyEntry{aead: aead, newAEAD: newAESGCM, keySize: len(key)})
+}
+
+func newAESGCM(key []byte) (cipher.AEAD, error) {
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(c)
+}
+
+// Add adds aead with the given id.
+// aead may be any AEAD (e.g. XChaCha20-Poly1305) whose nonce is at least MinNonceLength bytes.
+// The first key added becomes the primary key.
+func (k *Keyring) Add(id uint32, aead cipher.AEAD) error {
+	return k.add(id, &keyEntry{aead: aead})
+}
+
+// add adds e with the given id.
+func (k *Keyring) add(id uint32, e *keyEntry) error {
+	if k.rekey != nil {
+		return errRekeying
+	}
+	if e.aead.NonceSize() < MinNonceLength {
+		return fmt.Errorf("securetoken: nonce size %d is smaller than %d", e.aead.NonceSize(), MinNonceLength)
+	}
+	return k.update(func(s *keyringState) error {
+		if _, ok := s.keys[id]; ok {
+			return fmt.Errorf("securetoken: key %d already exists", id)
+		}
+		s.keys[id] = e
+		if !s.hasPrim {
+			s.primary, s.hasPrim = id, true
+		}
+		return nil
+	})
+}
+
+// SetPrimary makes the key with the given id the key used to seal new tokens.
+func (k *Keyring) SetPrimary(id uint32) error {
+	if k.rekey != nil {
+		return errRekeying
+	}
+	return k.update(func(s *keyringState) error {
+		if _, ok := s.keys[id]; !ok {
+			return fmt.Errorf("securetoken: key %d does not exist", id)
+		}
+		s.primary, s.hasPrim = id, true
+		return nil
+	})
+}
+
+// Remove removes the key with the given id.
+// Tokens sealed with a removed key can no longer be unsealed.
+// The primary key can not be removed. A locked key (see AddLockedKey)
+// is wiped once the seals and unseals that are using it have finished.
+func (k *Keyring) Remove(id uint32) error {
+	var removed *keyEntry
+	err := k.update(func(s *keyringState) error {
+		if s.hasPrim && s.primary == id {
+			return fmt.Errorf("securetoken: key %d is the primary key", id)
+		}
+		removed = s.keys[id]
+		delete(s.keys, id)
+		return nil
+	})
+	if removed != nil {
+		if r, ok := removed.aead.(interface{ retire() }); ok {
+			r.retire()
+		}
+	}
+	return err
+}
+
+// IDs returns the sorted ids of the keys in k.
+func (k *Keyring) IDs() []uint32 {
+	ids, _, _ := k.ids()
+	return ids
+}
+
+// primaryKey returns the id and AEAD of the primary key.
+func (k *Keyring) primaryKey() (uint32, cipher.AEAD, error) {
+	s := k.load()
+	if !s.hasPrim {
+		if k.rekey != nil {
+			return 0, k.rekey.template, nil
+		}
+		return 0, nil, errNoPrimaryKey
+	}
+	return s.primary, s.keys[s.primary].aead, nil
+}
+
+// sealKey returns the id and AEAD of the primary key for sealing
+// a token for purpose at now, and counts the seal against its limits.
+func (k *Keyring) sealKey(now time.Time, purpose string) (uint32, cipher.AEAD, error) {
+	if k.rekey != nil {
+		k.rotate(now)
+	}
+	s := k.load()
+	if !s.hasPrim {
+		return 0, nil, errNoPrimaryKey
+	}
+	id, e := s.primary, s.keys[s.primary]
+	p := e.policy.Load()
+	if p != nil {
+		if err := p.check(now, purpose); err != nil {
+			return 0, nil, err
+		}
+	}
+	n := atomic.AddUint64(&e.seals, 1)
+	if s.sealLimit > 0 && n > s.sealLimit {
+		return 0, nil, ErrKeyExhausted
+	}
+	if s.warnAt > 0 && n == s.warnAt && s.warn != nil {
+		s.warn(id, n)
+	}
+	if p != nil {
+		if p.MaxSeals > 0 && n > p.MaxSeals {
+			return 0, nil, ErrKeyExhausted
+		}
+		if p.WarnSeals > 0 && n >= p.WarnSeals {
+			e.warnOnce(id, p, LimitSeals)
+		}
+		if !p.WarnAfter.IsZero() && now.After(p.WarnAfter) {
+			e.warnOnce(id, p, LimitAge)
+		}
+	}
+	return id, e.aead, nil
+}
+
+// warnOnce calls the Warn func of p
+// the first time that the given limit is approached.
+func (e *keyEntry) warnOnce(id uint32, p *KeyPolicy, limit PolicyLimit) {
+	if p.Warn != nil && atomic.CompareAndSwapUint32(&e.warned[limit], 0, 1) {
+		p.Warn(id, limit)
+	}
+}
+
+// lookup returns the AEAD with the given id at now or nil if it does not exist.
+func (k *Keyring) lookup(id uint32, now time.Time) cipher.AEAD {
+	if k.rekey != nil {
+		return k.lookupEpoch(id, now)
+	}
+	if e, ok := k.load().keys[id]; ok {
+		return e.aead
+	}
+	return nil
+}
@@ -0,0 +1,229 @@
+package securetoken
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"testing"
+	"time"
+)
+
+var key2 = []byte("qwerpoiuqwerpoiuqwerpoiuqwerpoiu")
+
+// newGCM16 returns an AES-GCM AEAD with a 16 byte nonce,
+// which stands in for an AEAD with a different construction.
+func newGCM16(t *testing.T, key []byte) cipher.AEAD {
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	aead, err := cipher.NewGCMWithNonceSize(c, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return aead
+}
+
+func TestKeyringRotation(t *testing.T) {
+	setNow(time.Unix(1, 0))
+	defer restoreNow()
+
+	old, err := NewTokener(key, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v1, err := old.Seal([]byte("v1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kr := NewKeyring()
+	if err := kr.AddKey(0, key); err != nil {
+		t.Fatal(err)
+	}
+	if err := kr.Add(7, newGCM16(t, key2)); err != nil {
+		t.Fatal(err)
+	}
+	tok, err := NewKeyringTokener(kr, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v2old, err := tok.Seal([]byte("v2old"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := kr.SetPrimary(7); err != nil {
+		t.Fatal(err)
+	}
+	v2new, err := tok.Seal([]byte("v2new"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := tok.sealedLength([]byte("v2new"), true); len(v2new) != expected {
+		t.Errorf("Seal() returned token with length %d; expected %d", len(v2new), expected)
+	}
+
+	for sealed, data := range map[string]string{string(v1): "v1", string(v2old): "v2old", string(v2new): "v2new"} {
+		unsealed, err := tok.Unseal([]byte(sealed))
+		if err != nil || string(unsealed) != data {
+			t.Errorf("Unseal(%q) = %q, %v; expected %q, <nil>", sealed, unsealed, err, data)
+		}
+	}
+
+	if err := kr.Remove(7); err == nil {
+		t.Errorf("Remove(7) of primary key returned nil error")
+	}
+	if err := kr.Remove(0); err != nil {
+		t.Fatal(err)
+	}
+	for _, sealed := range [][]byte{v1, v2old} {
+		if data, err := tok.Unseal(sealed); data != nil || err != ErrTokenInvalid {
+			t.Errorf("Unseal(%q) = %q, %v; expected <nil>, %s", sealed, data, err, ErrTokenInvalid)
+		}
+	}
+}
+
+func TestKeyringMinVersion(t *testing.T) {
+	setNow(time.Unix(1, 0))
+	defer restoreNow()
+
+	old, err := NewTokener(key, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v1, err := old.Seal([]byte("v1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	kr := NewKeyring()
+	if err := kr.AddKey(0, key); err != nil {
+		t.Fatal(err)
+	}
+	tok, err := NewKeyringTokener(kr, ttl, WithMinVersion(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, err := tok.Unseal(v1); data != nil || err != ErrVersionRejected {
+		t.Errorf("Unseal(%q) = %q, %v; expected <nil>, %s", v1, data, err, ErrVersionRejected)
+	}
+}
+
+// TestKeyringHeaderAuthenticated tests that changing the key id
+// of a version 2 token makes it invalid.
+func TestKeyringHeaderAuthenticated(t *testing.T) {
+	kr := NewKeyring()
+	if err := kr.AddKey(1, key); err != nil {
+		t.Fatal(err)
+	}
+	if err := kr.AddKey(2, key); err != nil {
+		t.Fatal(err)
+	}
+	tok, err := NewKeyringTokener(kr, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := tok.Seal([]byte("data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := tok.decode(sealed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded[4] = 2
+	tampered := tok.encode(decoded)
+	if data, err := tok.Unseal(tampered); data != nil || err == nil {
+		t.Errorf("Unseal(%q) = %q, %v; expected <nil>, error", tampered, data, err)
+	}
+}
+
+func TestKeyringErrors(t *testing.T) {
+	kr := NewKeyring()
+	if _, err := NewKeyringTokener(kr, ttl); err != errNoPrimaryKey {
+		t.Errorf("NewKeyringTokener(empty) returned %v; expected %s", err, errNoPrimaryKey)
+	}
+	if err := kr.AddKey(1, []byte("short")); err == nil {
+		t.Errorf("AddKey(short key) returned nil error")
+	}
+	if err := kr.AddKey(1, key); err != nil {
+		t.Fatal(err)
+	}
+	if err := kr.AddKey(1, key); err == nil {
+		t.Errorf("AddKey(duplicate id) returned nil error")
+	}
+	if err := kr.SetPrimary(2); err == nil {
+		t.Errorf("SetPrimary(missing id) returned nil error")
+	}
+}
+
+func TestKeyringSealLimit(t *testing.T) {
+	kr := NewKeyring()
+	if err := kr.AddKey(1, key); err != nil {
+		t.Fatal(err)
+	}
+	if err := kr.AddKey(2, key2); err != nil {
+		t.Fatal(err)
+	}
+	var warned []uint64
+	kr.SetSealLimit(3, 2, func(id uint32, count uint64) {
+		if id != 1 {
+			t.Errorf("warn(%d, %d); expected key 1", id, count)
+		}
+		warned = append(warned, count)
+	})
+	tok, err := NewKeyringTokener(kr, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := tok.Seal([]byte("data")); err != nil {
+			t.Fatalf("Seal() %d returned error: %s", i, err)
+		}
+	}
+	if len(warned) != 1 || warned[0] != 2 {
+		t.Errorf("warn called with %v; expected [2]", warned)
+	}
+	if sealed, err := tok.Seal([]byte("data")); sealed != nil || err != ErrKeyExhausted {
+		t.Errorf("Seal() = %q, %v; expected <nil>, %s", sealed, err, ErrKeyExhausted)
+	}
+	if err := kr.SetPrimary(2); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tok.Seal([]byte("data")); err != nil {
+		t.Errorf("Seal() after rotation returned error: %s", err)
+	}
+	if n := kr.SealCount(2); n != 1 {
+		t.Errorf("SealCount(2) = %d; expected 1", n)
+	}
+}
+
+func TestKeyringReadsDoNotBlock(t *testing.T) {
+	k := NewKeyring()
+	if err := k.AddKey(1, key); err != nil {
+		t.Fatal(err)
+	}
+	tok, err := NewKeyringTokener(k, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := tok.Seal([]byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Hold the lock that changes take, as a slow rotation would.
+	k.mu.Lock()
+	done := make(chan error, 1)
+	go func() {
+		_, err := tok.Unseal(sealed)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Unseal(%q) = %v; expected <nil>", sealed, err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("Unseal blocked while the keyring was being changed")
+	}
+	k.mu.Unlock()
+}
@@ -0,0 +1,60 @@
+package securetoken
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// A KeySource provides a key, such as from configuration or a secret manager.
+type KeySource interface {
+	Key() ([]byte, error)
+}
+
+// A KeyString is a KeySource for a key encoded as hex or base64 text,
+// as accepted by ParseKey.
+type KeyString string
+
+// Key implements KeySource.
+func (s KeyString) Key() ([]byte, error) {
+	return ParseKey(string(s))
+}
+
+// An EnvKey is a KeySource for a key stored, encoded as for ParseKey,
+// in the environment variable that it names.
+type EnvKey string
+
+// Key implements KeySource.
+func (name EnvKey) Key() ([]byte, error) {
+	s, ok := os.LookupEnv(string(name))
+	if !ok {
+		return nil, fmt.Errorf("securetoken: environment variable %s is not set", string(name))
+	}
+	key, err := ParseKey(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w (from environment variable %s)", err, string(name))
+	}
+	return key, nil
+}
+
+// ParseKey decodes a key from its hex or base64 (standard or URL, padded or not)
+// encoding, ignoring surrounding whitespace. Text that is valid hex is decoded
+// as hex. It returns an error unless the decoded key is 16, 24 or 32 bytes long,
+// so a key can never be silently truncated or used in its encoded form.
+func ParseKey(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if key, ok := decodeKey(s); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("securetoken: key is not the hex or base64 encoding of 16, 24 or 32 bytes (got %d characters)", len(s))
+}
+
+// NewTokenerFromSource is similar to NewTokener except the key is read from src.
+func NewTokenerFromSource(src KeySource, ttl time.Duration, opts ...Option) (*Tokener, error) {
+	key, err := src.Key()
+	if err != nil {
+		return nil, err
+	}
+	return NewTokener(key, ttl, opts...)
+}
@@ -0,0 +1,58 @@
+package securetoken
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+)
+
+func TestParseKey(t *testing.T) {
+	raw := []byte("0123456789abcdef0123456789abcdef")
+	tests := []string{
+		hex.EncodeToString(raw),
+		base64.StdEncoding.EncodeToString(raw),
+		base64.RawURLEncoding.EncodeToString(raw),
+		" " + base64.URLEncoding.EncodeToString(raw) + "\n",
+	}
+	for _, s := range tests {
+		if key, err := ParseKey(s); !bytes.Equal(key, raw) || err != nil {
+			t.Errorf("ParseKey(%q) = %q, %v; expected %q, <nil>", s, key, err, raw)
+		}
+	}
+	// 32 hex characters are 16 bytes, not a 32 byte raw key.
+	if key, err := ParseKey(hex.EncodeToString(raw[:16])); len(key) != 16 || err != nil {
+		t.Errorf("ParseKey() of 32 hex characters = %d bytes, %v; expected 16 bytes", len(key), err)
+	}
+	for _, s := range []string{"", "a password!", hex.EncodeToString(raw[:10])} {
+		if key, err := ParseKey(s); err == nil {
+			t.Errorf("ParseKey(%q) = %q, <nil>; expected an error", s, key)
+		}
+	}
+}
+
+func TestNewTokenerFromSource(t *testing.T) {
+	raw := []byte("0123456789abcdef")
+	t.Setenv("SECURETOKEN_TEST_KEY", hex.EncodeToString(raw))
+	tok, err := NewTokenerFromSource(EnvKey("SECURETOKEN_TEST_KEY"), ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := NewTokener(raw, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := want.Seal([]byte("x"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plaintext, err := tok.Unseal(sealed); string(plaintext) != "x" || err != nil {
+		t.Errorf("Unseal(%q) = %q, %v; expected \"x\", <nil>", sealed, plaintext, err)
+	}
+	if _, err := NewTokenerFromSource(EnvKey("SECURETOKEN_TEST_UNSET"), ttl); err == nil {
+		t.Error("NewTokenerFromSource() with an unset variable returned no error")
+	}
+	if _, err := NewTokenerFromSource(KeyString("not a key"), ttl); err == nil {
+		t.Error("NewTokenerFromSource() with an invalid key string returned no error")
+	}
+}
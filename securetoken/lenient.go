@@ -0,0 +1,55 @@
+package securetoken
+
+import "bytes"
+
+// WithLenientDecoding returns an Option that makes Unseal ignore whitespace
+// anywhere in a token, such as line breaks added when an email wraps it,
+// and decode URL escapes such as %3D, such as when a token is copied from a
+// link. Tokens are still authenticated, so this only accepts more spellings
+// of valid tokens. It must not be used with Base45, whose alphabet includes
+// the space and the percent sign.
+func WithLenientDecoding() Option {
+	return func(t *Tokener) error {
+		t.lenient = true
+		return nil
+	}
+}
+
+// clean returns sealed without whitespace and with URL escapes decoded.
+// It returns sealed itself if there is nothing to clean.
+func clean(sealed []byte) []byte {
+	if bytes.IndexAny(sealed, " \t\r\n%") < 0 {
+		return sealed
+	}
+	out := make([]byte, 0, len(sealed))
+	for i := 0; i < len(sealed); i++ {
+		switch c := sealed[i]; c {
+		case ' ', '\t', '\r', '\n':
+		case '%':
+			if i+2 < len(sealed) && isHex(sealed[i+1]) && isHex(sealed[i+2]) {
+				out = append(out, unhex(sealed[i+1])<<4|unhex(sealed[i+2]))
+				i += 2
+			} else {
+				out = append(out, c)
+			}
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+func unhex(c byte) byte {
+	switch {
+	case c <= '9':
+		return c - '0'
+	case c <= 'F':
+		return c - 'A' + 10
+	default:
+		return c - 'a' + 10
+	}
+}
@@ -0,0 +1,46 @@
+package securetoken
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWithLenientDecoding(t *testing.T) {
+	tok, err := NewTokener(key, ttl, WithLenientDecoding())
+	if err != nil {
+		t.Fatal(err)
+	}
+	strict, err := NewTokener(key, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := tok.SealString("hello!") // Long enough to be padded.
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(sealed, "=") {
+		t.Fatalf("SealString(%q) = %q; expected padding", "hello!", sealed)
+	}
+	// base64 already skips line breaks, so a wrapped token is only tested here.
+	wrapped := sealed[:20] + "\r\n" + sealed[20:]
+	if p, err := tok.UnsealString(wrapped); p != "hello!" || err != nil {
+		t.Errorf("UnsealString(%q) = %q, %v; expected %q, <nil>", wrapped, p, err, "hello!")
+	}
+	pasted := []string{
+		"  " + sealed + "\n",
+		sealed[:20] + " " + sealed[20:],
+		strings.ReplaceAll(sealed, "=", "%3D"),
+		strings.ReplaceAll(sealed, "=", "%3d") + "\t",
+	}
+	for _, s := range pasted {
+		if p, err := tok.UnsealString(s); p != "hello!" || err != nil {
+			t.Errorf("UnsealString(%q) = %q, %v; expected %q, <nil>", s, p, err, "hello!")
+		}
+		if p, err := strict.UnsealString(s); p != "" || err != ErrTokenInvalid {
+			t.Errorf("UnsealString(%q) without WithLenientDecoding = %q, %v; expected \"\", %s", s, p, err, ErrTokenInvalid)
+		}
+	}
+	if s := sealed + "%zz"; string(clean([]byte(s))) != s {
+		t.Errorf("clean(%q) = %q; expected invalid escapes to be kept", s, clean([]byte(s)))
+	}
+}
@@ -0,0 +1,107 @@
+package securetoken
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"errors"
+)
+
+var (
+	errLockedMemoryUnsupported = errors.New("securetoken: locked memory is not supported on this platform")
+	errKeyRemoved              = errors.New("securetoken: key has been removed")
+)
+
+// AddLockedKey is similar to AddKey except the key is kept in locked memory:
+// the pages that hold it are excluded from swap and core dumps,
+// surrounded by guard pages, and only readable while a token is being
+// sealed or opened. key is zeroed after it has been copied.
+//
+// Every seal and unseal expands the key again, which is noticeably slower
+// than AddKey, and the expanded key schedule briefly lives in ordinary memory.
+// It is only supported on Linux.
+func (k *Keyring) AddLockedKey(id uint32, key []byte) error {
+	if _, err := aes.NewCipher(key); err != nil {
+		return err
+	}
+	buf, err := newLockedBuffer(key)
+	if err != nil {
+		return err
+	}
+	for i := range key {
+		key[i] = 0
+	}
+	if err := k.add(id, &keyEntry{aead: &lockedAEAD{buf}, newAEAD: newAESGCM, keySize: len(key)}); err != nil {
+		buf.retire()
+		return err
+	}
+	return nil
+}
+
+// lockedAEAD is an AES-GCM AEAD whose key is kept in locked memory.
+type lockedAEAD struct {
+	key *lockedBuffer
+}
+
+func (a *lockedAEAD) NonceSize() int { return 12 }
+func (a *lockedAEAD) Overhead() int  { return 16 }
+
+// Seal panics if the key has been removed. Tokeners call sealChecked
+// instead, which returns the error.
+func (a *lockedAEAD) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	out, err := a.sealChecked(dst, nonce, plaintext, additionalData)
+	if err != nil {
+		panic(err)
+	}
+	return out
+}
+
+func (a *lockedAEAD) sealChecked(dst, nonce, plaintext, additionalData []byte) ([]byte, error) {
+	aead, err := a.aead()
+	if err != nil {
+		return nil, err
+	}
+	return aead.Seal(dst, nonce, plaintext, additionalData), nil
+}
+
+func (a *lockedAEAD) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	aead, err := a.aead()
+	if err != nil {
+		return nil, err
+	}
+	return aead.Open(dst, nonce, ciphertext, additionalData)
+}
+
+// aead expands the key into a new AES-GCM AEAD. It returns errKeyRemoved
+// if the key was removed from its Keyring, which can happen while a seal
+// or unseal that loaded the key before the removal is still running.
+func (a *lockedAEAD) aead() (cipher.AEAD, error) {
+	key, err := a.key.open()
+	if err != nil {
+		return nil, err
+	}
+	c, err := aes.NewCipher(key)
+	a.key.close()
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(c)
+}
+
+// retire wipes the key once no seal or unseal is using it.
+func (a *lockedAEAD) retire() {
+	a.key.retire()
+}
+
+// A checkedSealer is an AEAD whose Seal can fail, such as a lockedAEAD
+// whose key has been removed.
+type checkedSealer interface {
+	sealChecked(dst, nonce, plaintext, additionalData []byte) ([]byte, error)
+}
+
+// sealAEAD seals with aead, returning the error of a checkedSealer.
+func sealAEAD(aead cipher.AEAD, dst, nonce, plaintext, additionalData []byte) ([]byte, error) {
+	if s, ok := aead.(checkedSealer); ok {
+		return s.sealChecked(dst, nonce, plaintext, additionalData)
+	}
+	return aead.Seal(dst, nonce, plaintext, additionalData), nil
+}
@@ -0,0 +1,137 @@
+package securetoken
+
+import (
+	"bytes"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAddLockedKey(t *testing.T) {
+	setNow(time.Unix(1, 0))
+	defer restoreNow()
+
+	locked := append([]byte(nil), key...)
+	kr := NewKeyring()
+	err := kr.AddLockedKey(0, locked)
+	if runtime.GOOS != "linux" {
+		if err != errLockedMemoryUnsupported {
+			t.Fatalf("AddLockedKey() returned %v; expected %s", err, errLockedMemoryUnsupported)
+		}
+		return
+	}
+	if err != nil {
+		t.Skipf("AddLockedKey() returned %s; locked memory may be limited", err)
+	}
+	if !bytes.Equal(locked, make([]byte, len(key))) {
+		t.Errorf("AddLockedKey() did not zero the key")
+	}
+
+	// Tokens are compatible with ordinary keys.
+	plain, err := NewTokener(key, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := plain.Seal([]byte("data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tok, err := NewKeyringTokener(kr, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, err := tok.Unseal(sealed); err != nil || string(data) != "data" {
+		t.Errorf("Unseal(%q) = %q, %v; expected \"data\", <nil>", sealed, data, err)
+	}
+
+	if err := kr.AddKey(1, key2); err != nil {
+		t.Fatal(err)
+	}
+	if err := kr.SetPrimary(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := kr.Remove(0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tok.Unseal(sealed); err != ErrTokenInvalid {
+		t.Errorf("Unseal(%q) returned %v; expected %s", sealed, err, ErrTokenInvalid)
+	}
+}
+
+func TestAddLockedKeyInvalid(t *testing.T) {
+	if err := NewKeyring().AddLockedKey(0, []byte("short")); err == nil {
+		t.Errorf("AddLockedKey(short key) returned nil error")
+	}
+}
+
+// TestRemoveLockedKeyConcurrently tests that removing a locked key while
+// tokens are being unsealed with it fails those unseals instead of panicking.
+func TestRemoveLockedKeyConcurrently(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("locked memory is only supported on Linux")
+	}
+	for i := 0; i < 20; i++ {
+		kr := NewKeyring()
+		if err := kr.AddKey(1, key2); err != nil {
+			t.Fatal(err)
+		}
+		if err := kr.AddLockedKey(0, append([]byte(nil), key...)); err != nil {
+			t.Skipf("AddLockedKey() returned %s; locked memory may be limited", err)
+		}
+		if err := kr.SetPrimary(0); err != nil {
+			t.Fatal(err)
+		}
+		tok, err := NewKeyringTokener(kr, time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sealed, err := tok.Seal([]byte("data"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := kr.SetPrimary(1); err != nil {
+			t.Fatal(err)
+		}
+
+		var wg sync.WaitGroup
+		for g := 0; g < 4; g++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 50; j++ {
+					if data, err := tok.Unseal(sealed); err != nil && err != ErrTokenInvalid || err == nil && string(data) != "data" {
+						t.Errorf("Unseal() = %q, %v; expected \"data\" or %s", data, err, ErrTokenInvalid)
+						return
+					}
+				}
+			}()
+		}
+		if err := kr.Remove(0); err != nil {
+			t.Fatal(err)
+		}
+		wg.Wait()
+	}
+}
+
+func TestRetiredLockedBuffer(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("locked memory is only supported on Linux")
+	}
+	b, err := newLockedBuffer(key)
+	if err != nil {
+		t.Skipf("newLockedBuffer() returned %s; locked memory may be limited", err)
+	}
+	data, err := b.open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.retire()
+	if !bytes.Equal(data, key) {
+		t.Errorf("retire() wiped a buffer that is open")
+	}
+	b.close()
+	if _, err := b.open(); err != errKeyRemoved {
+		t.Errorf("open() after retire() returned %v; expected %s", err, errKeyRemoved)
+	}
+}
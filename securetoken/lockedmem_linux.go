@@ -0,0 +1,108 @@
+package securetoken
+
+import (
+	"os"
+	"sync"
+	"syscall"
+)
+
+// madvDontDump is MADV_DONTDUMP, which excludes pages from core dumps.
+const madvDontDump = 0x10
+
+// A lockedBuffer holds bytes in mlock'd pages between two guard pages.
+// The pages are inaccessible unless the buffer is open.
+type lockedBuffer struct {
+	mu      sync.Mutex
+	mem     []byte // the whole mapping, including guard pages
+	inner   []byte // the locked pages
+	data    []byte
+	opens   int
+	retired bool // wiped once the last open is closed
+}
+
+func newLockedBuffer(src []byte) (*lockedBuffer, error) {
+	page := os.Getpagesize()
+	size := (len(src) + page - 1) / page * page
+	mem, err := syscall.Mmap(-1, 0, size+2*page, syscall.PROT_NONE, syscall.MAP_PRIVATE|syscall.MAP_ANON)
+	if err != nil {
+		return nil, err
+	}
+	b := &lockedBuffer{mem: mem, inner: mem[page : page+size]}
+	if err := syscall.Mprotect(b.inner, syscall.PROT_READ|syscall.PROT_WRITE); err != nil {
+		syscall.Munmap(mem)
+		return nil, err
+	}
+	if err := syscall.Mlock(b.inner); err != nil {
+		syscall.Munmap(mem)
+		return nil, err
+	}
+	syscall.Madvise(b.inner, madvDontDump)
+	b.data = b.inner[:copy(b.inner, src)]
+	if err := syscall.Mprotect(b.inner, syscall.PROT_NONE); err != nil {
+		b.wipe()
+		return nil, err
+	}
+	return b, nil
+}
+
+// open makes the buffer readable and returns its contents.
+// Unless it returns an error, it must be followed by a call to close.
+// It returns errKeyRemoved once the buffer has been retired.
+func (b *lockedBuffer) open() ([]byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.retired || b.mem == nil {
+		return nil, errKeyRemoved
+	}
+	if b.opens == 0 {
+		if err := syscall.Mprotect(b.inner, syscall.PROT_READ); err != nil {
+			return nil, err
+		}
+	}
+	b.opens++
+	return b.data, nil
+}
+
+// close makes the buffer inaccessible once every open has been closed,
+// and wipes it if it has been retired meanwhile.
+func (b *lockedBuffer) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.opens--
+	if b.opens > 0 {
+		return
+	}
+	if b.retired {
+		b.wipe()
+		return
+	}
+	// If this fails the key stays readable, which is no worse than an
+	// ordinary key, so it is not worth failing the seal or unseal.
+	syscall.Mprotect(b.inner, syscall.PROT_NONE)
+}
+
+// retire makes later opens fail and wipes the buffer
+// as soon as it is not open.
+func (b *lockedBuffer) retire() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.retired = true
+	if b.opens == 0 {
+		b.wipe()
+	}
+}
+
+// wipe zeroes and unmaps the buffer. b.mu must be held or b unshared.
+func (b *lockedBuffer) wipe() {
+	if b.mem == nil {
+		return
+	}
+	if syscall.Mprotect(b.inner, syscall.PROT_READ|syscall.PROT_WRITE) == nil {
+		for i := range b.inner {
+			b.inner[i] = 0
+		}
+	}
+	syscall.Munlock(b.inner)
+	syscall.Munmap(b.mem)
+	b.mem, b.inner, b.data = nil, nil, nil
+}
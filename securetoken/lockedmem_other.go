@@ -0,0 +1,13 @@
+//go:build !linux
+
+package securetoken
+
+type lockedBuffer struct{}
+
+func newLockedBuffer(src []byte) (*lockedBuffer, error) {
+	return nil, errLockedMemoryUnsupported
+}
+
+func (b *lockedBuffer) open() ([]byte, error) { return nil, errLockedMemoryUnsupported }
+func (b *lockedBuffer) close()                {}
+func (b *lockedBuffer) retire()               {}
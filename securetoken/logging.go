@@ -0,0 +1,126 @@
+package securetoken
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+)
+
+// errorKinds names the errors of this package in log events,
+// so that failures can be counted by kind.
+var errorKinds = []struct {
+	err  error
+	kind string
+}{
+	{ErrTokenInvalid, "invalid"},
+	{ErrTokenExpired, "expired"},
+	{ErrTokenTooLong, "too_long"},
+	{ErrVersionRejected, "version_rejected"},
+	{ErrRateLimited, "rate_limited"},
+	{ErrTokenRevoked, "revoked"},
+	{ErrWrongAudience, "wrong_audience"},
+	{ErrWrongContext, "wrong_context"},
+	{ErrCanary, "canary"},
+	{ErrPolicyDenied, "policy_denied"},
+	{ErrKeyExhausted, "key_exhausted"},
+	{ErrPurposeNotAllowed, "purpose_not_allowed"},
+	{ErrQuotaExceeded, "quota_exceeded"},
+	{ErrInvalidClaims, "invalid_claims"},
+	{ErrApprovalsRequired, "approvals_required"},
+	{ErrBreakGlassUnaudited, "break_glass_unaudited"},
+}
+
+// errorValue is a slog.LogValuer for an error that adds its kind.
+type errorValue struct {
+	err error
+}
+
+func (e errorValue) LogValue() slog.Value {
+	kind := "other"
+	for _, k := range errorKinds {
+		if errors.Is(e.err, k.err) {
+			kind = k.kind
+			break
+		}
+	}
+	return slog.GroupValue(slog.String("kind", kind), slog.String("msg", e.err.Error()))
+}
+
+// LogValue implements slog.LogValuer. It leaves out the Subject and Data,
+// which may identify a person, and logs the Actor only by its subject.
+func (c *Claims) LogValue() slog.Value {
+	attrs := []slog.Attr{slog.String("id", c.ID)}
+	if c.Purpose != "" {
+		attrs = append(attrs, slog.String("purpose", c.Purpose))
+	}
+	if c.Audience != "" {
+		attrs = append(attrs, slog.String("audience", c.Audience))
+	}
+	if len(c.Scopes) > 0 {
+		attrs = append(attrs, slog.Any("scopes", c.Scopes))
+	}
+	if c.Actor != nil {
+		attrs = append(attrs, slog.String("actor", c.Actor.Subject))
+	}
+	if len(c.Context) > 0 {
+		attrs = append(attrs, slog.Any("context", c.Context))
+	}
+	if !c.IssuedAt.IsZero() {
+		attrs = append(attrs, slog.Time("issued_at", c.IssuedAt))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// LogValue implements slog.LogValuer. It logs the header and timestamp of r
+// but neither its nonce nor its ciphertext.
+func (r *RawToken) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Int("version", int(r.Version)),
+		slog.Any("key_id", r.KeyID),
+		slog.Time("sealed_at", r.Timestamp),
+	)
+}
+
+// logSeal logs the result of sealing a token with the key with the given id.
+func (t *Tokener) logSeal(id uint32, err error) {
+	if t.logger == nil {
+		return
+	}
+	ctx := context.Background()
+	if err != nil {
+		t.logger.LogAttrs(ctx, slog.LevelError, "securetoken: seal failed",
+			slog.String("purpose", t.purpose), slog.Any("error", errorValue{err}))
+		return
+	}
+	if t.logger.Enabled(ctx, slog.LevelDebug) {
+		t.logger.LogAttrs(ctx, slog.LevelDebug, "securetoken: token sealed",
+			slog.String("purpose", t.purpose), slog.Any("key_id", id))
+	}
+}
+
+// logUnseal logs the result of unsealing a token of n bytes for caller.
+// c is nil unless claims were unsealed. Canary tokens are logged as errors
+// and other failures as warnings; the token itself is never logged.
+func (t *Tokener) logUnseal(caller string, n int, c *Claims, err error) {
+	if t.logger == nil {
+		return
+	}
+	ctx := context.Background()
+	if err != nil {
+		level := slog.LevelWarn
+		if err == ErrCanary {
+			level = slog.LevelError
+		}
+		t.logger.LogAttrs(ctx, level, "securetoken: unseal failed",
+			slog.String("purpose", t.purpose), slog.String("caller", caller),
+			slog.Int("length", n), slog.Any("error", errorValue{err}))
+		return
+	}
+	if t.logger.Enabled(ctx, slog.LevelDebug) {
+		attrs := []slog.Attr{slog.String("purpose", t.purpose), slog.String("caller", caller)}
+		if c != nil {
+			attrs = append(attrs, slog.Any("claims", c))
+		}
+		t.logger.LogAttrs(ctx, slog.LevelDebug, "securetoken: token unsealed", attrs...)
+	}
+}
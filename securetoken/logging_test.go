@@ -0,0 +1,48 @@
+package securetoken
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestLogging(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	tok := MustNewTokener(key, ttl, WithLogger(logger), WithPurpose("session"))
+
+	sealed, err := tok.SealClaims(&Claims{ID: "t1", Subject: "alice@example.com", Scopes: []string{"read"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tok.UnsealClaimsFor("10.0.0.1", sealed); err != nil {
+		t.Fatal(err)
+	}
+	tampered := append([]byte("x"), sealed[1:]...)
+	tok.Unseal(tampered)
+	canary, err := tok.SealCanary(Claims{Subject: "backup"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tok.UnsealClaims(canary)
+
+	out := buf.String()
+	for _, expected := range []string{
+		`level=DEBUG msg="securetoken: token sealed" purpose=session key_id=0`,
+		`level=DEBUG msg="securetoken: token unsealed" purpose=session caller=10.0.0.1 claims.id=t1 claims.scopes=[read]`,
+		`level=WARN msg="securetoken: unseal failed" purpose=session caller="" length=`,
+		`error.kind=invalid`,
+		`level=ERROR msg="securetoken: unseal failed"`,
+		`error.kind=canary`,
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("logged:\n%s\nexpected it to contain %s", out, expected)
+		}
+	}
+	for _, secret := range []string{string(sealed), string(tampered), "alice@example.com", "backup"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("logged:\n%s\nexpected it not to contain %s", out, secret)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+// Package message seals the payloads of messages sent through brokers
+// such as Kafka, SQS or NATS.
+//
+// Each payload is bound to the topic and key of its message as additional
+// authenticated data, so a sealed payload that is copied to another topic
+// or key fails to unseal. Payloads are sealed with a securetoken.Tokener,
+// so they share its keys, rotation and ttl; a Tokener for messages
+// should have a ttl at least as long as messages can stay in the broker.
+package message
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/nicksnyder/go-securetoken/securetoken"
+)
+
+// A Tokener seals and unseals with additional data.
+// It is implemented by *securetoken.Tokener.
+type Tokener interface {
+	SealAAD(plaintext, aad []byte) ([]byte, error)
+	Unseal(sealed []byte, opts ...securetoken.UnsealOption) ([]byte, error)
+}
+
+var _ Tokener = (*securetoken.Tokener)(nil)
+
+// An Appender seals and unseals into buffers supplied by the caller.
+// It is implemented by *securetoken.Tokener.
+type Appender interface {
+	AppendSeal(dst, plaintext, aad []byte) ([]byte, error)
+	AppendUnseal(dst, sealed []byte, opts ...securetoken.UnsealOption) ([]byte, error)
+}
+
+var _ Appender = (*securetoken.Tokener)(nil)
+
+// A Message is a message in a broker.
+type Message struct {
+	Topic   string
+	Key     []byte
+	Payload []byte
+}
+
+// A BatchError is returned by the batch functions when one message fails.
+type BatchError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("message: message %d: %v", e.Index, e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// Seal seals the payload of a message with the given topic and key.
+func Seal(t Tokener, topic string, key, payload []byte) ([]byte, error) {
+	return t.SealAAD(payload, aad(topic, key))
+}
+
+// Unseal unseals a payload sealed by Seal with the same topic and key.
+func Unseal(t Tokener, topic string, key, sealed []byte) ([]byte, error) {
+	return t.Unseal(sealed, securetoken.WithAAD(aad(topic, key)))
+}
+
+// SealBatch replaces the payload of each message with its sealed payload.
+// If a message fails, the error is a *BatchError and msgs are left unchanged.
+func SealBatch(t Tokener, msgs []Message) error {
+	return batch(msgs, func(m *Message) ([]byte, error) {
+		return Seal(t, m.Topic, m.Key, m.Payload)
+	})
+}
+
+// UnsealBatch replaces the sealed payload of each message with its payload.
+// If a message fails, the error is a *BatchError and msgs are left unchanged.
+func UnsealBatch(t Tokener, msgs []Message) error {
+	return batch(msgs, func(m *Message) ([]byte, error) {
+		return Unseal(t, m.Topic, m.Key, m.Payload)
+	})
+}
+
+// SealBatchArena is similar to SealBatch except the sealed payloads are
+// appended to arena instead of being allocated one by one, and the extended
+// arena is returned. The payloads alias the arena, so it must not be reused
+// (e.g. as arena[:0] for the next batch) while they are still needed.
+// If a message fails, the error is a *BatchError, msgs are left unchanged
+// and arena is returned.
+func SealBatchArena(t Appender, msgs []Message, arena []byte) ([]byte, error) {
+	var ad []byte
+	return batchArena(msgs, arena, func(dst []byte, m *Message) ([]byte, error) {
+		ad = appendAAD(ad[:0], m.Topic, m.Key)
+		return t.AppendSeal(dst, m.Payload, ad)
+	})
+}
+
+// UnsealBatchArena is similar to UnsealBatch except the payloads are
+// appended to arena in the same way as SealBatchArena.
+func UnsealBatchArena(t Appender, msgs []Message, arena []byte) ([]byte, error) {
+	var ad []byte
+	return batchArena(msgs, arena, func(dst []byte, m *Message) ([]byte, error) {
+		ad = appendAAD(ad[:0], m.Topic, m.Key)
+		return t.AppendUnseal(dst, m.Payload, securetoken.WithAAD(ad))
+	})
+}
+
+func batchArena(msgs []Message, arena []byte, f func([]byte, *Message) ([]byte, error)) ([]byte, error) {
+	out := make([][]byte, len(msgs))
+	buf := arena
+	for i := range msgs {
+		n := len(buf)
+		var err error
+		buf, err = f(buf, &msgs[i])
+		if err != nil {
+			return arena, &BatchError{Index: i, Err: err}
+		}
+		out[i] = buf[n:len(buf):len(buf)]
+	}
+	for i := range msgs {
+		msgs[i].Payload = out[i]
+	}
+	return buf, nil
+}
+
+func batch(msgs []Message, f func(*Message) ([]byte, error)) error {
+	out := make([][]byte, len(msgs))
+	for i := range msgs {
+		buf, err := f(&msgs[i])
+		if err != nil {
+			return &BatchError{Index: i, Err: err}
+		}
+		out[i] = buf
+	}
+	for i := range msgs {
+		msgs[i].Payload = out[i]
+	}
+	return nil
+}
+
+// aad returns the additional data for a message: the length of topic,
+// topic and key, so that no two topic and key pairs have the same encoding.
+func aad(topic string, key []byte) []byte {
+	return appendAAD(make([]byte, 0, binary.MaxVarintLen64+len(topic)+len(key)), topic, key)
+}
+
+// appendAAD appends the additional data for a message to dst.
+func appendAAD(dst []byte, topic string, key []byte) []byte {
+	dst = binary.AppendUvarint(dst, uint64(len(topic)))
+	dst = append(dst, topic...)
+	return append(dst, key...)
+}
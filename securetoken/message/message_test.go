@@ -0,0 +1,96 @@
+package message
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/nicksnyder/go-securetoken/securetoken"
+	"github.com/nicksnyder/go-securetoken/securetoken/securetokentest"
+)
+
+func TestSealUnseal(t *testing.T) {
+	tok := securetokentest.NewTokener(t)
+	sealed, err := Seal(tok, "users", []byte("42"), []byte(`{"email":"a@example.com"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if payload, err := Unseal(tok, "users", []byte("42"), sealed); string(payload) != `{"email":"a@example.com"}` || err != nil {
+		t.Errorf("Unseal() = %q, %v; expected the payload", payload, err)
+	}
+	tests := []struct {
+		topic string
+		key   string
+	}{
+		{"orders", "42"},
+		{"users", "43"},
+		{"users4", "2"},
+	}
+	for _, test := range tests {
+		if _, err := Unseal(tok, test.topic, []byte(test.key), sealed); err != securetoken.ErrTokenInvalid {
+			t.Errorf("Unseal(%q, %q) returned %v; expected %s", test.topic, test.key, err, securetoken.ErrTokenInvalid)
+		}
+	}
+}
+
+func TestBatch(t *testing.T) {
+	tok := securetokentest.NewTokener(t)
+	msgs := []Message{
+		{Topic: "users", Key: []byte("1"), Payload: []byte("a")},
+		{Topic: "users", Key: []byte("2"), Payload: []byte("b")},
+	}
+	if err := SealBatch(tok, msgs); err != nil {
+		t.Fatal(err)
+	}
+	sealed := append([]Message(nil), msgs...)
+	if err := UnsealBatch(tok, msgs); err != nil {
+		t.Fatal(err)
+	}
+	if string(msgs[0].Payload) != "a" || string(msgs[1].Payload) != "b" {
+		t.Errorf("UnsealBatch() = %q, %q; expected \"a\", \"b\"", msgs[0].Payload, msgs[1].Payload)
+	}
+
+	sealed[1].Key = []byte("3")
+	err := UnsealBatch(tok, sealed)
+	var be *BatchError
+	if !errors.As(err, &be) || be.Index != 1 || !errors.Is(err, securetoken.ErrTokenInvalid) {
+		t.Errorf("UnsealBatch() with a moved message returned %v; expected a BatchError for message 1", err)
+	}
+	if string(sealed[0].Payload) == "a" {
+		t.Error("UnsealBatch() modified messages despite failing")
+	}
+}
+
+func TestBatchArena(t *testing.T) {
+	tok := securetokentest.NewTokener(t)
+	msgs := []Message{
+		{Topic: "users", Key: []byte("1"), Payload: []byte("a")},
+		{Topic: "users", Key: []byte("2"), Payload: []byte("b")},
+	}
+	arena := make([]byte, 0, 4096)
+	arena, err := SealBatchArena(tok, msgs, arena)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cap(arena) != 4096 {
+		t.Errorf("SealBatchArena() grew the arena to %d bytes; expected it to fit in 4096", cap(arena))
+	}
+	for i, m := range msgs {
+		if p, err := Unseal(tok, m.Topic, m.Key, m.Payload); err != nil {
+			t.Errorf("Unseal() of message %d = %q, %v; expected the payload", i, p, err)
+		}
+	}
+
+	plain := make([]byte, 0, 2)
+	plain, err = UnsealBatchArena(tok, msgs, plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(plain) != "ab" || string(msgs[0].Payload) != "a" || string(msgs[1].Payload) != "b" {
+		t.Errorf("UnsealBatchArena() = %q with payloads %q, %q; expected \"ab\", \"a\", \"b\"", plain, msgs[0].Payload, msgs[1].Payload)
+	}
+
+	sealed := []Message{{Topic: "users", Key: []byte("3"), Payload: []byte("not a token")}}
+	if out, err := UnsealBatchArena(tok, sealed, arena[:0]); len(out) != 0 || err == nil {
+		t.Errorf("UnsealBatchArena() with an invalid token = %q, %v; expected an empty arena and an error", out, err)
+	}
+}
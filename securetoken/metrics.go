@@ -0,0 +1,195 @@
+package securetoken
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// A TokenEvent describes one call that sealed or unsealed a token,
+// for the metrics hook of a Tokener (see WithMetricsHook).
+type TokenEvent struct {
+	// Unseal is false for seals and true for unseals.
+	Unseal bool
+
+	// Purpose is the Purpose of the claims, if the token is a claims token
+	// that has one, or else the purpose of the Tokener (see WithPurpose).
+	Purpose string
+
+	// TTL is the TTL of the claims, if the token is a claims token
+	// that has one, or else the ttl of the Tokener.
+	TTL time.Duration
+
+	// PayloadSize is the length of the plaintext or JSON claims, before
+	// padding. It is 0 if Err is not nil.
+	PayloadSize int
+
+	// TokenSize is the length of the sealed token.
+	TokenSize int
+
+	// Err is the error that the call returned.
+	Err error
+}
+
+// WithMetricsHook returns an Option that makes the Tokener call hook once
+// for every Seal, SealClaims, Unseal, UnsealClaims and UnsealSubject call
+// and their variants, e.g. to feed histograms labeled by purpose.
+// PurposeStats is a hook that keeps the histograms in memory.
+// hook is called synchronously, so it must be fast.
+func WithMetricsHook(hook func(TokenEvent)) Option {
+	return func(t *Tokener) error {
+		t.metrics = hook
+		return nil
+	}
+}
+
+// reportSeal calls the metrics hook for a seal of payloadSize bytes into a
+// token of tokenSize bytes. c is nil unless claims were sealed.
+func (t *Tokener) reportSeal(c *Claims, payloadSize, tokenSize int, err error) {
+	if t.metrics != nil {
+		t.metrics(t.event(false, c, payloadSize, tokenSize, err))
+	}
+}
+
+// reportUnseal calls the metrics hook for an unseal of a token of
+// tokenSize bytes. c is nil unless claims were unsealed.
+func (t *Tokener) reportUnseal(c *Claims, cfg *unsealConfig, tokenSize int, err error) {
+	if t.metrics != nil {
+		t.metrics(t.event(true, c, cfg.payloadLen, tokenSize, err))
+	}
+}
+
+func (t *Tokener) event(unseal bool, c *Claims, payloadSize, tokenSize int, err error) TokenEvent {
+	e := TokenEvent{
+		Unseal:      unseal,
+		Purpose:     t.purpose,
+		TTL:         t.policy().ttl,
+		PayloadSize: payloadSize,
+		TokenSize:   tokenSize,
+		Err:         err,
+	}
+	if c != nil && c.Purpose != "" {
+		e.Purpose = c.Purpose
+	}
+	if c != nil && c.TTL > 0 {
+		e.TTL = c.TTL
+	}
+	if err != nil {
+		e.PayloadSize = 0
+	}
+	return e
+}
+
+// DefaultTTLBounds are the TTL histogram bounds of NewPurposeStats.
+var DefaultTTLBounds = []time.Duration{
+	time.Minute,
+	5 * time.Minute,
+	15 * time.Minute,
+	time.Hour,
+	24 * time.Hour,
+	7 * 24 * time.Hour,
+	30 * 24 * time.Hour,
+}
+
+// PurposeStats keeps a PurposeSummary for each purpose, for capacity
+// planning: which kinds of tokens dominate traffic, how large they are
+// (e.g. against a cookie budget) and how long they live.
+// Use its Observe method with WithMetricsHook.
+// It is goroutine safe.
+type PurposeStats struct {
+	bounds []time.Duration
+
+	mu       sync.Mutex
+	purposes map[string]*PurposeSummary
+}
+
+// A PurposeSummary summarizes the TokenEvents of one purpose.
+type PurposeSummary struct {
+	// Seals and Unseals count successful calls, and Failures failed ones.
+	Seals, Unseals, Failures uint64
+
+	// TTLCounts is a histogram of the TTLs of sealed tokens:
+	// TTLCounts[i] counts the tokens with a TTL of at most TTLBounds[i]
+	// that are not counted by TTLCounts[i-1], and the last count
+	// is of the TTLs above every bound.
+	TTLBounds []time.Duration
+	TTLCounts []uint64
+
+	// Payload and Token summarize the payload and token sizes
+	// of successful calls.
+	Payload SizeSummary
+	Token   SizeSummary
+}
+
+// A SizeSummary summarizes a number of sizes in bytes.
+type SizeSummary struct {
+	Count    uint64
+	Sum      uint64
+	Min, Max int
+}
+
+// Mean returns the mean size, or 0 if there are no sizes.
+func (s SizeSummary) Mean() float64 {
+	if s.Count == 0 {
+		return 0
+	}
+	return float64(s.Sum) / float64(s.Count)
+}
+
+func (s *SizeSummary) add(n int) {
+	if s.Count == 0 || n < s.Min {
+		s.Min = n
+	}
+	if n > s.Max {
+		s.Max = n
+	}
+	s.Count++
+	s.Sum += uint64(n)
+}
+
+// NewPurposeStats returns an empty PurposeStats whose TTL histograms
+// have the given upper bounds, or DefaultTTLBounds if there are none.
+func NewPurposeStats(ttlBounds ...time.Duration) *PurposeStats {
+	if len(ttlBounds) == 0 {
+		ttlBounds = DefaultTTLBounds
+	}
+	bounds := append([]time.Duration(nil), ttlBounds...)
+	sort.Slice(bounds, func(i, j int) bool { return bounds[i] < bounds[j] })
+	return &PurposeStats{bounds: bounds, purposes: make(map[string]*PurposeSummary)}
+}
+
+// Observe records e.
+func (s *PurposeStats) Observe(e TokenEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := s.purposes[e.Purpose]
+	if p == nil {
+		p = &PurposeSummary{TTLBounds: s.bounds, TTLCounts: make([]uint64, len(s.bounds)+1)}
+		s.purposes[e.Purpose] = p
+	}
+	switch {
+	case e.Err != nil:
+		p.Failures++
+		return
+	case e.Unseal:
+		p.Unseals++
+	default:
+		p.Seals++
+		p.TTLCounts[sort.Search(len(s.bounds), func(i int) bool { return e.TTL <= s.bounds[i] })]++
+	}
+	p.Payload.add(e.PayloadSize)
+	p.Token.add(e.TokenSize)
+}
+
+// Snapshot returns a copy of the summary of every purpose.
+func (s *PurposeStats) Snapshot() map[string]PurposeSummary {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make(map[string]PurposeSummary, len(s.purposes))
+	for purpose, p := range s.purposes {
+		c := *p
+		c.TTLCounts = append([]uint64(nil), p.TTLCounts...)
+		out[purpose] = c
+	}
+	return out
+}
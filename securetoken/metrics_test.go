@@ -0,0 +1,50 @@
+package securetoken
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPurposeStats(t *testing.T) {
+	stats := NewPurposeStats(time.Minute, time.Hour)
+	tok, err := NewTokener(key, ttl, WithPurpose("session"), WithMetricsHook(stats.Observe))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := tok.Seal([]byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tok.Unseal(sealed); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tok.Unseal([]byte("invalid")); err == nil {
+		t.Fatal("Unseal(invalid) returned <nil>; expected error")
+	}
+	invite, err := tok.SealClaims(&Claims{Subject: "alice", Purpose: "invite", TTL: 2 * time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tok.UnsealClaims(invite); err != nil {
+		t.Fatal(err)
+	}
+
+	s := stats.Snapshot()
+	session := s["session"]
+	if session.Seals != 1 || session.Unseals != 1 || session.Failures != 1 {
+		t.Errorf("session seals, unseals, failures = %d, %d, %d; expected 1, 1, 1", session.Seals, session.Unseals, session.Failures)
+	}
+	if session.Payload.Min != 5 || session.Payload.Max != 5 || session.Token.Max != len(sealed) {
+		t.Errorf("session sizes = %+v, %+v; expected payloads of 5 bytes and tokens of %d", session.Payload, session.Token, len(sealed))
+	}
+	if got := session.TTLCounts; len(got) != 3 || got[0] != 1 {
+		t.Errorf("session TTLCounts = %v; expected [1 0 0]", got)
+	}
+	inv := s["invite"]
+	if inv.Seals != 1 || inv.Unseals != 1 || inv.TTLCounts[2] != 1 {
+		t.Errorf("invite summary = %+v; expected 1 seal and unseal with a TTL above an hour", inv)
+	}
+	if inv.Payload.Mean() <= 5 {
+		t.Errorf("invite Payload.Mean() = %v; expected the size of the JSON claims", inv.Payload.Mean())
+	}
+}
@@ -0,0 +1,50 @@
+package securetoken
+
+import (
+	"log/slog"
+	"time"
+)
+
+// MustNewTokener is like NewTokener but panics if the Tokener cannot be created.
+// It is meant for initializing globals and for main; errors while sealing
+// and unsealing must still be handled.
+func MustNewTokener(key []byte, ttl time.Duration, opts ...Option) *Tokener {
+	t, err := NewTokener(key, ttl, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// MustNewKeyringTokener is like NewKeyringTokener but panics if the
+// Tokener cannot be created.
+func MustNewKeyringTokener(kr *Keyring, ttl time.Duration, opts ...Option) *Tokener {
+	t, err := NewKeyringTokener(kr, ttl, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// MustSeal is like Seal but panics on error.
+// It is meant for tokens created during initialization, such as fixtures.
+func (t *Tokener) MustSeal(plaintext []byte) []byte {
+	sealed, err := t.Seal(plaintext)
+	if err != nil {
+		panic(err)
+	}
+	return sealed
+}
+
+// WithLogger returns an Option that makes the Tokener log to logger,
+// starting with a description of its configuration once it is created.
+// Failures to seal are logged as errors, failures to unseal as warnings
+// (or as errors for canary tokens), and tokens sealed and unsealed at the
+// debug level. Events carry the kind of error, the purpose and the caller
+// but never a token, key material, or the subject or data of claims.
+func WithLogger(logger *slog.Logger) Option {
+	return func(t *Tokener) error {
+		t.logger = logger
+		return nil
+	}
+}
@@ -0,0 +1,33 @@
+package securetoken
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestMustNewTokener(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("MustNewTokener() with an invalid key did not panic")
+		}
+	}()
+	tok := MustNewTokener(key, ttl)
+	if plaintext, err := tok.Unseal(tok.MustSeal([]byte("x"))); string(plaintext) != "x" || err != nil {
+		t.Errorf("Unseal(MustSeal(\"x\")) = %q, %v; expected \"x\", <nil>", plaintext, err)
+	}
+	MustNewTokener([]byte("short"), ttl)
+}
+
+func TestWithLogger(t *testing.T) {
+	var buf bytes.Buffer
+	MustNewTokener(key, ttl, WithLogger(slog.New(slog.NewTextHandler(&buf, nil))))
+	out := buf.String()
+	if !strings.Contains(out, "tokener created") || !strings.Contains(out, "tokener.ttl=1m0s") {
+		t.Errorf("WithLogger logged %q; expected the tokener configuration", out)
+	}
+	if strings.Contains(out, string(key)) {
+		t.Errorf("WithLogger logged %q; expected no key material", out)
+	}
+}
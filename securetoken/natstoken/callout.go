@@ -0,0 +1,69 @@
+//go:build nats
+
+package natstoken
+
+import (
+	"github.com/nats-io/jwt/v2"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nkeys"
+)
+
+// AuthCalloutSubject is the subject that the NATS server sends
+// auth callout requests to.
+const AuthCalloutSubject = "$SYS.REQ.USER.AUTH"
+
+// A Callout answers NATS auth callout requests.
+type Callout struct {
+	// Authorizer authorizes the token of each client.
+	Authorizer *Authorizer
+
+	// Issuer signs the user and response JWTs. It is the key pair
+	// configured as the auth callout issuer of the NATS server.
+	Issuer nkeys.KeyPair
+
+	// Account is the account that clients are placed in.
+	Account string
+}
+
+// Subscribe subscribes c to auth callout requests on nc.
+func (c *Callout) Subscribe(nc *nats.Conn) (*nats.Subscription, error) {
+	return nc.Subscribe(AuthCalloutSubject, c.handle)
+}
+
+func (c *Callout) handle(msg *nats.Msg) {
+	req, err := jwt.DecodeAuthorizationRequestClaims(string(msg.Data))
+	if err != nil {
+		return
+	}
+	resp := jwt.NewAuthorizationResponseClaims(req.UserNkey)
+	resp.Audience = req.Server.ID
+	if userJWT, err := c.userJWT(req); err != nil {
+		resp.Error = err.Error()
+	} else {
+		resp.Jwt = userJWT
+	}
+	token, err := resp.Encode(c.Issuer)
+	if err != nil {
+		return
+	}
+	msg.Respond([]byte(token))
+}
+
+func (c *Callout) userJWT(req *jwt.AuthorizationRequestClaims) (string, error) {
+	auth, err := c.Authorizer.Authorize(req.ConnectOptions.Token)
+	if err != nil {
+		return "", err
+	}
+	uc := jwt.NewUserClaims(req.UserNkey)
+	uc.Name = auth.User
+	uc.Audience = c.Account
+	if !auth.Expires.IsZero() {
+		uc.Expires = auth.Expires.Unix()
+	}
+	p := auth.Permissions
+	uc.Pub.Allow.Add(p.PublishAllow...)
+	uc.Pub.Deny.Add(p.PublishDeny...)
+	uc.Sub.Allow.Add(p.SubscribeAllow...)
+	uc.Sub.Deny.Add(p.SubscribeDeny...)
+	return uc.Encode(c.Issuer)
+}
@@ -0,0 +1,129 @@
+// Package natstoken lets NATS clients authenticate with sealed tokens
+// through the NATS auth callout, so that web and messaging clients share
+// one token system.
+//
+// Clients connect with a token sealed by SealClaims as their NATS token
+// (nats.Token). The server forwards it to an auth callout service, where
+// an Authorizer unseals it and maps its claims to NATS permissions.
+// The callout service itself, which decodes and signs NATS JWTs,
+// is in this package when built with the nats build tag:
+//
+//	go build -tags nats
+package natstoken
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/nicksnyder/go-securetoken/securetoken"
+)
+
+// Scope prefixes mapped to permissions by ScopePermissions.
+const (
+	PublishScope   = "nats:pub:"
+	SubscribeScope = "nats:sub:"
+)
+
+var (
+	errNoAudience = errors.New("natstoken: Authorizer requires an Audience or a Purpose")
+	errNoExpiry   = errors.New("natstoken: token does not expire")
+)
+
+// Permissions are the NATS permissions of a client.
+type Permissions struct {
+	PublishAllow   []string
+	PublishDeny    []string
+	SubscribeAllow []string
+	SubscribeDeny  []string
+}
+
+// An Authorization is the result of authorizing a client.
+type Authorization struct {
+	// User is the name that NATS knows the client by.
+	User string
+
+	// Permissions are the permissions of the client.
+	Permissions *Permissions
+
+	// Expires is when the client is disconnected: when its token expires.
+	Expires time.Time
+}
+
+// An Authorizer maps tokens to NATS permissions.
+type Authorizer struct {
+	// Tokener unseals tokens.
+	Tokener securetoken.ClaimsUnsealer
+
+	// Audience and Purpose, if not empty, are the Audience and Purpose
+	// that tokens must have. At least one must be set, so that tokens
+	// issued for other services, which the Tokener may share a key with,
+	// do not grant NATS access.
+	Audience string
+	Purpose  string
+
+	// TTL is the ttl of tokens, used to compute when clients expire.
+	// Tokens with a shorter claims TTL expire sooner, and tokens
+	// without either are rejected, since NATS would never disconnect them.
+	TTL time.Duration
+
+	// Permissions maps the claims of a token to permissions.
+	// If it is nil, ScopePermissions is used.
+	Permissions func(c *securetoken.Claims) (*Permissions, error)
+}
+
+// Authorize unseals token and returns the authorization of its client.
+func (a *Authorizer) Authorize(token string) (*Authorization, error) {
+	if a.Audience == "" && a.Purpose == "" {
+		return nil, errNoAudience
+	}
+	var opts []securetoken.UnsealOption
+	if a.Audience != "" {
+		opts = append(opts, securetoken.WithAudience(a.Audience))
+	}
+	c, err := a.Tokener.UnsealClaims([]byte(token), opts...)
+	if err != nil {
+		return nil, err
+	}
+	if a.Purpose != "" && c.Purpose != a.Purpose {
+		return nil, securetoken.ErrTokenInvalid
+	}
+	permissions := a.Permissions
+	if permissions == nil {
+		permissions = ScopePermissions
+	}
+	p, err := permissions(c)
+	if err != nil {
+		return nil, err
+	}
+	ttl := a.TTL
+	if c.TTL > 0 && (ttl <= 0 || c.TTL < ttl) {
+		ttl = c.TTL
+	}
+	if ttl <= 0 {
+		return nil, errNoExpiry
+	}
+	return &Authorization{User: c.Subject, Permissions: p, Expires: c.IssuedAt.Add(ttl)}, nil
+}
+
+// ScopePermissions allows a client to publish to the subjects of its
+// "nats:pub:<subject>" scopes and subscribe to the subjects of its
+// "nats:sub:<subject>" scopes, and nothing else.
+func ScopePermissions(c *securetoken.Claims) (*Permissions, error) {
+	p := &Permissions{}
+	for _, s := range c.Scopes {
+		switch {
+		case strings.HasPrefix(s, PublishScope):
+			p.PublishAllow = append(p.PublishAllow, s[len(PublishScope):])
+		case strings.HasPrefix(s, SubscribeScope):
+			p.SubscribeAllow = append(p.SubscribeAllow, s[len(SubscribeScope):])
+		}
+	}
+	if len(p.PublishAllow) == 0 {
+		p.PublishDeny = []string{">"}
+	}
+	if len(p.SubscribeAllow) == 0 {
+		p.SubscribeDeny = []string{">"}
+	}
+	return p, nil
+}
@@ -0,0 +1,81 @@
+package natstoken
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nicksnyder/go-securetoken/securetoken"
+	"github.com/nicksnyder/go-securetoken/securetoken/securetokentest"
+)
+
+func TestAuthorize(t *testing.T) {
+	tok := securetokentest.NewTokener(t)
+	a := &Authorizer{Tokener: tok, Audience: "nats", TTL: securetokentest.TTL}
+	sealed, err := securetoken.NewClaims().
+		Subject("device-7").
+		Audience("nats").
+		Scope("nats:pub:telemetry.device-7", "nats:sub:commands.device-7", "orders:read").
+		TTL(10 * time.Minute).
+		Seal(tok)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth, err := a.Authorize(string(sealed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &Permissions{
+		PublishAllow:   []string{"telemetry.device-7"},
+		SubscribeAllow: []string{"commands.device-7"},
+	}
+	if auth.User != "device-7" || !reflect.DeepEqual(auth.Permissions, expected) {
+		t.Errorf("Authorize() = %+v; expected user device-7 with permissions %+v", auth, expected)
+	}
+	if want := securetokentest.Now.Add(10 * time.Minute); !auth.Expires.Equal(want) {
+		t.Errorf("Authorize() expires %s; expected %s", auth.Expires, want)
+	}
+	if _, err := a.Authorize("forged"); err != securetoken.ErrTokenInvalid {
+		t.Errorf("Authorize(\"forged\") returned %v; expected %s", err, securetoken.ErrTokenInvalid)
+	}
+}
+
+func TestAuthorizeRejects(t *testing.T) {
+	tok := securetokentest.NewTokener(t)
+	web, err := securetoken.NewClaims().Subject("alice").Audience("web").Seal(tok)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &Authorizer{Tokener: tok, Audience: "nats", TTL: securetokentest.TTL}
+	if _, err := a.Authorize(string(web)); err != securetoken.ErrWrongAudience {
+		t.Errorf("Authorize() of a token for another audience returned %v; expected %s", err, securetoken.ErrWrongAudience)
+	}
+
+	sealed, err := securetoken.NewClaims().Subject("alice").Audience("nats").Seal(tok)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		name     string
+		a        *Authorizer
+		expected error
+	}{
+		{"no audience or purpose", &Authorizer{Tokener: tok, TTL: securetokentest.TTL}, errNoAudience},
+		{"another purpose", &Authorizer{Tokener: tok, Audience: "nats", Purpose: "nats", TTL: securetokentest.TTL}, securetoken.ErrTokenInvalid},
+		{"no expiry", &Authorizer{Tokener: tok, Audience: "nats"}, errNoExpiry},
+	} {
+		if _, err := tt.a.Authorize(string(sealed)); err != tt.expected {
+			t.Errorf("Authorize() with %s returned %v; expected %s", tt.name, err, tt.expected)
+		}
+	}
+}
+
+func TestScopePermissionsDenyByDefault(t *testing.T) {
+	p, err := ScopePermissions(&securetoken.Claims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(p.PublishDeny, []string{">"}) || !reflect.DeepEqual(p.SubscribeDeny, []string{">"}) {
+		t.Errorf("ScopePermissions() without scopes = %+v; expected everything denied", p)
+	}
+}
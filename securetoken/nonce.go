@@ -0,0 +1,42 @@
+package securetoken
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"time"
+)
+
+var errNonceTimestamp = errors.New("securetoken: nonce source did not write the timestamp")
+
+// A NonceSource generates the nonces of sealed tokens.
+// Implementations must be goroutine safe.
+type NonceSource interface {
+	// PutNonce fills nonce for a token sealed at now.
+	// The first TimestampLength bytes must hold now, as written by PutTimestamp,
+	// because Unseal reads the age of a token from them.
+	// A nonce must never be repeated for the same key.
+	PutNonce(nonce []byte, now time.Time) error
+}
+
+// PutTimestamp writes now into the first TimestampLength bytes of dst.
+func PutTimestamp(dst []byte, now time.Time) {
+	binary.LittleEndian.PutUint64(dst, uint64(now.UnixNano()))
+}
+
+// NewRandomNonceSource returns the default NonceSource,
+// which fills the bytes after the timestamp from r.
+// r is usually crypto/rand.Reader.
+func NewRandomNonceSource(r io.Reader) NonceSource {
+	return randomNonceSource{r}
+}
+
+type randomNonceSource struct {
+	r io.Reader
+}
+
+func (s randomNonceSource) PutNonce(nonce []byte, now time.Time) error {
+	PutTimestamp(nonce, now)
+	_, err := io.ReadFull(s.r, nonce[TimestampLength:])
+	return err
+}
@@ -0,0 +1,72 @@
+package securetoken
+
+import (
+	"encoding/binary"
+	"sync"
+	"testing"
+	"time"
+)
+
+// counterNonceSource fills nonces with an increasing counter.
+type counterNonceSource struct {
+	mu sync.Mutex
+	n  uint32
+}
+
+func (c *counterNonceSource) PutNonce(nonce []byte, now time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.n++
+	PutTimestamp(nonce, now)
+	binary.BigEndian.PutUint32(nonce[TimestampLength:], c.n)
+	return nil
+}
+
+func TestWithNonceSource(t *testing.T) {
+	setNow(time.Unix(1, 0))
+	defer restoreNow()
+
+	src := &counterNonceSource{}
+	tok, err := NewTokener(key, ttl, WithNonceSource(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := tok.Seal([]byte("data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := tok.decode(sealed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := ParseRaw(decoded, MinNonceLength)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := binary.BigEndian.Uint32(raw.Nonce[TimestampLength:]); n != 1 {
+		t.Errorf("nonce counter = %d; expected 1", n)
+	}
+	if data, err := tok.Unseal(sealed); err != nil || string(data) != "data" {
+		t.Errorf("Unseal(%q) = %q, %v; expected \"data\", <nil>", sealed, data, err)
+	}
+
+	if _, err := NewTokener(key, ttl, WithNonceSource(nil)); err == nil {
+		t.Errorf("NewTokener(WithNonceSource(nil)) returned nil error")
+	}
+}
+
+type badNonceSource struct{}
+
+func (badNonceSource) PutNonce(nonce []byte, now time.Time) error {
+	return nil
+}
+
+func TestNonceSourceMissingTimestamp(t *testing.T) {
+	tok, err := NewTokener(key, ttl, WithNonceSource(badNonceSource{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sealed, err := tok.Seal([]byte("data")); sealed != nil || err != errNonceTimestamp {
+		t.Errorf("Seal() = %q, %v; expected <nil>, %s", sealed, err, errNonceTimestamp)
+	}
+}
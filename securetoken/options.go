@@ -0,0 +1,128 @@
+package securetoken
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// An Option configures a Tokener.
+type Option func(*Tokener) error
+
+// WithMinVersion returns an Option that makes Unseal reject tokens
+// whose format version is older than v with ErrVersionRejected.
+// It is intended to be used once a migration window to a newer
+// token version has closed.
+// v must be between 1 and the version that Seal produces, which is
+// checked once all options have been applied, so that options that
+// change the version (e.g. WithAutoAEAD) may come later.
+func WithMinVersion(v uint8) Option {
+	return func(t *Tokener) error {
+		if v < Version1 {
+			return fmt.Errorf("securetoken: invalid minimum version %d", v)
+		}
+		t.minVersion = v
+		return nil
+	}
+}
+
+// checkMinVersion returns an error if t would reject the tokens it seals.
+func (t *Tokener) checkMinVersion() error {
+	if t.minVersion > t.version {
+		return fmt.Errorf("securetoken: invalid minimum version %d", t.minVersion)
+	}
+	return nil
+}
+
+// checkOptions runs the checks that depend on more than one option once
+// all of them have been applied: checkMinVersion and, if WithSelfTest
+// was given, SelfTest.
+func (t *Tokener) checkOptions() error {
+	if err := t.checkMinVersion(); err != nil {
+		return err
+	}
+	if t.selfTest {
+		return t.SelfTest()
+	}
+	return nil
+}
+
+// WithClock returns an Option that makes the Tokener use now
+// instead of time.Now to timestamp and expire tokens.
+func WithClock(now func() time.Time) Option {
+	return func(t *Tokener) error {
+		if now == nil {
+			return errors.New("securetoken: nil clock")
+		}
+		t.clock = now
+		return nil
+	}
+}
+
+// WithRandom returns an Option that makes the Tokener read the random
+// part of nonces from r instead of crypto/rand.Reader, such as a hardware
+// TRNG or, in deterministic simulation tests, a seeded generator.
+// r must be safe for concurrent use and must be cryptographically secure
+// outside of tests. NewTokener fails if reading from r fails, and
+// SelfTest checks r rather than crypto/rand.Reader.
+func WithRandom(r io.Reader) Option {
+	return func(t *Tokener) error {
+		if r == nil {
+			return errors.New("securetoken: nil random source")
+		}
+		if _, err := io.ReadFull(r, make([]byte, 16)); err != nil {
+			return fmt.Errorf("securetoken: random source failed: %w", err)
+		}
+		t.random = r
+		t.nonces = NewRandomNonceSource(r)
+		return nil
+	}
+}
+
+// WithNonceSource returns an Option that makes the Tokener
+// generate nonces with src.
+func WithNonceSource(src NonceSource) Option {
+	return func(t *Tokener) error {
+		if src == nil {
+			return errors.New("securetoken: nil nonce source")
+		}
+		t.nonces = src
+		return nil
+	}
+}
+
+// WithFailureLimiter returns an Option that makes Unseal and UnsealFor
+// rate limit failures with l.
+func WithFailureLimiter(l *FailureLimiter) Option {
+	return func(t *Tokener) error {
+		t.limiter = l
+		return nil
+	}
+}
+
+// WithPurpose returns an Option that binds tokens to purpose
+// (e.g. "session" or "csrf"). The purpose is authenticated along with
+// every token, so a token sealed for one purpose can not be unsealed by
+// a Tokener with a different purpose, even if they share keys.
+func WithPurpose(purpose string) Option {
+	return func(t *Tokener) error {
+		t.purpose = purpose
+		return nil
+	}
+}
+
+// WithMaxLength returns an Option that makes Unseal reject tokens longer
+// than n encoded bytes with ErrTokenTooLong before decoding or allocating
+// anything, which bounds the work done for unauthenticated input.
+// It should be set on Tokeners that unseal tokens from public endpoints.
+// The default of 0 accepts tokens of any length.
+func WithMaxLength(n int) Option {
+	return func(t *Tokener) error {
+		if n < 0 {
+			return fmt.Errorf("securetoken: invalid maximum length %d", n)
+		}
+		t.maxLength = n
+		return nil
+	}
+}
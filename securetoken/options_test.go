@@ -0,0 +1,144 @@
+package securetoken
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWithMinVersionInvalid(t *testing.T) {
+	for _, v := range []uint8{0, Version2} {
+		if _, err := NewTokener(key, ttl, WithMinVersion(v)); err == nil {
+			t.Errorf("NewTokener(WithMinVersion(%d)) returned nil error", v)
+		}
+	}
+}
+
+func TestWithMinVersionAfterAutoAEAD(t *testing.T) {
+	tok, err := NewTokener(key, ttl, WithAutoAEAD(), WithMinVersion(Version2))
+	if err != nil {
+		t.Fatalf("NewTokener(WithAutoAEAD(), WithMinVersion(2)) returned %s", err)
+	}
+	sealed, err := tok.Seal([]byte("data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tok.Unseal(sealed); err != nil {
+		t.Errorf("Unseal(%q) returned %s", sealed, err)
+	}
+}
+
+// TestUnsealVersionRejected tests that Unseal returns ErrVersionRejected
+// for tokens older than the minimum version.
+func TestUnsealVersionRejected(t *testing.T) {
+	setNow(time.Unix(1, 0))
+	defer restoreNow()
+
+	tok, err := NewTokener(key, ttl, WithMinVersion(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := tok.Seal([]byte("data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tok.Unseal(sealed); err != nil {
+		t.Fatalf("Unseal(%q) returned non-nil error: %s", sealed, err)
+	}
+
+	// Simulate a tokener that has moved past version 1.
+	tok.live.p.Store(&policy{ttl: ttl, minVersion: 2})
+	data, err := tok.Unseal(sealed)
+	if data != nil || err != ErrVersionRejected {
+		t.Fatalf("Unseal(%q) = %q, %v; expected <nil>, %s", sealed, data, err, ErrVersionRejected)
+	}
+}
+
+func TestWithClockAndRandom(t *testing.T) {
+	now := time.Unix(100, 0)
+	clock := func() time.Time { return now }
+	newTok := func() *Tokener {
+		tok, err := NewTokener(key, ttl, WithClock(clock), WithRandom(zeroReader{}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tok
+	}
+	a, err := newTok().Seal([]byte("data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := newTok().Seal([]byte("data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(a) != string(b) {
+		t.Errorf("Seal() = %q and %q; expected identical tokens", a, b)
+	}
+
+	tok := newTok()
+	now = now.Add(ttl + 1)
+	if data, err := tok.Unseal(a); data != nil || err != ErrTokenExpired {
+		t.Errorf("Unseal(%q) = %q, %v; expected <nil>, %s", a, data, err, ErrTokenExpired)
+	}
+
+	if _, err := NewTokener(key, ttl, WithClock(nil)); err == nil {
+		t.Errorf("NewTokener(WithClock(nil)) returned nil error")
+	}
+	if _, err := NewTokener(key, ttl, WithRandom(nil)); err == nil {
+		t.Errorf("NewTokener(WithRandom(nil)) returned nil error")
+	}
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
+
+func TestWithMaxLength(t *testing.T) {
+	tok, err := NewTokener(key, ttl, WithMaxLength(64))
+	if err != nil {
+		t.Fatal(err)
+	}
+	short, err := tok.Seal([]byte("data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tok.Unseal(short); err != nil {
+		t.Errorf("Unseal(%q) returned %s", short, err)
+	}
+	long, err := tok.Seal(make([]byte, 64))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, err := tok.Unseal(long); data != nil || err != ErrTokenTooLong {
+		t.Errorf("Unseal(%q) = %q, %v; expected <nil>, %s", long, data, err, ErrTokenTooLong)
+	}
+	if _, err := NewTokener(key, ttl, WithMaxLength(-1)); err == nil {
+		t.Errorf("NewTokener(WithMaxLength(-1)) returned nil error")
+	}
+}
+
+type failingReader struct{}
+
+func (failingReader) Read(p []byte) (int, error) {
+	return 0, errors.New("TRNG offline")
+}
+
+func TestWithRandomValidation(t *testing.T) {
+	if _, err := NewTokener(key, ttl, WithRandom(failingReader{})); err == nil {
+		t.Error("NewTokener(WithRandom(failingReader{})) returned nil error")
+	}
+	// A deterministic source is accepted, but fails SelfTest.
+	tok, err := NewTokener(key, ttl, WithRandom(zeroReader{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tok.SelfTest(); err == nil {
+		t.Error("SelfTest() with a zero random source returned nil error")
+	}
+}
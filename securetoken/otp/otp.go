@@ -0,0 +1,148 @@
+// Package otp implements short numeric one-time codes, such as for
+// verifying an email address or phone number, without server side state
+// for the code itself.
+//
+// Issue returns a code to send to the user together with a token that
+// seals the code, its subject and its purpose. The token is kept by the
+// client, e.g. in a cookie or hidden form field, and sent back along with
+// the code that the user enters. Because a short code can be guessed,
+// attempts are counted per token in an AttemptStore.
+package otp
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/nicksnyder/go-securetoken/securetoken"
+)
+
+// Purpose is the purpose that Tokeners of one-time code tokens should be
+// created with (see securetoken.WithPurpose).
+const Purpose = "securetoken.otp"
+
+var (
+	// ErrWrongCode is returned by Verify when the code, subject or purpose
+	// does not match the token.
+	ErrWrongCode = errors.New("otp: wrong code")
+
+	// ErrTooManyAttempts is returned by Verify when a token has been
+	// tried too many times or has already been used.
+	ErrTooManyAttempts = errors.New("otp: too many attempts")
+)
+
+// An AttemptStore counts the attempts made with each token.
+// Implementations must be goroutine safe.
+type AttemptStore interface {
+	// Attempt records an attempt with the token id and returns the number
+	// of attempts recorded so far, including this one.
+	Attempt(id string) (int, error)
+
+	// Exhaust makes every later attempt with the token id fail.
+	Exhaust(id string) error
+}
+
+// A Manager issues and verifies one-time codes.
+type Manager struct {
+	// Tokener seals the tokens. Its ttl is how long a code is valid,
+	// typically a few minutes.
+	Tokener securetoken.SealUnsealer
+
+	// Attempts counts attempts per token.
+	Attempts AttemptStore
+
+	// Digits is the length of codes. It defaults to 6.
+	Digits int
+
+	// MaxAttempts is the number of attempts allowed per token. It defaults to 5.
+	MaxAttempts int
+}
+
+type payload struct {
+	ID      string `json:"id"`
+	Subject string `json:"sub"`
+	Purpose string `json:"pur"`
+	Code    string `json:"code"`
+}
+
+// Issue returns a new code for subject and purpose (e.g. "verify-email")
+// and the token that must be presented with it.
+func (m *Manager) Issue(subject, purpose string) (code string, token []byte, err error) {
+	code, err = randomCode(m.digits())
+	if err != nil {
+		return "", nil, err
+	}
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", nil, err
+	}
+	buf, err := json.Marshal(payload{
+		ID:      base64.RawURLEncoding.EncodeToString(id),
+		Subject: subject,
+		Purpose: purpose,
+		Code:    code,
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	token, err = m.Tokener.Seal(buf)
+	if err != nil {
+		return "", nil, err
+	}
+	return code, token, nil
+}
+
+// Verify returns nil if code is the code of token and token was issued for
+// subject and purpose. A token can only be verified successfully once.
+func (m *Manager) Verify(token []byte, subject, purpose, code string) error {
+	buf, err := m.Tokener.Unseal(token)
+	if err != nil {
+		return err
+	}
+	p := &payload{}
+	if err := json.Unmarshal(buf, p); err != nil || p.ID == "" {
+		return securetoken.ErrTokenInvalid
+	}
+	n, err := m.Attempts.Attempt(p.ID)
+	if err != nil {
+		return err
+	}
+	if n > m.maxAttempts() {
+		return ErrTooManyAttempts
+	}
+	ok := subtle.ConstantTimeCompare([]byte(code), []byte(p.Code)) &
+		subtle.ConstantTimeCompare([]byte(subject), []byte(p.Subject)) &
+		subtle.ConstantTimeCompare([]byte(purpose), []byte(p.Purpose))
+	if ok != 1 {
+		return ErrWrongCode
+	}
+	return m.Attempts.Exhaust(p.ID)
+}
+
+func (m *Manager) digits() int {
+	if m.Digits > 0 {
+		return m.Digits
+	}
+	return 6
+}
+
+func (m *Manager) maxAttempts() int {
+	if m.MaxAttempts > 0 {
+		return m.MaxAttempts
+	}
+	return 5
+}
+
+// randomCode returns a uniformly random code of the given number of digits.
+func randomCode(digits int) (string, error) {
+	limit := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(digits)), nil)
+	n, err := rand.Int(rand.Reader, limit)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*s", digits, n.String()), nil
+}
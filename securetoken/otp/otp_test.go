@@ -0,0 +1,69 @@
+package otp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nicksnyder/go-securetoken/securetoken"
+	"github.com/nicksnyder/go-securetoken/securetoken/securetokentest"
+)
+
+func newManager(t *testing.T) *Manager {
+	tok := securetokentest.NewTokener(t, securetoken.WithPurpose(Purpose))
+	return &Manager{Tokener: tok, Attempts: NewMemoryAttemptStore(time.Hour), MaxAttempts: 3}
+}
+
+func TestVerify(t *testing.T) {
+	m := newManager(t)
+	code, token, err := m.Issue("alice@example.com", "verify-email")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(code) != 6 {
+		t.Errorf("Issue() returned code %q; expected 6 digits", code)
+	}
+	if err := m.Verify(token, "bob@example.com", "verify-email", code); err != ErrWrongCode {
+		t.Errorf("Verify() with wrong subject returned %v; expected %s", err, ErrWrongCode)
+	}
+	if err := m.Verify(token, "alice@example.com", "reset-password", code); err != ErrWrongCode {
+		t.Errorf("Verify() with wrong purpose returned %v; expected %s", err, ErrWrongCode)
+	}
+	if err := m.Verify(token, "alice@example.com", "verify-email", code); err != nil {
+		t.Errorf("Verify() returned %v; expected <nil>", err)
+	}
+	if err := m.Verify(token, "alice@example.com", "verify-email", code); err != ErrTooManyAttempts {
+		t.Errorf("second Verify() returned %v; expected %s", err, ErrTooManyAttempts)
+	}
+}
+
+func TestVerifyAttemptLimit(t *testing.T) {
+	m := newManager(t)
+	code, token, err := m.Issue("alice", "login")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrong := "000000"
+	if code == wrong {
+		wrong = "000001"
+	}
+	for i := 0; i < 3; i++ {
+		if err := m.Verify(token, "alice", "login", wrong); err != ErrWrongCode {
+			t.Fatalf("Verify() attempt %d returned %v; expected %s", i+1, err, ErrWrongCode)
+		}
+	}
+	if err := m.Verify(token, "alice", "login", code); err != ErrTooManyAttempts {
+		t.Errorf("Verify() after limit returned %v; expected %s", err, ErrTooManyAttempts)
+	}
+}
+
+func TestRandomCode(t *testing.T) {
+	for _, digits := range []int{1, 6, 10} {
+		code, err := randomCode(digits)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(code) != digits {
+			t.Errorf("randomCode(%d) = %q; expected %d digits", digits, code, digits)
+		}
+	}
+}
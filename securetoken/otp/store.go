@@ -0,0 +1,67 @@
+package otp
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// A MemoryAttemptStore is an AttemptStore that keeps counts in memory.
+// It is goroutine safe.
+type MemoryAttemptStore struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu     sync.Mutex
+	counts map[string]*attempts
+}
+
+type attempts struct {
+	n       int
+	expires time.Time
+}
+
+// NewMemoryAttemptStore returns an empty MemoryAttemptStore that forgets
+// a token ttl after its first attempt. ttl should be at least the ttl
+// of the Tokener of the Manager.
+func NewMemoryAttemptStore(ttl time.Duration) *MemoryAttemptStore {
+	return &MemoryAttemptStore{ttl: ttl, now: time.Now, counts: make(map[string]*attempts)}
+}
+
+// Attempt implements AttemptStore.
+func (s *MemoryAttemptStore) Attempt(id string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a := s.get(id)
+	if a.n < math.MaxInt {
+		a.n++
+	}
+	return a.n, nil
+}
+
+// Exhaust implements AttemptStore.
+func (s *MemoryAttemptStore) Exhaust(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.get(id).n = math.MaxInt
+	return nil
+}
+
+// get returns the attempts of id, pruning expired entries.
+// s.mu must be held.
+func (s *MemoryAttemptStore) get(id string) *attempts {
+	now := s.now()
+	if a, ok := s.counts[id]; ok && now.Before(a.expires) {
+		return a
+	}
+	if len(s.counts) >= 10000 {
+		for id, a := range s.counts {
+			if !now.Before(a.expires) {
+				delete(s.counts, id)
+			}
+		}
+	}
+	a := &attempts{expires: now.Add(s.ttl)}
+	s.counts[id] = a
+	return a
+}
@@ -0,0 +1,60 @@
+package securetoken
+
+import "fmt"
+
+// paddedMarker is appended to the purpose in the additional data of padded
+// tokens, so that they only unseal with a Tokener that removes the padding.
+const paddedMarker = "\x00padded"
+
+// maxPadding is the largest bucket that WithPadding accepts.
+const maxPadding = 1 << 16
+
+// WithPadding returns an Option that pads plaintexts to a multiple of
+// bucket bytes before sealing them, e.g. 64, so that the length of a token
+// does not reveal which of several payloads, such as admin or user claims,
+// it carries. Unseal removes the padding. A plaintext is padded with 0x80
+// followed by zeros (ISO/IEC 7816-4), so it always grows by at least a byte.
+//
+// Padded tokens are marked in their additional data, so only Tokeners that
+// use the option unseal them, and those Tokeners do not unseal tokens sealed
+// without it. Enable it on every Tokener at once, as when changing purpose.
+func WithPadding(bucket int) Option {
+	return func(t *Tokener) error {
+		if bucket <= 0 || bucket > maxPadding {
+			return fmt.Errorf("securetoken: padding bucket %d is not between 1 and %d", bucket, maxPadding)
+		}
+		t.padding = bucket
+		return nil
+	}
+}
+
+// pad returns plaintext padded to a multiple of the padding of t,
+// or plaintext itself if t does not pad.
+func (t *Tokener) pad(plaintext []byte) []byte {
+	if t.padding == 0 {
+		return plaintext
+	}
+	n := (len(plaintext)/t.padding + 1) * t.padding
+	padded := make([]byte, n)
+	copy(padded, plaintext)
+	padded[len(plaintext)] = 0x80
+	return padded
+}
+
+// unpad removes the padding from the bytes of b after start,
+// if t pads. It reports false if the padding is malformed.
+func (t *Tokener) unpad(b []byte, start int) ([]byte, bool) {
+	if t.padding == 0 {
+		return b, true
+	}
+	for i := len(b) - 1; i >= start; i-- {
+		switch b[i] {
+		case 0:
+			continue
+		case 0x80:
+			return b[:i], true
+		}
+		return nil, false
+	}
+	return nil, false
+}
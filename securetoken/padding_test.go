@@ -0,0 +1,59 @@
+package securetoken
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPadding(t *testing.T) {
+	tok, err := NewTokener(key, ttl, WithPadding(64))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lengths := map[int]bool{}
+	for _, data := range []string{"", "user", strings.Repeat("a", 62), "\x80\x00", "admin with a somewhat longer payload"} {
+		sealed, err := tok.Seal([]byte(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		lengths[len(sealed)] = true
+		if unsealed, err := tok.Unseal(sealed); string(unsealed) != data || err != nil {
+			t.Errorf("Unseal(Seal(%q)) = %q, %v; expected %q, <nil>", data, unsealed, err, data)
+		}
+		appended, err := tok.AppendSeal([]byte("prefix"), []byte(data), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out, err := tok.AppendUnseal([]byte("\x80"), appended[len("prefix"):]); string(out) != "\x80"+data || err != nil {
+			t.Errorf("AppendUnseal(AppendSeal(%q)) = %q, %v; expected %q, <nil>", data, out, err, "\x80"+data)
+		}
+	}
+	if len(lengths) != 1 {
+		t.Errorf("payloads up to 63 bytes sealed to %d different lengths; expected 1", len(lengths))
+	}
+
+	plain, err := NewTokener(key, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	padded, err := tok.Seal([]byte("data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := plain.Unseal(padded); err != ErrTokenInvalid {
+		t.Errorf("Unseal() of a padded token without WithPadding returned %v; expected %s", err, ErrTokenInvalid)
+	}
+	unpadded, err := plain.Seal([]byte("data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tok.Unseal(unpadded); err != ErrTokenInvalid {
+		t.Errorf("Unseal() of an unpadded token with WithPadding returned %v; expected %s", err, ErrTokenInvalid)
+	}
+
+	for _, bucket := range []int{0, -1, maxPadding + 1} {
+		if _, err := NewTokener(key, ttl, WithPadding(bucket)); err == nil {
+			t.Errorf("NewTokener(WithPadding(%d)) returned nil error", bucket)
+		}
+	}
+}
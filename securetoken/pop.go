@@ -0,0 +1,92 @@
+package securetoken
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+)
+
+// ErrProofInvalid is returned by VerifyProof when the client did not prove
+// possession of the key that the token is bound to.
+var ErrProofInvalid = errors.New("securetoken: proof of possession invalid")
+
+// A Confirmation identifies the key that a token is bound to.
+type Confirmation struct {
+	// KeyThumbprint is the Thumbprint of the public key of the client.
+	KeyThumbprint string `json:"jkt"`
+}
+
+// Thumbprint returns the base64url encoded SHA-256 hash of the PKIX encoding
+// of pub, which must be an *ecdsa.PublicKey or ed25519.PublicKey.
+func Thumbprint(pub crypto.PublicKey) (string, error) {
+	switch pub.(type) {
+	case *ecdsa.PublicKey, ed25519.PublicKey:
+	default:
+		return "", errors.New("securetoken: unsupported proof of possession key type")
+	}
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(der)
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// BindKey binds c to pub, so that the token is only accepted by VerifyProof
+// together with a proof signed by the private key of pub.
+func (c *Claims) BindKey(pub crypto.PublicKey) error {
+	jkt, err := Thumbprint(pub)
+	if err != nil {
+		return err
+	}
+	c.Confirmation = &Confirmation{KeyThumbprint: jkt}
+	return nil
+}
+
+// ProofMessage returns the message that a client signs to prove possession
+// of its key when it sends token with a request: a SHA-256 hash of the token,
+// the request method and target, and a challenge from the server
+// (or another value that the server only accepts once, such as a timestamp).
+// Binding the proof to the request means a captured proof cannot be
+// replayed with a different request.
+func ProofMessage(token []byte, method, target, challenge string) []byte {
+	h := sha256.New()
+	for _, field := range [][]byte{token, []byte(method), []byte(target), []byte(challenge)} {
+		var n [8]byte
+		binary.BigEndian.PutUint64(n[:], uint64(len(field)))
+		h.Write(n[:])
+		h.Write(field)
+	}
+	return h.Sum(nil)
+}
+
+// VerifyProof returns ErrProofInvalid unless c is bound to pub and
+// signature is a signature of message by the private key of pub:
+// Ed25519 over message, or ECDSA (ASN.1) over the SHA-256 hash of message.
+func VerifyProof(c *Claims, pub crypto.PublicKey, message, signature []byte) error {
+	if c.Confirmation == nil {
+		return ErrProofInvalid
+	}
+	jkt, err := Thumbprint(pub)
+	if err != nil || subtle.ConstantTimeCompare([]byte(jkt), []byte(c.Confirmation.KeyThumbprint)) != 1 {
+		return ErrProofInvalid
+	}
+	var ok bool
+	switch pub := pub.(type) {
+	case ed25519.PublicKey:
+		ok = ed25519.Verify(pub, message, signature)
+	case *ecdsa.PublicKey:
+		digest := sha256.Sum256(message)
+		ok = ecdsa.VerifyASN1(pub, digest[:], signature)
+	}
+	if !ok {
+		return ErrProofInvalid
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package securetoken
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"testing"
+)
+
+func TestVerifyProof(t *testing.T) {
+	tok, err := NewTokener(key, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPub, edPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecPriv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := &Claims{Subject: "alice"}
+	if err := c.BindKey(edPub); err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := tok.SealClaims(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err = tok.UnsealClaims(sealed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := ProofMessage(sealed, "POST", "/orders", "challenge-1")
+	if err := VerifyProof(c, edPub, msg, ed25519.Sign(edPriv, msg)); err != nil {
+		t.Errorf("VerifyProof() with Ed25519 returned %v; expected <nil>", err)
+	}
+	replayed := ProofMessage(sealed, "POST", "/admin", "challenge-1")
+	if err := VerifyProof(c, edPub, replayed, ed25519.Sign(edPriv, msg)); err != ErrProofInvalid {
+		t.Errorf("VerifyProof() for another request returned %v; expected %s", err, ErrProofInvalid)
+	}
+	if err := VerifyProof(c, otherPub, msg, ed25519.Sign(edPriv, msg)); err != ErrProofInvalid {
+		t.Errorf("VerifyProof() with another key returned %v; expected %s", err, ErrProofInvalid)
+	}
+	if err := VerifyProof(&Claims{}, edPub, msg, ed25519.Sign(edPriv, msg)); err != ErrProofInvalid {
+		t.Errorf("VerifyProof() of an unbound token returned %v; expected %s", err, ErrProofInvalid)
+	}
+
+	ec := &Claims{}
+	if err := ec.BindKey(&ecPriv.PublicKey); err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256.Sum256(msg)
+	sig, err := ecdsa.SignASN1(rand.Reader, ecPriv, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyProof(ec, &ecPriv.PublicKey, msg, sig); err != nil {
+		t.Errorf("VerifyProof() with ECDSA returned %v; expected <nil>", err)
+	}
+}
@@ -0,0 +1,158 @@
+package securetoken
+
+import (
+	"errors"
+	"time"
+)
+
+var errEmptyPredicate = errors.New("securetoken: revocation predicate matches every token")
+
+// A RevocationPredicate matches the tokens to revoke by their claims, e.g.
+// every token of a subject, or every token with a scope issued in a window,
+// for incident response. Every field that is set must match.
+type RevocationPredicate struct {
+	// Subject, if not empty, matches tokens of the subject.
+	Subject string
+
+	// Scope, if not empty, matches tokens that have the scope.
+	Scope string
+
+	// IssuedAfter and IssuedBefore, if not zero, match tokens
+	// sealed after and before them.
+	IssuedAfter  time.Time
+	IssuedBefore time.Time
+
+	// Until is when every matching token has expired,
+	// after which the predicate may be forgotten.
+	Until time.Time
+}
+
+// Matches reports whether p matches the token with claims c,
+// which have IssuedAt set.
+func (p *RevocationPredicate) Matches(c *Claims) bool {
+	if p.Subject != "" && c.Subject != p.Subject {
+		return false
+	}
+	if p.Scope != "" && !c.HasScope(p.Scope) {
+		return false
+	}
+	if !p.IssuedAfter.IsZero() && !c.IssuedAt.After(p.IssuedAfter) {
+		return false
+	}
+	if !p.IssuedBefore.IsZero() && !c.IssuedAt.Before(p.IssuedBefore) {
+		return false
+	}
+	return true
+}
+
+// validate returns an error if p matches every token,
+// which should be done by rotating keys instead.
+func (p *RevocationPredicate) validate() error {
+	if p.Subject == "" && p.Scope == "" && p.IssuedAfter.IsZero() && p.IssuedBefore.IsZero() {
+		return errEmptyPredicate
+	}
+	return nil
+}
+
+// A PredicateRevocationStore is a RevocationStore that also revokes
+// tokens by predicate. UnsealClaims checks the predicates of the
+// RevocationStore of a Tokener if it implements this interface.
+// Implementations must be goroutine safe.
+type PredicateRevocationStore interface {
+	RevocationStore
+
+	// RevokeMatching revokes every token that p matches.
+	RevokeMatching(p RevocationPredicate) error
+
+	// RevokedMatching reports whether a predicate matches the token with
+	// claims c. Implementations should index predicates, e.g. by subject
+	// and scope, rather than evaluate every predicate for every token.
+	RevokedMatching(c *Claims) (bool, error)
+}
+
+// checkRevokedMatching returns ErrTokenRevoked if a predicate of the
+// revocation store of t matches c.
+func (t *Tokener) checkRevokedMatching(c *Claims) error {
+	s, ok := t.revoked.(PredicateRevocationStore)
+	if !ok {
+		return nil
+	}
+	revoked, err := s.RevokedMatching(c)
+	if err != nil {
+		return err
+	}
+	if revoked {
+		return ErrTokenRevoked
+	}
+	return nil
+}
+
+// predicateIndex indexes revocation predicates by subject and scope.
+// It is not goroutine safe.
+type predicateIndex struct {
+	bySubject map[string][]RevocationPredicate
+	byScope   map[string][]RevocationPredicate
+	other     []RevocationPredicate // predicates with neither
+	n         int
+}
+
+func (x *predicateIndex) add(p RevocationPredicate) {
+	switch {
+	case p.Subject != "":
+		if x.bySubject == nil {
+			x.bySubject = make(map[string][]RevocationPredicate)
+		}
+		x.bySubject[p.Subject] = append(x.bySubject[p.Subject], p)
+	case p.Scope != "":
+		if x.byScope == nil {
+			x.byScope = make(map[string][]RevocationPredicate)
+		}
+		x.byScope[p.Scope] = append(x.byScope[p.Scope], p)
+	default:
+		x.other = append(x.other, p)
+	}
+	x.n++
+}
+
+// matches reports whether a predicate of x matches c.
+func (x *predicateIndex) matches(c *Claims) bool {
+	if x.n == 0 {
+		return false
+	}
+	if anyMatches(x.bySubject[c.Subject], c) || anyMatches(x.other, c) {
+		return true
+	}
+	for _, scope := range c.Scopes {
+		if anyMatches(x.byScope[scope], c) {
+			return true
+		}
+	}
+	return false
+}
+
+func anyMatches(ps []RevocationPredicate, c *Claims) bool {
+	for i := range ps {
+		if ps[i].Matches(c) {
+			return true
+		}
+	}
+	return false
+}
+
+// prune forgets the predicates that have expired at now.
+func (x *predicateIndex) prune(now time.Time) {
+	all := make([]RevocationPredicate, 0, x.n)
+	for _, ps := range x.bySubject {
+		all = append(all, ps...)
+	}
+	for _, ps := range x.byScope {
+		all = append(all, ps...)
+	}
+	all = append(all, x.other...)
+	*x = predicateIndex{}
+	for _, p := range all {
+		if !now.After(p.Until) {
+			x.add(p)
+		}
+	}
+}
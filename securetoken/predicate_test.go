@@ -0,0 +1,77 @@
+package securetoken
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRevokeMatching(t *testing.T) {
+	setNow(time.Unix(1000, 0))
+	defer restoreNow()
+
+	store := NewMemoryRevocationStore()
+	tok, err := NewTokener(key, ttl, WithRevocationStore(store))
+	if err != nil {
+		t.Fatal(err)
+	}
+	seal := func(c *Claims) []byte {
+		sealed, err := tok.SealClaims(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sealed
+	}
+	alice := seal(&Claims{Subject: "alice"})
+	bob := seal(&Claims{Subject: "bob", Scopes: []string{"admin"}})
+	setNow(time.Unix(1010, 0))
+	carol := seal(&Claims{Subject: "carol", Scopes: []string{"admin"}})
+	dave := seal(&Claims{Subject: "dave", Scopes: []string{"read"}})
+
+	until := time.Unix(1000, 0).Add(ttl)
+	if err := store.RevokeMatching(RevocationPredicate{Subject: "alice", Until: until}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.RevokeMatching(RevocationPredicate{Scope: "admin", IssuedAfter: time.Unix(1005, 0), Until: until}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		sealed []byte
+		err    error
+	}{
+		{"alice", alice, ErrTokenRevoked},
+		{"bob", bob, nil},
+		{"carol", carol, ErrTokenRevoked},
+		{"dave", dave, nil},
+	}
+	for _, test := range tests {
+		if _, err := tok.UnsealClaims(test.sealed); err != test.err {
+			t.Errorf("UnsealClaims(%s) returned %v; expected %v", test.name, err, test.err)
+		}
+	}
+
+	if err := store.RevokeMatching(RevocationPredicate{Until: until}); err == nil {
+		t.Error("RevokeMatching() of a predicate that matches every token returned nil error")
+	}
+}
+
+func TestRevocationPredicateMatches(t *testing.T) {
+	c := &Claims{Subject: "alice", Scopes: []string{"read"}, IssuedAt: time.Unix(1000, 0)}
+	tests := []struct {
+		p       RevocationPredicate
+		matches bool
+	}{
+		{RevocationPredicate{Subject: "alice"}, true},
+		{RevocationPredicate{Subject: "bob"}, false},
+		{RevocationPredicate{Subject: "alice", Scope: "write"}, false},
+		{RevocationPredicate{Scope: "read", IssuedBefore: time.Unix(1001, 0)}, true},
+		{RevocationPredicate{Scope: "read", IssuedBefore: time.Unix(1000, 0)}, false},
+		{RevocationPredicate{IssuedAfter: time.Unix(999, 0), IssuedBefore: time.Unix(1001, 0)}, true},
+	}
+	for _, test := range tests {
+		if matches := test.p.Matches(c); matches != test.matches {
+			t.Errorf("%+v.Matches(%+v) = %t; expected %t", test.p, c, matches, test.matches)
+		}
+	}
+}
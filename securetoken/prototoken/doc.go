@@ -0,0 +1,13 @@
+// Package prototoken seals protocol buffer messages instead of JSON.
+//
+// It depends on google.golang.org/protobuf and is only built with the
+// protobuf build tag, so that the rest of securetoken does not:
+//
+//	go build -tags protobuf
+//
+// A Codec marshals messages with proto.Marshal before sealing them.
+// With WrapAny, messages are wrapped in an anypb.Any first, so that
+// UnsealAny can return a message of the type it was sealed as, resolved
+// by Resolver (by default the global registry). A Resolver can restrict
+// which types are accepted or look them up in a schema registry.
+package prototoken
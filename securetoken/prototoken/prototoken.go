@@ -0,0 +1,99 @@
+//go:build protobuf
+
+package prototoken
+
+import (
+	"errors"
+
+	"github.com/nicksnyder/go-securetoken/securetoken"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// ErrNotAny is returned by UnsealAny when the Codec does not wrap messages in Any.
+var ErrNotAny = errors.New("prototoken: codec does not wrap messages in Any")
+
+// A Codec seals and unseals protocol buffer messages.
+type Codec struct {
+	// Tokener seals and unseals the marshaled messages.
+	Tokener securetoken.SealUnsealer
+
+	// WrapAny wraps messages in an anypb.Any before sealing them.
+	WrapAny bool
+
+	// Resolver resolves the type URLs of Any messages.
+	// If it is nil, protoregistry.GlobalTypes is used.
+	Resolver interface {
+		protoregistry.MessageTypeResolver
+		protoregistry.ExtensionTypeResolver
+	}
+}
+
+// Seal marshals and seals m.
+func (c *Codec) Seal(m proto.Message) ([]byte, error) {
+	if c.WrapAny {
+		a, err := anypb.New(m)
+		if err != nil {
+			return nil, err
+		}
+		m = a
+	}
+	buf, err := proto.MarshalOptions{Deterministic: true}.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return c.Tokener.Seal(buf)
+}
+
+// Unseal unseals a token sealed by Seal into m, which must be of the type
+// that was sealed.
+func (c *Codec) Unseal(sealed []byte, m proto.Message) error {
+	if !c.WrapAny {
+		buf, err := c.Tokener.Unseal(sealed)
+		if err != nil {
+			return err
+		}
+		return c.unmarshal(buf, m)
+	}
+	a := &anypb.Any{}
+	if err := c.unsealAny(sealed, a); err != nil {
+		return err
+	}
+	return anypb.UnmarshalTo(a, m, c.unmarshalOptions())
+}
+
+// UnsealAny unseals a token sealed by a Codec with WrapAny and returns a
+// message of the type that was sealed.
+func (c *Codec) UnsealAny(sealed []byte) (proto.Message, error) {
+	if !c.WrapAny {
+		return nil, ErrNotAny
+	}
+	a := &anypb.Any{}
+	if err := c.unsealAny(sealed, a); err != nil {
+		return nil, err
+	}
+	return anypb.UnmarshalNew(a, c.unmarshalOptions())
+}
+
+func (c *Codec) unsealAny(sealed []byte, a *anypb.Any) error {
+	buf, err := c.Tokener.Unseal(sealed)
+	if err != nil {
+		return err
+	}
+	return c.unmarshal(buf, a)
+}
+
+func (c *Codec) unmarshal(buf []byte, m proto.Message) error {
+	if err := c.unmarshalOptions().Unmarshal(buf, m); err != nil {
+		return securetoken.ErrTokenInvalid
+	}
+	return nil
+}
+
+func (c *Codec) unmarshalOptions() proto.UnmarshalOptions {
+	if c.Resolver == nil {
+		return proto.UnmarshalOptions{}
+	}
+	return proto.UnmarshalOptions{Resolver: c.Resolver}
+}
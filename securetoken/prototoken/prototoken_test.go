@@ -0,0 +1,73 @@
+//go:build protobuf
+
+package prototoken
+
+import (
+	"testing"
+
+	"github.com/nicksnyder/go-securetoken/securetoken/securetokentest"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestRoundTrip(t *testing.T) {
+	c := &Codec{Tokener: securetokentest.NewTokener(t)}
+	sealed, err := c.Seal(wrapperspb.String("alice"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := &wrapperspb.StringValue{}
+	if err := c.Unseal(sealed, got); err != nil || got.GetValue() != "alice" {
+		t.Errorf("Unseal() = %v, %v; expected \"alice\", <nil>", got, err)
+	}
+	if _, err := c.UnsealAny(sealed); err != ErrNotAny {
+		t.Errorf("UnsealAny() without WrapAny returned %v; expected %s", err, ErrNotAny)
+	}
+}
+
+func TestAnyRoundTrip(t *testing.T) {
+	c := &Codec{Tokener: securetokentest.NewTokener(t), WrapAny: true}
+	want := durationpb.New(90)
+	sealed, err := c.Seal(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := c.UnsealAny(sealed)
+	if err != nil || !proto.Equal(m, want) {
+		t.Errorf("UnsealAny() = %v, %v; expected %v, <nil>", m, err, want)
+	}
+	got := &durationpb.Duration{}
+	if err := c.Unseal(sealed, got); err != nil || !proto.Equal(got, want) {
+		t.Errorf("Unseal() = %v, %v; expected %v, <nil>", got, err, want)
+	}
+	if err := c.Unseal(sealed, &wrapperspb.StringValue{}); err == nil {
+		t.Error("Unseal() into another type returned no error")
+	}
+}
+
+func TestResolverRejectsUnknownTypes(t *testing.T) {
+	tok := securetokentest.NewTokener(t)
+	types := new(protoregistry.Types)
+	if err := types.RegisterMessage((&wrapperspb.StringValue{}).ProtoReflect().Type()); err != nil {
+		t.Fatal(err)
+	}
+	c := &Codec{Tokener: tok, WrapAny: true, Resolver: types}
+
+	sealed, err := c.Seal(wrapperspb.String("alice"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m, err := c.UnsealAny(sealed); err != nil || m.(*wrapperspb.StringValue).GetValue() != "alice" {
+		t.Errorf("UnsealAny() of a registered type = %v, %v; expected \"alice\", <nil>", m, err)
+	}
+
+	sealed, err = c.Seal(durationpb.New(90))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m, err := c.UnsealAny(sealed); err == nil {
+		t.Errorf("UnsealAny() of an unregistered type = %v; expected an error", m)
+	}
+}
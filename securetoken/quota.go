@@ -0,0 +1,92 @@
+package securetoken
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrQuotaExceeded is returned by Seal when the IssuanceQuota of the Tokener
+// does not allow another token to be sealed yet.
+var ErrQuotaExceeded = errors.New("securetoken: issuance quota exceeded")
+
+// An IssuanceQuota limits the rate at which tokens are sealed with token
+// buckets, both per purpose (see WithPurpose) and per key, so that a
+// compromised service can not silently mint tokens in bulk.
+// Rates are per second, so a quota of 600 tokens a minute has a Rate of 10.
+// It may be shared by several Tokeners, such as clones with different purposes.
+// It is goroutine safe.
+type IssuanceQuota struct {
+	perPurpose RateLimit
+	perKey     RateLimit
+	breach     func(purpose string, id uint32)
+
+	mu       sync.Mutex
+	limits   map[string]RateLimit
+	purposes map[string]*bucket
+	keys     map[uint32]*bucket
+}
+
+// NewIssuanceQuota returns an IssuanceQuota that enforces perPurpose on the
+// tokens sealed for each purpose and perKey on the tokens sealed with each key.
+// A RateLimit with a Burst of 0 allows unlimited seals.
+// breach, if not nil, is called with the purpose and key id of every seal
+// that is refused, e.g. to alert.
+func NewIssuanceQuota(perPurpose, perKey RateLimit, breach func(purpose string, id uint32)) *IssuanceQuota {
+	return &IssuanceQuota{
+		perPurpose: perPurpose,
+		perKey:     perKey,
+		breach:     breach,
+		limits:     make(map[string]RateLimit),
+		purposes:   make(map[string]*bucket),
+		keys:       make(map[uint32]*bucket),
+	}
+}
+
+// SetPurposeLimit replaces the per purpose limit for purpose with limit.
+func (q *IssuanceQuota) SetPurposeLimit(purpose string, limit RateLimit) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.limits[purpose] = limit
+	delete(q.purposes, purpose)
+}
+
+// WithIssuanceQuota returns an Option that makes Seal return
+// ErrQuotaExceeded when q does not allow another token.
+func WithIssuanceQuota(q *IssuanceQuota) Option {
+	return func(t *Tokener) error {
+		t.quota = q
+		return nil
+	}
+}
+
+// take reports whether a token may be sealed for purpose with the key
+// with the given id at now, and counts it if so.
+func (q *IssuanceQuota) take(purpose string, id uint32, now time.Time) bool {
+	q.mu.Lock()
+	pl, ok := q.limits[purpose]
+	if !ok {
+		pl = q.perPurpose
+	}
+	pb, ok := q.purposes[purpose]
+	if !ok {
+		pb = &bucket{tokens: float64(pl.Burst)}
+		q.purposes[purpose] = pb
+	}
+	kb, ok := q.keys[id]
+	if !ok {
+		kb = &bucket{tokens: float64(q.perKey.Burst)}
+		q.keys[id] = kb
+	}
+	allowed := (pl.Burst == 0 || pb.refill(pl, now) >= 1) &&
+		(q.perKey.Burst == 0 || kb.refill(q.perKey, now) >= 1)
+	if allowed {
+		pb.tokens--
+		kb.tokens--
+	}
+	q.mu.Unlock()
+	if !allowed && q.breach != nil {
+		q.breach(purpose, id)
+	}
+	return allowed
+}
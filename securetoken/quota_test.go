@@ -0,0 +1,85 @@
+package securetoken
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIssuanceQuota(t *testing.T) {
+	now := time.Unix(1000, 0)
+	setNow(now)
+	defer restoreNow()
+
+	type breach struct {
+		purpose string
+		id      uint32
+	}
+	var breaches []breach
+	q := NewIssuanceQuota(RateLimit{Rate: 1, Burst: 2}, RateLimit{}, func(purpose string, id uint32) {
+		breaches = append(breaches, breach{purpose, id})
+	})
+	q.SetPurposeLimit("bulk", RateLimit{})
+	session, err := NewTokener(key, ttl, WithPurpose("session"), WithIssuanceQuota(q))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bulk, err := session.Clone(WithPurpose("bulk"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := session.Seal([]byte("data")); err != nil {
+			t.Fatalf("Seal() %d returned %v; expected <nil>", i, err)
+		}
+	}
+	if sealed, err := session.Seal([]byte("data")); sealed != nil || err != ErrQuotaExceeded {
+		t.Errorf("Seal() over quota = %q, %v; expected <nil>, %s", sealed, err, ErrQuotaExceeded)
+	}
+	if len(breaches) != 1 || breaches[0] != (breach{"session", 0}) {
+		t.Errorf("breach called with %+v; expected one call for session and key 0", breaches)
+	}
+	for i := 0; i < 10; i++ {
+		if _, err := bulk.Seal([]byte("data")); err != nil {
+			t.Fatalf("Seal() %d for an unlimited purpose returned %v; expected <nil>", i, err)
+		}
+	}
+
+	setNow(now.Add(time.Second))
+	if _, err := session.Seal([]byte("data")); err != nil {
+		t.Errorf("Seal() after a second returned %v; expected <nil>", err)
+	}
+	if _, err := session.Seal([]byte("data")); err != ErrQuotaExceeded {
+		t.Errorf("Seal() over quota returned %v; expected %s", err, ErrQuotaExceeded)
+	}
+}
+
+func TestIssuanceQuotaPerKey(t *testing.T) {
+	setNow(time.Unix(1000, 0))
+	defer restoreNow()
+
+	kr := NewKeyring()
+	if err := kr.AddKey(1, key); err != nil {
+		t.Fatal(err)
+	}
+	if err := kr.AddKey(2, key2); err != nil {
+		t.Fatal(err)
+	}
+	q := NewIssuanceQuota(RateLimit{}, RateLimit{Rate: 1, Burst: 1}, nil)
+	tok, err := NewKeyringTokener(kr, ttl, WithIssuanceQuota(q))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tok.Seal([]byte("data")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tok.Seal([]byte("data")); err != ErrQuotaExceeded {
+		t.Errorf("Seal() over the quota of key 1 returned %v; expected %s", err, ErrQuotaExceeded)
+	}
+	if err := kr.SetPrimary(2); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tok.Seal([]byte("data")); err != nil {
+		t.Errorf("Seal() with key 2 returned %v; expected <nil>", err)
+	}
+}
@@ -0,0 +1,110 @@
+package securetoken
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrRateLimited is returned by Unseal when too many tokens have recently
+// failed to unseal. The token is not examined.
+var ErrRateLimited = errors.New("securetoken: rate limited")
+
+// maxCallerBuckets is the number of per caller buckets
+// above which refilled buckets are discarded.
+const maxCallerBuckets = 10000
+
+// A RateLimit allows Burst failures at once,
+// after which failures are allowed at Rate per second.
+// A RateLimit with a Burst of 0 allows unlimited failures.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// A FailureLimiter limits the rate of unseal failures with token buckets,
+// both in total and per caller (e.g. per IP address).
+// Once a bucket is empty, Unseal returns ErrRateLimited without
+// examining tokens until the bucket refills.
+// It is goroutine safe.
+type FailureLimiter struct {
+	global    RateLimit
+	perCaller RateLimit
+
+	mu      sync.Mutex
+	all     bucket
+	callers map[string]*bucket
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewFailureLimiter returns a FailureLimiter that enforces global
+// on all failures and perCaller on the failures of each caller.
+func NewFailureLimiter(global, perCaller RateLimit) *FailureLimiter {
+	return &FailureLimiter{
+		global:    global,
+		perCaller: perCaller,
+		all:       bucket{tokens: float64(global.Burst)},
+		callers:   make(map[string]*bucket),
+	}
+}
+
+// allow reports whether caller may attempt an unseal at now.
+func (l *FailureLimiter) allow(caller string, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.global.Burst > 0 && l.all.refill(l.global, now) < 1 {
+		return false
+	}
+	if b, ok := l.callers[caller]; ok && l.perCaller.Burst > 0 && b.refill(l.perCaller, now) < 1 {
+		return false
+	}
+	return true
+}
+
+// fail records a failure by caller at now.
+func (l *FailureLimiter) fail(caller string, now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.global.Burst > 0 {
+		l.all.refill(l.global, now)
+		l.all.tokens--
+	}
+	if l.perCaller.Burst > 0 && caller != "" {
+		b, ok := l.callers[caller]
+		if !ok {
+			if len(l.callers) >= maxCallerBuckets {
+				l.prune(now)
+			}
+			b = &bucket{tokens: float64(l.perCaller.Burst), last: now}
+			l.callers[caller] = b
+		}
+		b.refill(l.perCaller, now)
+		b.tokens--
+	}
+}
+
+// prune discards the buckets of callers that have fully refilled.
+func (l *FailureLimiter) prune(now time.Time) {
+	for caller, b := range l.callers {
+		if b.refill(l.perCaller, now) >= float64(l.perCaller.Burst) {
+			delete(l.callers, caller)
+		}
+	}
+}
+
+// refill adds the tokens accumulated since the last refill and
+// returns the number of tokens in the bucket.
+func (b *bucket) refill(limit RateLimit, now time.Time) float64 {
+	if !b.last.IsZero() && now.After(b.last) {
+		b.tokens += now.Sub(b.last).Seconds() * limit.Rate
+		if b.tokens > float64(limit.Burst) {
+			b.tokens = float64(limit.Burst)
+		}
+	}
+	b.last = now
+	return b.tokens
+}
@@ -0,0 +1,44 @@
+package securetoken
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFailureLimiter(t *testing.T) {
+	setNow(time.Unix(1, 0))
+	defer restoreNow()
+
+	l := NewFailureLimiter(RateLimit{Rate: 1, Burst: 4}, RateLimit{Rate: 0.5, Burst: 2})
+	tok, err := NewTokener(key, ttl, WithFailureLimiter(l))
+	if err != nil {
+		t.Fatal(err)
+	}
+	valid, err := tok.Seal([]byte("data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	invalid := []byte("invalid")
+
+	expect := func(caller string, sealed []byte, expected error) {
+		t.Helper()
+		if _, err := tok.UnsealFor(caller, sealed); err != expected {
+			t.Errorf("UnsealFor(%q, %q) returned %v; expected %v", caller, sealed, err, expected)
+		}
+	}
+
+	expect("a", invalid, ErrTokenInvalid)
+	expect("a", invalid, ErrTokenInvalid)
+	expect("a", valid, ErrRateLimited)
+	expect("b", valid, nil)
+	expect("b", invalid, ErrTokenInvalid)
+	expect("c", invalid, ErrTokenInvalid)
+	expect("d", valid, ErrRateLimited)
+
+	setNow(timeNow().Add(1 * time.Second))
+	expect("d", valid, nil)
+	expect("a", valid, ErrRateLimited)
+
+	setNow(timeNow().Add(1 * time.Second))
+	expect("a", valid, nil)
+}
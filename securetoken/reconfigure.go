@@ -0,0 +1,104 @@
+package securetoken
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var errNotReconfigurable = errors.New("securetoken: only WithTokenTTL, WithSoftTTL, WithLeeway and WithMinVersion can be reconfigured")
+
+// A policy holds the settings of a Tokener that Reconfigure can change.
+type policy struct {
+	ttl        time.Duration
+	softTTL    time.Duration
+	leeway     time.Duration
+	minVersion uint8
+}
+
+// livePolicy holds the current policy of a Tokener.
+type livePolicy struct {
+	mu sync.Mutex // serializes Reconfigure
+	p  atomic.Pointer[policy]
+}
+
+// publishPolicy makes the policy fields of t its current policy.
+func (t *Tokener) publishPolicy() {
+	t.live = &livePolicy{}
+	t.live.p.Store(&policy{ttl: t.ttl, softTTL: t.softTTL, leeway: t.leeway, minVersion: t.minVersion})
+}
+
+// policy returns the current policy of t.
+func (t *Tokener) policy() *policy {
+	if t.live == nil {
+		return &policy{ttl: t.ttl, softTTL: t.softTTL, leeway: t.leeway, minVersion: t.minVersion}
+	}
+	return t.live.p.Load()
+}
+
+// TTL returns the current ttl of t (see WithTokenTTL).
+func (t *Tokener) TTL() time.Duration {
+	return t.policy().ttl
+}
+
+// Leeway returns the current leeway of t (see WithLeeway).
+func (t *Tokener) Leeway() time.Duration {
+	return t.policy().leeway
+}
+
+// WithTokenTTL returns an Option that sets the ttl of the Tokener,
+// e.g. to change it with Reconfigure.
+func WithTokenTTL(ttl time.Duration) Option {
+	return func(t *Tokener) error {
+		if ttl <= 0 {
+			return fmt.Errorf("securetoken: invalid ttl %s", ttl)
+		}
+		t.ttl = ttl
+		return nil
+	}
+}
+
+// WithLeeway returns an Option that accepts tokens for up to d after they
+// expire, to allow for clock skew between the servers that seal and unseal.
+func WithLeeway(d time.Duration) Option {
+	return func(t *Tokener) error {
+		if d < 0 {
+			return fmt.Errorf("securetoken: invalid leeway %s", d)
+		}
+		t.leeway = d
+		return nil
+	}
+}
+
+// Reconfigure changes the ttl, soft ttl, leeway and minimum version of t
+// while it is in use, e.g. when a configuration system reports a change.
+// Calls that are in flight finish with the previous settings, and later calls
+// use the new ones. Only WithTokenTTL, WithSoftTTL, WithLeeway and
+// WithMinVersion may be given; if any option fails or another option is given, t is unchanged.
+func (t *Tokener) Reconfigure(opts ...Option) error {
+	t.live.mu.Lock()
+	defer t.live.mu.Unlock()
+	p := t.policy()
+	scratch := &Tokener{keys: t.keys, version: t.version, ttl: p.ttl, softTTL: p.softTTL, leeway: p.leeway, minVersion: p.minVersion}
+	for _, opt := range opts {
+		if err := opt(scratch); err != nil {
+			return err
+		}
+	}
+	if err := scratch.checkMinVersion(); err != nil {
+		return err
+	}
+	rest := *scratch
+	rest.keys, rest.version, rest.ttl, rest.softTTL, rest.leeway, rest.minVersion = nil, 0, 0, 0, 0, 0
+	if !reflect.DeepEqual(rest, Tokener{}) {
+		return errNotReconfigurable
+	}
+	t.live.p.Store(&policy{ttl: scratch.ttl, softTTL: scratch.softTTL, leeway: scratch.leeway, minVersion: scratch.minVersion})
+	if t.logger != nil {
+		t.logger.Info("securetoken: tokener reconfigured", "tokener", t)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package securetoken
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestReconfigure(t *testing.T) {
+	setNow(time.Unix(1000, 0))
+	defer restoreNow()
+
+	kr := NewKeyring()
+	if err := kr.AddKey(0, key); err != nil {
+		t.Fatal(err)
+	}
+	tok, err := NewKeyringTokener(kr, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := tok.Seal([]byte("data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	clone := tok.WithTTL(ttl)
+
+	setNow(time.Unix(1000, 0).Add(ttl + time.Second))
+	if _, err := tok.Unseal(sealed); err != ErrTokenExpired {
+		t.Fatalf("Unseal(%q) returned %v; expected %s", sealed, err, ErrTokenExpired)
+	}
+	if err := tok.Reconfigure(WithLeeway(2 * time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if l := tok.Leeway(); l != 2*time.Second || tok.TTL() != ttl {
+		t.Errorf("Leeway(), TTL() = %s, %s; expected %s, %s", l, tok.TTL(), 2*time.Second, ttl)
+	}
+	if data, err := tok.Unseal(sealed); string(data) != "data" || err != nil {
+		t.Errorf("Unseal(%q) with leeway = %q, %v; expected %q, <nil>", sealed, data, err, "data")
+	}
+	if _, err := clone.Unseal(sealed); err != ErrTokenExpired {
+		t.Errorf("Unseal(%q) by a clone made before Reconfigure returned %v; expected %s", sealed, err, ErrTokenExpired)
+	}
+
+	if err := tok.Reconfigure(WithTokenTTL(ttl/2), WithMinVersion(Version2)); err != nil {
+		t.Fatal(err)
+	}
+	if p := tok.policy(); p.ttl != ttl/2 || p.leeway != 2*time.Second || p.minVersion != Version2 {
+		t.Errorf("policy() = %+v; expected ttl %s, leeway 2s and version %d", *p, ttl/2, Version2)
+	}
+
+	for _, opts := range [][]Option{
+		{WithTokenTTL(0)},
+		{WithLeeway(time.Second), WithPurpose("csrf")},
+		{WithMinVersion(3)},
+	} {
+		if err := tok.Reconfigure(opts...); err == nil {
+			t.Errorf("Reconfigure() with %d options returned <nil>; expected an error", len(opts))
+		}
+	}
+	if p := tok.policy(); p.ttl != ttl/2 || p.leeway != 2*time.Second {
+		t.Errorf("policy() after failed Reconfigure calls = %+v; expected it unchanged", *p)
+	}
+}
+
+func TestReconfigureConcurrent(t *testing.T) {
+	tok, err := NewTokener(key, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := tok.Seal([]byte("data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 1; i <= 100; i++ {
+			if err := tok.Reconfigure(WithTokenTTL(time.Duration(i) * time.Minute)); err != nil {
+				t.Error(err)
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			if _, err := tok.Unseal(sealed); err != nil {
+				t.Error(err)
+			}
+		}
+	}()
+	wg.Wait()
+}
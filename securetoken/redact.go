@@ -0,0 +1,73 @@
+package securetoken
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+)
+
+// String describes t without revealing any key material.
+func (t *Tokener) String() string {
+	return fmt.Sprintf("securetoken.Tokener{version: %d, ttl: %s, purpose: %q, keys: %s}",
+		t.version, t.policy().ttl, t.purpose, t.keys)
+}
+
+// GoString is the same as String, so that %#v does not reveal key material.
+func (t *Tokener) GoString() string {
+	return t.String()
+}
+
+// LogValue implements slog.LogValuer without revealing key material.
+func (t *Tokener) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Int("version", int(t.version)),
+		slog.Duration("ttl", t.policy().ttl),
+		slog.String("purpose", t.purpose),
+		slog.Any("keys", t.keys),
+	)
+}
+
+// String describes the key ids of k without revealing any key material.
+func (k *Keyring) String() string {
+	ids, primary, ok := k.ids()
+	if !ok {
+		return fmt.Sprintf("securetoken.Keyring{ids: %v}", ids)
+	}
+	return fmt.Sprintf("securetoken.Keyring{ids: %v, primary: %d}", ids, primary)
+}
+
+// GoString is the same as String, so that %#v does not reveal key material.
+func (k *Keyring) GoString() string {
+	return k.String()
+}
+
+// LogValue implements slog.LogValuer without revealing key material.
+func (k *Keyring) LogValue() slog.Value {
+	ids, primary, ok := k.ids()
+	attrs := []slog.Attr{slog.Any("ids", ids)}
+	if ok {
+		attrs = append(attrs, slog.Any("primary", primary))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// ids returns the sorted ids of the keys in k and the primary key id.
+func (k *Keyring) ids() (ids []uint32, primary uint32, ok bool) {
+	s := k.load()
+	ids = make([]uint32, 0, len(s.keys))
+	for id := range s.keys {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, s.primary, s.hasPrim
+}
+
+// GoString is the same as String, so that %#v does not reveal the token.
+func (tok Token) GoString() string {
+	return tok.String()
+}
+
+// LogValue implements slog.LogValuer without revealing the token.
+func (tok Token) LogValue() slog.Value {
+	return slog.StringValue(tok.String())
+}
@@ -0,0 +1,49 @@
+package securetoken
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRedacted(t *testing.T) {
+	master := []byte("0123456789abcdef0123456789abcdef")
+	kr, err := NewRekeyingKeyring(master, time.Hour, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rekeyed, err := NewKeyringTokener(kr, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tok, err := NewTokener(key, ttl, WithPurpose("session"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	secrets := [][]byte{key, master}
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	for _, v := range []interface{}{tok, rekeyed, kr, Token(key)} {
+		for _, format := range []string{"%v", "%+v", "%#v", "%s"} {
+			out := fmt.Sprintf(format, v)
+			for _, secret := range secrets {
+				if strings.Contains(out, string(secret)) {
+					t.Errorf("Sprintf(%q, %T) = %q; expected no key material", format, v, out)
+				}
+			}
+		}
+		logger.Info("value", "v", v)
+	}
+	for _, secret := range secrets {
+		if bytes.Contains(buf.Bytes(), secret) {
+			t.Errorf("slog output %q contains key material", buf.String())
+		}
+	}
+	if s := tok.String(); !strings.Contains(s, `purpose: "session"`) || !strings.Contains(s, "ids: [0]") {
+		t.Errorf("String() = %q; expected the purpose and key ids", s)
+	}
+}
@@ -0,0 +1,119 @@
+package securetoken
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+// rekeyer derives one key per period from a master key.
+type rekeyer struct {
+	master []byte
+	period time.Duration
+	retain uint32
+
+	// template is an AEAD of the same kind as the derived keys, which the
+	// keyring stands in for its primary key until the first seal.
+	template cipher.AEAD
+}
+
+// NewRekeyingKeyring returns a Keyring that derives a new AES-256-GCM key
+// from master with HKDF-SHA256 every period, so that no key seals tokens
+// for longer than period even if a process runs for months.
+// The id of each key is its epoch, the number of periods since the Unix epoch,
+// which is stored in the header of every token.
+// Tokens sealed up to retain periods ago can still be unsealed,
+// so retain periods should be at least as long as the ttl of the Tokener.
+// Keys are rotated by Seal on the clock of the Tokener (see WithClock).
+// master must be at least 16 bytes and should be 32 random bytes,
+// and period must be a whole number of seconds.
+func NewRekeyingKeyring(master []byte, period time.Duration, retain int) (*Keyring, error) {
+	if len(master) < 16 {
+		return nil, errors.New("securetoken: master key must be at least 16 bytes")
+	}
+	if period < time.Second || period%time.Second != 0 || retain < 0 {
+		return nil, errors.New("securetoken: invalid rekeying period")
+	}
+	k := NewKeyring()
+	k.rekey = &rekeyer{
+		master: append([]byte(nil), master...),
+		period: period,
+		retain: uint32(retain),
+	}
+	k.rekey.template = k.rekey.derive(0)
+	return k, nil
+}
+
+// epoch returns the epoch of now.
+func (r *rekeyer) epoch(now time.Time) uint32 {
+	return uint32(now.Unix() / int64(r.period/time.Second))
+}
+
+// derive returns the AEAD of the key of epoch.
+func (r *rekeyer) derive(epoch uint32) cipher.AEAD {
+	var info [len("securetoken epoch ") + 4]byte
+	binary.BigEndian.PutUint32(info[copy(info[:], "securetoken epoch "):], epoch)
+	key, err := hkdf.Key(sha256.New, r.master, nil, string(info[:]), 32)
+	if err != nil {
+		panic(err) // Only possible for excessive key lengths.
+	}
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		panic(err)
+	}
+	aead, err := cipher.NewGCM(c)
+	if err != nil {
+		panic(err)
+	}
+	return aead
+}
+
+// rotate makes the key of the epoch of now primary,
+// deriving it if necessary, and discards keys that are too old.
+func (k *Keyring) rotate(now time.Time) {
+	epoch := k.rekey.epoch(now)
+	if s := k.load(); s.hasPrim && s.primary >= epoch {
+		return
+	}
+	k.update(func(s *keyringState) error {
+		if _, ok := s.keys[epoch]; !ok {
+			s.keys[epoch] = &keyEntry{aead: k.rekey.derive(epoch), newAEAD: newAESGCM, keySize: 32}
+		}
+		if !s.hasPrim || epoch > s.primary {
+			s.primary, s.hasPrim = epoch, true
+		}
+		for id := range s.keys {
+			if id+k.rekey.retain < epoch {
+				delete(s.keys, id)
+			}
+		}
+		return nil
+	})
+}
+
+// lookupEpoch returns the AEAD of the key of epoch, deriving it if the epoch
+// is retained at now, or nil if it is not.
+func (k *Keyring) lookupEpoch(epoch uint32, now time.Time) cipher.AEAD {
+	current := k.rekey.epoch(now)
+	if epoch > current+1 || epoch+k.rekey.retain < current {
+		return nil
+	}
+	if e, ok := k.load().keys[epoch]; ok {
+		return e.aead
+	}
+	var aead cipher.AEAD
+	k.update(func(s *keyringState) error {
+		e, ok := s.keys[epoch]
+		if !ok {
+			e = &keyEntry{aead: k.rekey.derive(epoch), newAEAD: newAESGCM, keySize: 32}
+			s.keys[epoch] = e
+		}
+		aead = e.aead
+		return nil
+	})
+	return aead
+}
@@ -0,0 +1,115 @@
+package securetoken
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRekeyingKeyring(t *testing.T) {
+	setNow(time.Unix(3600, 0))
+	defer restoreNow()
+
+	kr, err := NewRekeyingKeyring(key2, time.Hour, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tok, err := NewKeyringTokener(kr, 2*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seal := func() []byte {
+		sealed, err := tok.Seal([]byte("data"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sealed
+	}
+	epochOf := func(sealed []byte) uint32 {
+		decoded, err := tok.decode(sealed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		raw, err := ParseRaw(decoded, MinNonceLength)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return raw.KeyID
+	}
+
+	first := seal()
+	if epoch := epochOf(first); epoch != 1 {
+		t.Errorf("epoch = %d; expected 1", epoch)
+	}
+	setNow(time.Unix(2*3600, 0))
+	second := seal()
+	if epoch := epochOf(second); epoch != 2 {
+		t.Errorf("epoch = %d; expected 2", epoch)
+	}
+	for _, sealed := range [][]byte{first, second} {
+		if data, err := tok.Unseal(sealed); err != nil || string(data) != "data" {
+			t.Errorf("Unseal(%q) = %q, %v; expected \"data\", <nil>", sealed, data, err)
+		}
+	}
+
+	// A restarted process derives the same keys.
+	kr2, err := NewRekeyingKeyring(key2, time.Hour, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tok2, err := NewKeyringTokener(kr2, 2*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, err := tok2.Unseal(first); err != nil || string(data) != "data" {
+		t.Errorf("Unseal(%q) = %q, %v; expected \"data\", <nil>", first, data, err)
+	}
+
+	// Keys older than retain periods are gone even if the ttl has not passed.
+	setNow(time.Unix(3*3600, 0))
+	seal()
+	if _, err := tok.Unseal(first); err != ErrTokenInvalid {
+		t.Errorf("Unseal(%q) returned %v; expected %s", first, err, ErrTokenInvalid)
+	}
+
+	if err := kr.AddKey(5, key); err != errRekeying {
+		t.Errorf("AddKey() returned %v; expected %s", err, errRekeying)
+	}
+}
+
+func TestNewRekeyingKeyringInvalid(t *testing.T) {
+	if _, err := NewRekeyingKeyring([]byte("short"), time.Hour, 1); err == nil {
+		t.Errorf("NewRekeyingKeyring(short master) returned nil error")
+	}
+	if _, err := NewRekeyingKeyring(key2, time.Millisecond, 1); err == nil {
+		t.Errorf("NewRekeyingKeyring(1ms) returned nil error")
+	}
+}
+
+func TestRekeyingKeyringClock(t *testing.T) {
+	if _, err := NewRekeyingKeyring(key2, 1500*time.Millisecond, 1); err == nil {
+		t.Errorf("NewRekeyingKeyring(1.5s) returned nil error")
+	}
+
+	// Keys rotate on the clock of the Tokener, not the wall clock.
+	kr, err := NewRekeyingKeyring(key2, time.Hour, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := kr.IDs(); len(ids) != 0 {
+		t.Errorf("IDs() before the first seal = %v; expected []", ids)
+	}
+	tok, err := NewKeyringTokener(kr, time.Hour, WithClock(func() time.Time { return time.Unix(5*3600, 0) }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := tok.Seal([]byte("data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := kr.IDs(); len(ids) != 1 || ids[0] != 5 {
+		t.Errorf("IDs() after sealing at epoch 5 = %v; expected [5]", ids)
+	}
+	if data, err := tok.Unseal(sealed); err != nil || string(data) != "data" {
+		t.Errorf("Unseal(%q) = %q, %v; expected \"data\", <nil>", sealed, data, err)
+	}
+}
@@ -0,0 +1,223 @@
+// Package rememberme implements persistent login tokens with
+// series and token rotation and theft detection.
+//
+// Each login starts a series. Every time a remember-me token is used,
+// the token part of its series is replaced and a new remember-me token
+// is issued. If a token of a known series arrives with an old token part,
+// it must have been copied, so every series of the subject is revoked.
+// The token part that was just replaced is still accepted once within
+// a short grace period, so that concurrent requests that carry the same
+// token, such as a page and its assets, do not look like theft.
+package rememberme
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/nicksnyder/go-securetoken/securetoken"
+)
+
+// Purpose is the purpose that Tokeners of remember-me tokens should be created
+// with (see securetoken.WithPurpose), so that remember-me tokens can never be
+// confused with session tokens even if the keys are shared.
+const Purpose = "securetoken.remember-me"
+
+var (
+	// ErrUnknownSeries is returned by Use when the series of a token
+	// has been revoked or never existed.
+	ErrUnknownSeries = errors.New("rememberme: unknown series")
+
+	// ErrTheft is returned by Use when an old token of a series is used.
+	// Every series of the subject has been revoked.
+	ErrTheft = errors.New("rememberme: token reused; all series revoked")
+)
+
+// GracePeriod is how long the previous token part of a series
+// is still accepted, once, after it has been replaced.
+const GracePeriod = 30 * time.Second
+
+// An Entry is the server side state of a series.
+type Entry struct {
+	Subject string
+
+	// TokenHash is the SHA-256 hash of the current token part of the series.
+	TokenHash []byte
+
+	// PreviousHash is the SHA-256 hash of the token part that TokenHash
+	// replaced at Replaced, until it is used within the GracePeriod.
+	PreviousHash []byte
+	Replaced     time.Time
+}
+
+// A Store holds the state of series.
+// Implementations must be goroutine safe.
+type Store interface {
+	// Get returns the entry of series, or ok false if there is none.
+	Get(series string) (e Entry, ok bool, err error)
+
+	// Put sets the entry of series.
+	Put(series string, e Entry) error
+
+	// Delete deletes series.
+	Delete(series string) error
+
+	// DeleteSubject deletes every series of subject.
+	DeleteSubject(subject string) error
+}
+
+// A Manager issues and verifies remember-me tokens.
+type Manager struct {
+	tokener securetoken.SealUnsealer
+	store   Store
+}
+
+// New returns a Manager that seals tokens with tokener and keeps series in store.
+// tokener should be dedicated to remember-me tokens, with its own key or Purpose,
+// and a ttl as long as a remembered login should last.
+func New(tokener securetoken.SealUnsealer, store Store) *Manager {
+	return &Manager{tokener: tokener, store: store}
+}
+
+type payload struct {
+	Subject string `json:"sub"`
+	Series  string `json:"ser"`
+	Token   string `json:"tok"`
+}
+
+// Issue starts a new series for subject and returns its first token.
+func (m *Manager) Issue(subject string) ([]byte, error) {
+	series, err := randomString()
+	if err != nil {
+		return nil, err
+	}
+	return m.issue(subject, series, nil)
+}
+
+// Use verifies a remember-me token and returns its subject
+// together with the token that replaces it.
+// It returns ErrTheft if the token has already been used, except for
+// one use of the token that was replaced within the last GracePeriod,
+// for which next is nil: the request that replaced it got the next token.
+func (m *Manager) Use(sealed []byte) (subject string, next []byte, err error) {
+	p, err := m.unseal(sealed)
+	if err != nil {
+		return "", nil, err
+	}
+	lock := seriesLock(p.Series)
+	lock.Lock()
+	defer lock.Unlock()
+	e, ok, err := m.store.Get(p.Series)
+	if err != nil {
+		return "", nil, err
+	}
+	if !ok || e.Subject != p.Subject {
+		return "", nil, ErrUnknownSeries
+	}
+	h := hash(p.Token)
+	if subtle.ConstantTimeCompare(e.TokenHash, h) != 1 {
+		if subtle.ConstantTimeCompare(e.PreviousHash, h) == 1 && m.now().Sub(e.Replaced) < GracePeriod {
+			e.PreviousHash = nil
+			if err := m.store.Put(p.Series, e); err != nil {
+				return "", nil, err
+			}
+			return p.Subject, nil, nil
+		}
+		if err := m.store.DeleteSubject(p.Subject); err != nil {
+			return "", nil, err
+		}
+		return "", nil, ErrTheft
+	}
+	next, err = m.issue(p.Subject, p.Series, h)
+	if err != nil {
+		return "", nil, err
+	}
+	return p.Subject, next, nil
+}
+
+// Forget ends the series of a remember-me token, e.g. at logout.
+func (m *Manager) Forget(sealed []byte) error {
+	p, err := m.unseal(sealed)
+	if err != nil {
+		return err
+	}
+	return m.store.Delete(p.Series)
+}
+
+// ForgetSubject ends every series of subject.
+func (m *Manager) ForgetSubject(subject string) error {
+	return m.store.DeleteSubject(subject)
+}
+
+// issue issues a new token for series, which replaces the token
+// part whose hash is previous.
+func (m *Manager) issue(subject, series string, previous []byte) ([]byte, error) {
+	token, err := randomString()
+	if err != nil {
+		return nil, err
+	}
+	buf, err := json.Marshal(payload{Subject: subject, Series: series, Token: token})
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := m.tokener.Seal(buf)
+	if err != nil {
+		return nil, err
+	}
+	e := Entry{Subject: subject, TokenHash: hash(token)}
+	if previous != nil {
+		e.PreviousHash, e.Replaced = previous, m.now()
+	}
+	if err := m.store.Put(series, e); err != nil {
+		return nil, err
+	}
+	return sealed, nil
+}
+
+func (m *Manager) unseal(sealed []byte) (*payload, error) {
+	buf, err := m.tokener.Unseal(sealed)
+	if err != nil {
+		return nil, err
+	}
+	p := &payload{}
+	if err := json.Unmarshal(buf, p); err != nil || p.Series == "" {
+		return nil, securetoken.ErrTokenInvalid
+	}
+	return p, nil
+}
+
+// now returns the time on the clock of the Tokener if it has one.
+func (m *Manager) now() time.Time {
+	if c, ok := m.tokener.(interface{ Now() time.Time }); ok {
+		return c.Now()
+	}
+	return time.Now()
+}
+
+func randomString() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func hash(token string) []byte {
+	h := sha256.Sum256([]byte(token))
+	return h[:]
+}
+
+// seriesLocks serializes concurrent uses of a series within this process,
+// so that only one of several requests racing with the same valid token
+// replaces it and the others use the grace period.
+var seriesLocks [64]sync.Mutex
+
+func seriesLock(series string) *sync.Mutex {
+	h := sha256.Sum256([]byte(series))
+	return &seriesLocks[h[0]%byte(len(seriesLocks))]
+}
@@ -0,0 +1,114 @@
+package rememberme
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/nicksnyder/go-securetoken/securetoken"
+	"github.com/nicksnyder/go-securetoken/securetoken/securetokentest"
+)
+
+func newManager(t *testing.T) *Manager {
+	return New(securetokentest.NewTokener(t, securetoken.WithPurpose(Purpose)), NewMemoryStore())
+}
+
+func TestRotation(t *testing.T) {
+	m := newManager(t)
+	first, err := m.Issue("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, second, err := m.Use(first)
+	if err != nil || subject != "alice" {
+		t.Fatalf("Use(%q) = %q, %v; expected \"alice\", <nil>", first, subject, err)
+	}
+	subject, _, err = m.Use(second)
+	if err != nil || subject != "alice" {
+		t.Fatalf("Use(%q) = %q, %v; expected \"alice\", <nil>", second, subject, err)
+	}
+}
+
+func TestTheft(t *testing.T) {
+	tok := securetokentest.NewTokener(t, securetoken.WithPurpose(Purpose))
+	m := New(tok, NewMemoryStore())
+	stolen, err := m.Issue("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := m.Issue("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob, err := m.Issue("bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, next, err := m.Use(stolen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tok.Clock.Advance(GracePeriod)
+	if _, _, err := m.Use(stolen); err != ErrTheft {
+		t.Fatalf("Use(reused token) returned %v; expected %s", err, ErrTheft)
+	}
+	for _, sealed := range [][]byte{next, other} {
+		if _, _, err := m.Use(sealed); err != ErrUnknownSeries {
+			t.Errorf("Use(%q) returned %v; expected %s", sealed, err, ErrUnknownSeries)
+		}
+	}
+	if subject, _, err := m.Use(bob); err != nil || subject != "bob" {
+		t.Errorf("Use(%q) = %q, %v; expected \"bob\", <nil>", bob, subject, err)
+	}
+}
+
+func TestForget(t *testing.T) {
+	m := newManager(t)
+	sealed, err := m.Issue("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Forget(sealed); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := m.Use(sealed); err != ErrUnknownSeries {
+		t.Errorf("Use(%q) returned %v; expected %s", sealed, err, ErrUnknownSeries)
+	}
+}
+
+// TestPurpose tests that session tokens are not accepted as remember-me tokens.
+func TestPurpose(t *testing.T) {
+	m := newManager(t)
+	session := securetokentest.NewTokener(t)
+	sealed, err := session.Seal([]byte(`{"sub":"alice","ser":"x","tok":"y"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := m.Use(sealed); err != securetoken.ErrTokenInvalid {
+		t.Errorf("Use(%q) returned %v; expected %s", sealed, err, securetoken.ErrTokenInvalid)
+	}
+}
+
+func TestGracePeriod(t *testing.T) {
+	m := newManager(t)
+	first, err := m.Issue("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, next, err := m.Use(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A concurrent request with the same token is not theft.
+	if subject, again, err := m.Use(first); subject != "alice" || again != nil || err != nil {
+		t.Errorf("Use(replaced token) = %q, %q, %v; expected \"alice\", <nil>, <nil>", subject, again, err)
+	}
+	if _, _, err := m.Use(first); err != ErrTheft {
+		t.Errorf("third Use(replaced token) returned %v; expected %s", err, ErrTheft)
+	}
+	if _, _, err := m.Use(next); err != ErrUnknownSeries {
+		t.Errorf("Use(next) after theft returned %v; expected %s", err, ErrUnknownSeries)
+	}
+	if bytes.Equal(first, next) {
+		t.Error("Use() returned the same token")
+	}
+}
@@ -0,0 +1,51 @@
+package rememberme
+
+import "sync"
+
+// A MemoryStore is a Store that keeps series in memory.
+// It is goroutine safe.
+type MemoryStore struct {
+	mu     sync.Mutex
+	series map[string]Entry
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{series: make(map[string]Entry)}
+}
+
+// Get implements Store.
+func (s *MemoryStore) Get(series string) (Entry, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.series[series]
+	return e, ok, nil
+}
+
+// Put implements Store.
+func (s *MemoryStore) Put(series string, e Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.series[series] = e
+	return nil
+}
+
+// Delete implements Store.
+func (s *MemoryStore) Delete(series string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.series, series)
+	return nil
+}
+
+// DeleteSubject implements Store.
+func (s *MemoryStore) DeleteSubject(subject string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for series, e := range s.series {
+		if e.Subject == subject {
+			delete(s.series, series)
+		}
+	}
+	return nil
+}
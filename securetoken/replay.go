@@ -0,0 +1,127 @@
+package securetoken
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"sync"
+	"time"
+)
+
+// A ReplayDetector flags tokens that are presented by many distinct
+// clients within a short window, which is a likely sign that a bearer
+// token was stolen and is being replayed. Use its AfterUnseal method with
+// WithAfterUnseal; clients are told apart by the Caller of the
+// RequestInfo, which httptoken sets to the IP address of the client.
+// It only keeps fingerprints of tokens and clients, not the values.
+// It is goroutine safe.
+type ReplayDetector struct {
+	window     time.Duration
+	maxClients int
+	flag       func(c *Claims, info *RequestInfo, clients int)
+
+	mu        sync.Mutex
+	tokens    map[[16]byte]*replayEntry
+	lastSweep time.Time
+}
+
+type replayEntry struct {
+	clients map[[16]byte]time.Time
+	flagged bool
+}
+
+// NewReplayDetector returns a ReplayDetector that calls flag once when a
+// token has been presented by more than maxClients distinct clients
+// within window, with the claims and request of the presentation that
+// crossed the limit and the number of clients seen.
+// A token can be flagged again once all of its clients have aged out
+// of the window.
+func NewReplayDetector(window time.Duration, maxClients int, flag func(c *Claims, info *RequestInfo, clients int)) *ReplayDetector {
+	return &ReplayDetector{
+		window:     window,
+		maxClients: maxClients,
+		flag:       flag,
+		tokens:     make(map[[16]byte]*replayEntry),
+	}
+}
+
+// AfterUnseal records that info.Caller presented the token with claims c.
+// It always returns nil, so detection never rejects a request; flag can
+// revoke the token if that is wanted.
+func (d *ReplayDetector) AfterUnseal(c *Claims, info *RequestInfo) error {
+	if info.Caller == "" {
+		return nil
+	}
+	token, client := tokenFingerprint(c), fingerprint(info.Caller)
+	now := timeNow()
+
+	d.mu.Lock()
+	if now.Sub(d.lastSweep) >= d.window {
+		d.sweep(now)
+	}
+	e := d.tokens[token]
+	if e == nil {
+		e = &replayEntry{clients: make(map[[16]byte]time.Time)}
+		d.tokens[token] = e
+	}
+	e.expire(now, d.window)
+	e.clients[client] = now
+	n := len(e.clients)
+	flag := n > d.maxClients && !e.flagged
+	if flag {
+		e.flagged = true
+	}
+	d.mu.Unlock()
+
+	if flag && d.flag != nil {
+		d.flag(c, info, n)
+	}
+	return nil
+}
+
+// sweep removes the clients that were last seen before the window
+// and the tokens without clients.
+func (d *ReplayDetector) sweep(now time.Time) {
+	for token, e := range d.tokens {
+		e.expire(now, d.window)
+		if len(e.clients) == 0 {
+			delete(d.tokens, token)
+		}
+	}
+	d.lastSweep = now
+}
+
+// expire removes the clients that were last seen before the window.
+func (e *replayEntry) expire(now time.Time, window time.Duration) {
+	for client, seen := range e.clients {
+		if now.Sub(seen) > window {
+			delete(e.clients, client)
+		}
+	}
+	if len(e.clients) == 0 {
+		e.flagged = false
+	}
+}
+
+// tokenFingerprint identifies the token of c by its ID, if it has one,
+// or by its subject and issue time, which are unique in practice.
+func tokenFingerprint(c *Claims) [16]byte {
+	h := sha256.New()
+	h.Write([]byte(c.ID))
+	h.Write([]byte{0})
+	h.Write([]byte(c.Subject))
+	h.Write([]byte{0})
+	h.Write([]byte(c.Purpose))
+	var ts [8]byte
+	binary.BigEndian.PutUint64(ts[:], uint64(c.IssuedAt.UnixNano()))
+	h.Write(ts[:])
+	var f [16]byte
+	copy(f[:], h.Sum(nil))
+	return f
+}
+
+func fingerprint(s string) [16]byte {
+	sum := sha256.Sum256([]byte(s))
+	var f [16]byte
+	copy(f[:], sum[:])
+	return f
+}
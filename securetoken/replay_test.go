@@ -0,0 +1,55 @@
+package securetoken
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReplayDetector(t *testing.T) {
+	setNow(time.Unix(1, 0))
+	defer restoreNow()
+
+	var flagged []int
+	d := NewReplayDetector(time.Minute, 2, func(c *Claims, info *RequestInfo, clients int) {
+		flagged = append(flagged, clients)
+	})
+	tok, err := NewTokener(key, time.Hour, WithAfterUnseal(d.AfterUnseal))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := tok.SealClaims(&Claims{Subject: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := tok.SealClaims(&Claims{Subject: "bob"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, caller := range []string{"10.0.0.1", "10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.4"} {
+		if _, err := tok.UnsealClaimsFor(caller, sealed); err != nil {
+			t.Fatalf("UnsealClaimsFor(%q) returned %v; expected <nil>", caller, err)
+		}
+		if _, err := tok.UnsealClaimsFor(caller, other); err != nil {
+			t.Fatal(err)
+		}
+		if caller == "10.0.0.2" {
+			setNow(timeNow().Add(30 * time.Second))
+		}
+	}
+	if len(flagged) != 2 || flagged[0] != 3 || flagged[1] != 3 {
+		t.Errorf("flag called with %v; expected [3 3], once for each token", flagged)
+	}
+
+	// Clients age out of the window.
+	flagged = nil
+	setNow(timeNow().Add(2 * time.Minute))
+	for _, caller := range []string{"10.0.0.5", "10.0.0.6"} {
+		if _, err := tok.UnsealClaimsFor(caller, sealed); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(flagged) != 0 {
+		t.Errorf("flag called with %v after the window; expected no calls", flagged)
+	}
+}
@@ -0,0 +1,118 @@
+package securetoken
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrWrongRegion is returned by Seal when the primary key of a Tokener
+// created WithRegion belongs to another region.
+var ErrWrongRegion = errors.New("securetoken: key belongs to another residency region")
+
+// A Region identifies a data residency region, such as the EU.
+// Applications define their own regions, e.g.
+//
+//	const RegionEU securetoken.Region = 1
+//
+// A key belongs to a region if it was added with Keyring.AddRegionalKey,
+// which gives it an id whose top byte is the region. The keyring records
+// the region with the key rather than reading it back from ids, so keys
+// with other id schemes (e.g. timestamps or rekeying epochs) never appear
+// to belong to a region. Because the id is in the header of every
+// version 2 token, where it is authenticated, so is the region.
+// Region 0 is for keys without a region, and Tokeners created without
+// WithRegion accept keys of every region.
+type Region uint8
+
+// regionShift is the position of the region in a key id.
+const regionShift = 24
+
+// KeyID returns the id of key number n of r. n must be less than 1<<24.
+func (r Region) KeyID(n uint32) uint32 {
+	return uint32(r)<<regionShift | n&(1<<regionShift-1)
+}
+
+// RegionOf returns the region that the id of a key of Keyring.AddRegionalKey
+// encodes. For ids of other keys it is meaningless.
+func RegionOf(id uint32) Region {
+	return Region(id >> regionShift)
+}
+
+// AddRegionalKey adds an AES-GCM key of region r with id r.KeyID(n).
+// n must be less than 1<<24, and key must be either 16, 24, or 32 bytes.
+func (k *Keyring) AddRegionalKey(r Region, n uint32, key []byte) error {
+	if r == 0 || n >= 1<<regionShift {
+		return fmt.Errorf("securetoken: invalid regional key %d of region %d", n, r)
+	}
+	aead, err := newAESGCM(key)
+	if err != nil {
+		return err
+	}
+	return k.add(r.KeyID(n), &keyEntry{aead: aead, newAEAD: newAESGCM, keySize: len(key), region: r})
+}
+
+// regionOf returns the region of the key with the given id,
+// or 0 if it does not exist or has no region.
+func (k *Keyring) regionOf(id uint32) Region {
+	if e, ok := k.load().keys[id]; ok {
+		return e.region
+	}
+	return 0
+}
+
+// WithRegion returns an Option that restricts a Tokener to the keys of r:
+// Seal returns ErrWrongRegion if the primary key belongs to another region
+// or to none, and tokens of keys of other regions or of no region are
+// invalid, even if their key is in the keyring. Deployments outside r
+// should not have the keys of r at all, which the option cannot check;
+// it guards against keyrings that mix keys of several regions by mistake.
+// It requires a Tokener created by NewKeyringTokener or NewRegionalTokener
+// with a keyring that does not derive its own keys.
+func WithRegion(r Region) Option {
+	return func(t *Tokener) error {
+		if r == 0 {
+			return errors.New("securetoken: WithRegion requires a region other than 0")
+		}
+		if t.version < Version2 {
+			return fmt.Errorf("securetoken: WithRegion requires version %d tokens", Version2)
+		}
+		if t.keys.rekey != nil {
+			return errRekeying
+		}
+		t.region = r
+		return nil
+	}
+}
+
+// NewRegionalTokener returns a Tokener for the residency region r that
+// seals with the key of r read from src, e.g. the secret manager of r.
+// The key has id r.KeyID(0).
+func NewRegionalTokener(r Region, src KeySource, ttl time.Duration, opts ...Option) (*Tokener, error) {
+	key, err := src.Key()
+	if err != nil {
+		return nil, err
+	}
+	kr := NewKeyring()
+	if err := kr.AddRegionalKey(r, 0, key); err != nil {
+		return nil, err
+	}
+	return NewKeyringTokener(kr, ttl, append([]Option{WithRegion(r)}, opts...)...)
+}
+
+// TokenRegion returns the region of the key that sealed a token, without
+// unsealing it, e.g. to route the token to a service in that region.
+// It is RegionOf the key id, so it is only meaningful for tokens sealed
+// with keys of Keyring.AddRegionalKey. The region is not authenticated
+// until the token is unsealed.
+func (t *Tokener) TokenRegion(sealed []byte) (Region, error) {
+	decoded, err := t.decode(sealed)
+	if err != nil {
+		return 0, ErrTokenInvalid
+	}
+	_, id, err := parseHeader(decoded)
+	if err != nil {
+		return 0, err
+	}
+	return RegionOf(id), nil
+}
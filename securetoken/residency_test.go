@@ -0,0 +1,106 @@
+package securetoken
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRegion(t *testing.T) {
+	const eu, us Region = 1, 2
+	if id := eu.KeyID(7); id != 1<<24|7 || RegionOf(id) != eu {
+		t.Errorf("eu.KeyID(7) = %#x with region %d; expected %#x with region %d", id, RegionOf(id), 1<<24|7, eu)
+	}
+
+	euTok, err := NewRegionalTokener(eu, KeyString("000102030405060708090a0b0c0d0e0f"), ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := euTok.Seal([]byte("data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, err := euTok.TokenRegion(sealed); r != eu || err != nil {
+		t.Errorf("TokenRegion(%q) = %d, %v; expected %d, <nil>", sealed, r, err, eu)
+	}
+	if data, err := euTok.Unseal(sealed); string(data) != "data" || err != nil {
+		t.Errorf("Unseal(%q) = %q, %v; expected %q, <nil>", sealed, data, err, "data")
+	}
+
+	// A US keyring that also holds the EU key by mistake.
+	kr := NewKeyring()
+	if err := kr.AddRegionalKey(us, 0, key); err != nil {
+		t.Fatal(err)
+	}
+	euKey, err := KeyString("000102030405060708090a0b0c0d0e0f").Key()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := kr.AddRegionalKey(eu, 0, euKey); err != nil {
+		t.Fatal(err)
+	}
+	usTok, err := NewKeyringTokener(kr, ttl, WithRegion(us))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := usTok.Unseal(sealed); err != ErrTokenInvalid {
+		t.Errorf("Unseal(%q) by another region returned %v; expected %s", sealed, err, ErrTokenInvalid)
+	}
+	if err := kr.SetPrimary(eu.KeyID(0)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := usTok.Seal([]byte("data")); err != ErrWrongRegion {
+		t.Errorf("Seal() with a primary key of another region returned %v; expected %s", err, ErrWrongRegion)
+	}
+
+	// Without WithRegion, keys of every region are accepted.
+	anyTok, err := NewKeyringTokener(kr, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := anyTok.Unseal(sealed); err != nil {
+		t.Errorf("Unseal(%q) without WithRegion returned %v", sealed, err)
+	}
+
+	if _, err := NewTokener(key, time.Minute, WithRegion(eu)); err == nil {
+		t.Error("NewTokener(WithRegion()) returned nil error; expected version 1 tokens to be rejected")
+	}
+	if _, err := NewKeyringTokener(kr, ttl, WithRegion(0)); err == nil {
+		t.Error("NewKeyringTokener(WithRegion(0)) returned nil error")
+	}
+}
+
+func TestRegionKeyIDs(t *testing.T) {
+	const region Region = 0x65
+
+	// A key whose id is a timestamp looks like a key of region 0x65,
+	// but it was not added as one.
+	kr := NewKeyring()
+	if err := kr.AddKey(1700000000, key); err != nil {
+		t.Fatal(err)
+	}
+	if RegionOf(1700000000) != region {
+		t.Fatalf("RegionOf(1700000000) = %d; expected %d", RegionOf(1700000000), region)
+	}
+	tok, err := NewKeyringTokener(kr, ttl, WithRegion(region))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tok.Seal([]byte("data")); err != ErrWrongRegion {
+		t.Errorf("Seal() with a timestamp key id returned %v; expected %s", err, ErrWrongRegion)
+	}
+
+	if err := kr.AddRegionalKey(region, 1<<24, key); err == nil {
+		t.Error("AddRegionalKey(1<<24) returned <nil>")
+	}
+	if err := kr.AddRegionalKey(0, 1, key); err == nil {
+		t.Error("AddRegionalKey() of region 0 returned <nil>")
+	}
+
+	rekeying, err := NewRekeyingKeyring(key, time.Minute, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewKeyringTokener(rekeying, ttl, WithRegion(region)); err != errRekeying {
+		t.Errorf("NewKeyringTokener(WithRegion()) with a rekeying keyring returned %v; expected %s", err, errRekeying)
+	}
+}
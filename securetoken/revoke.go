@@ -0,0 +1,126 @@
+package securetoken
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrTokenRevoked is returned by UnsealClaims when the token or a token
+// it was derived from has been revoked.
+var ErrTokenRevoked = errors.New("securetoken: token revoked")
+
+// A RevocationStore records revoked token IDs.
+// Implementations must be goroutine safe.
+type RevocationStore interface {
+	// Revoke revokes the token with the given ID. The ID may be
+	// forgotten after until, once the token has expired.
+	Revoke(id string, until time.Time) error
+
+	// Revoked reports whether the token with the given ID has been revoked.
+	Revoked(id string) (bool, error)
+}
+
+// WithRevocationStore returns an Option that makes UnsealClaims return
+// ErrTokenRevoked for tokens whose ID, or the ID of any of their Parents,
+// has been revoked in s.
+func WithRevocationStore(s RevocationStore) Option {
+	return func(t *Tokener) error {
+		t.revoked = s
+		return nil
+	}
+}
+
+// checkRevoked returns ErrTokenRevoked if c or one of its parents is revoked,
+// or if a revocation predicate matches c.
+func (t *Tokener) checkRevoked(c *Claims) error {
+	if t.revoked == nil {
+		return nil
+	}
+	ids := append([]string{c.ID}, c.Parents...)
+	for _, id := range ids {
+		if id == "" {
+			continue
+		}
+		revoked, err := t.revoked.Revoked(id)
+		if err != nil {
+			return err
+		}
+		if revoked {
+			return ErrTokenRevoked
+		}
+	}
+	return t.checkRevokedMatching(c)
+}
+
+// A MemoryRevocationStore is a PredicateRevocationStore that keeps IDs
+// and predicates in memory. It is goroutine safe.
+type MemoryRevocationStore struct {
+	mu    sync.Mutex
+	ids   map[string]time.Time
+	preds predicateIndex
+	now   func() time.Time
+}
+
+// NewMemoryRevocationStore returns an empty MemoryRevocationStore.
+func NewMemoryRevocationStore() *MemoryRevocationStore {
+	return &MemoryRevocationStore{ids: make(map[string]time.Time), now: time.Now}
+}
+
+// Revoke implements RevocationStore.
+func (s *MemoryRevocationStore) Revoke(id string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.ids) >= 10000 {
+		now := s.now()
+		for id, until := range s.ids {
+			if now.After(until) {
+				delete(s.ids, id)
+			}
+		}
+	}
+	if until.After(s.ids[id]) {
+		s.ids[id] = until
+	}
+	return nil
+}
+
+// Revoked implements RevocationStore.
+func (s *MemoryRevocationStore) Revoked(id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.ids[id]
+	return ok, nil
+}
+
+// RevokeMatching implements PredicateRevocationStore.
+func (s *MemoryRevocationStore) RevokeMatching(p RevocationPredicate) error {
+	if err := p.validate(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.preds.n >= 10000 {
+		s.preds.prune(s.now())
+	}
+	s.preds.add(p)
+	return nil
+}
+
+// RevokedMatching implements PredicateRevocationStore.
+func (s *MemoryRevocationStore) RevokedMatching(c *Claims) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.preds.matches(c), nil
+}
+
+// newID returns a random token ID.
+func newID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
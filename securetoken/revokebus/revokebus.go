@@ -0,0 +1,252 @@
+// Package revokebus propagates revocations between the instances of a
+// service over a publish/subscribe channel, such as Redis pub/sub or NATS.
+//
+// Revocations are checked against a store in each instance, so that
+// unsealing does not wait on the network. A Bus revokes a token ID (or
+// increments the session version of a subject, see package sessions) in
+// the shared store, applies it to the local store and publishes it; every
+// other instance applies it to its local store when it receives it, so the
+// instances converge within the delivery latency of the channel rather
+// than when their caches expire.
+//
+// Events published while an instance was not subscribed are not replayed.
+// Instances should load the current revocations when they start, e.g. with
+// securetoken.ImportRevocations, before they serve requests.
+package revokebus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/nicksnyder/go-securetoken/securetoken"
+	"github.com/nicksnyder/go-securetoken/securetoken/sessions"
+)
+
+// DefaultVersionTTL is the VersionTTL of a Bus that does not set one.
+const DefaultVersionTTL = time.Minute
+
+var errNoVersionStore = errors.New("revokebus: the Bus has no version store")
+
+// A PubSub is a broadcast channel between instances.
+// Implementations must be goroutine safe.
+type PubSub interface {
+	// Publish sends msg to every subscriber, including those of this instance.
+	Publish(ctx context.Context, msg []byte) error
+
+	// Subscribe calls handle with every message published until ctx is
+	// done, and then returns ctx.Err().
+	Subscribe(ctx context.Context, handle func(msg []byte)) error
+}
+
+// event is a revocation as it is published.
+type event struct {
+	ID      string    `json:"id,omitempty"`
+	Until   time.Time `json:"until,omitempty"`
+	Subject string    `json:"sub,omitempty"`
+	Version uint64    `json:"sv,omitempty"`
+}
+
+// A Bus broadcasts revocations.
+// It is goroutine safe.
+type Bus struct {
+	ps     PubSub
+	shared securetoken.RevocationStore
+	local  securetoken.RevocationStore
+
+	// Errors, if not nil, is called with the errors of applying events
+	// received from other instances.
+	Errors func(err error)
+
+	// VersionTTL is how long Versions caches a session version before it
+	// reads it from the version store again, which bounds how long an
+	// increment whose event was lost goes unnoticed.
+	// It is DefaultVersionTTL if 0.
+	VersionTTL time.Duration
+
+	versions *Versions
+}
+
+// New returns a Bus that publishes on ps. Revoke records IDs in shared,
+// which may be nil if there is no shared store, and every instance
+// records them in local, which the Tokener should use
+// (see securetoken.WithRevocationStore). versions is the store of session
+// versions that Versions caches, or nil if the Bus only revokes IDs.
+func New(ps PubSub, shared, local securetoken.RevocationStore, versions sessions.VersionStore) *Bus {
+	b := &Bus{ps: ps, shared: shared, local: local}
+	b.versions = &Versions{bus: b, store: versions, cache: make(map[string]cachedVersion), now: time.Now}
+	return b
+}
+
+// Revoke implements securetoken.RevocationStore by revoking id in the
+// shared and local stores and publishing the revocation.
+func (b *Bus) Revoke(id string, until time.Time) error {
+	if b.shared != nil {
+		if err := b.shared.Revoke(id, until); err != nil {
+			return err
+		}
+	}
+	if err := b.local.Revoke(id, until); err != nil {
+		return err
+	}
+	return b.publish(event{ID: id, Until: until})
+}
+
+// Revoked implements securetoken.RevocationStore by checking the local store.
+func (b *Bus) Revoked(id string) (bool, error) {
+	return b.local.Revoked(id)
+}
+
+// Run applies the revocations published by every instance until ctx is
+// done. It must be running for the instance to learn of them.
+func (b *Bus) Run(ctx context.Context) error {
+	return b.ps.Subscribe(ctx, func(msg []byte) {
+		if err := b.apply(msg); err != nil && b.Errors != nil {
+			b.Errors(err)
+		}
+	})
+}
+
+func (b *Bus) publish(e event) error {
+	msg, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return b.ps.Publish(context.Background(), msg)
+}
+
+func (b *Bus) apply(msg []byte) error {
+	var e event
+	if err := json.Unmarshal(msg, &e); err != nil {
+		return err
+	}
+	if e.ID != "" {
+		if err := b.local.Revoke(e.ID, e.Until); err != nil {
+			return err
+		}
+	}
+	if e.Subject != "" {
+		b.versions.observe(e.Subject, e.Version)
+	}
+	return nil
+}
+
+// Versions returns a sessions.VersionStore that caches the session
+// versions of the version store given to New and broadcasts increments
+// on b, for use with sessions.New.
+func (b *Bus) Versions() *Versions {
+	return b.versions
+}
+
+// Versions is a sessions.VersionStore that caches the session versions of
+// another store and learns of increments by other instances from its Bus.
+// It is goroutine safe.
+type Versions struct {
+	bus   *Bus
+	store sessions.VersionStore
+	now   func() time.Time
+
+	mu    sync.Mutex
+	cache map[string]cachedVersion
+}
+
+type cachedVersion struct {
+	ver     uint64
+	fetched time.Time
+}
+
+// Version implements sessions.VersionStore. Subjects that are not cached,
+// or were cached longer than the VersionTTL of the Bus ago, are looked up
+// in the underlying store.
+func (v *Versions) Version(subject string) (uint64, error) {
+	if v.store == nil {
+		return 0, errNoVersionStore
+	}
+	ttl := v.bus.VersionTTL
+	if ttl == 0 {
+		ttl = DefaultVersionTTL
+	}
+	v.mu.Lock()
+	c, ok := v.cache[subject]
+	v.mu.Unlock()
+	if ok && v.now().Sub(c.fetched) < ttl {
+		return c.ver, nil
+	}
+	ver, err := v.store.Version(subject)
+	if err != nil {
+		return 0, err
+	}
+	return v.observe(subject, ver), nil
+}
+
+// Increment implements sessions.VersionStore by incrementing the version
+// in the underlying store and publishing the new version.
+func (v *Versions) Increment(subject string) (uint64, error) {
+	if v.store == nil {
+		return 0, errNoVersionStore
+	}
+	ver, err := v.store.Increment(subject)
+	if err != nil {
+		return 0, err
+	}
+	v.observe(subject, ver)
+	return ver, v.bus.publish(event{Subject: subject, Version: ver})
+}
+
+// observe records that the version of subject is at least ver, as of now,
+// and returns the cached version.
+func (v *Versions) observe(subject string, ver uint64) uint64 {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	c := v.cache[subject]
+	if ver > c.ver {
+		c.ver = ver
+	}
+	c.fetched = v.now()
+	v.cache[subject] = c
+	return c.ver
+}
+
+// A MemoryPubSub is a PubSub within a single process, for tests and for
+// fanning revocations out to several Buses in one process.
+// It is goroutine safe.
+type MemoryPubSub struct {
+	mu   sync.Mutex
+	subs map[int]func(msg []byte)
+	next int
+}
+
+// NewMemoryPubSub returns a MemoryPubSub without subscribers.
+func NewMemoryPubSub() *MemoryPubSub {
+	return &MemoryPubSub{subs: make(map[int]func(msg []byte))}
+}
+
+// Publish implements PubSub. It calls the subscribers before returning.
+func (p *MemoryPubSub) Publish(ctx context.Context, msg []byte) error {
+	p.mu.Lock()
+	subs := make([]func(msg []byte), 0, len(p.subs))
+	for _, handle := range p.subs {
+		subs = append(subs, handle)
+	}
+	p.mu.Unlock()
+	for _, handle := range subs {
+		handle(append([]byte(nil), msg...))
+	}
+	return nil
+}
+
+// Subscribe implements PubSub.
+func (p *MemoryPubSub) Subscribe(ctx context.Context, handle func(msg []byte)) error {
+	p.mu.Lock()
+	id := p.next
+	p.next++
+	p.subs[id] = handle
+	p.mu.Unlock()
+	<-ctx.Done()
+	p.mu.Lock()
+	delete(p.subs, id)
+	p.mu.Unlock()
+	return ctx.Err()
+}
@@ -0,0 +1,136 @@
+package revokebus
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nicksnyder/go-securetoken/securetoken"
+	"github.com/nicksnyder/go-securetoken/securetoken/sessions"
+)
+
+// newBuses returns n Buses that share ps, shared and versions, and runs
+// them until the test ends.
+func newBuses(t *testing.T, n int, ps PubSub, shared securetoken.RevocationStore, versions sessions.VersionStore) []*Bus {
+	ctx, cancel := context.WithCancel(context.Background())
+	buses := make([]*Bus, n)
+	done := make(chan struct{}, n)
+	for i := range buses {
+		buses[i] = New(ps, shared, securetoken.NewMemoryRevocationStore(), versions)
+		go func(b *Bus) {
+			b.Run(ctx)
+			done <- struct{}{}
+		}(buses[i])
+	}
+	t.Cleanup(func() {
+		cancel()
+		for range buses {
+			<-done
+		}
+	})
+	// Wait until every Bus has subscribed.
+	mem := ps.(*MemoryPubSub)
+	for {
+		mem.mu.Lock()
+		subscribed := len(mem.subs)
+		mem.mu.Unlock()
+		if subscribed == n {
+			return buses
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestBusRevoke(t *testing.T) {
+	shared := securetoken.NewMemoryRevocationStore()
+	buses := newBuses(t, 3, NewMemoryPubSub(), shared, nil)
+
+	if err := buses[0].Revoke("1", time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	for i, b := range buses {
+		if revoked, err := b.Revoked("1"); !revoked || err != nil {
+			t.Errorf("buses[%d].Revoked(\"1\") = %t, %v; expected true, <nil>", i, revoked, err)
+		}
+	}
+	if revoked, _ := shared.Revoked("1"); !revoked {
+		t.Error("Revoke() did not revoke the ID in the shared store")
+	}
+	if revoked, _ := buses[1].Revoked("2"); revoked {
+		t.Error("Revoked(\"2\") = true; expected false")
+	}
+}
+
+func TestBusTokener(t *testing.T) {
+	buses := newBuses(t, 2, NewMemoryPubSub(), nil, nil)
+	key := []byte("0123456789abcdef")
+	issuer, err := securetoken.NewTokener(key, time.Hour, securetoken.WithRevocationStore(buses[0]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier, err := securetoken.NewTokener(key, time.Hour, securetoken.WithRevocationStore(buses[1]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := issuer.SealClaims(&securetoken.Claims{ID: "1", Subject: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := verifier.UnsealClaims(sealed); err != nil {
+		t.Fatal(err)
+	}
+	if err := buses[0].Revoke("1", time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := verifier.UnsealClaims(sealed); err != securetoken.ErrTokenRevoked {
+		t.Errorf("UnsealClaims() on another instance after Revoke returned %v; expected %s", err, securetoken.ErrTokenRevoked)
+	}
+}
+
+func TestBusVersions(t *testing.T) {
+	store := sessions.NewMemoryVersionStore()
+	buses := newBuses(t, 2, NewMemoryPubSub(), nil, store)
+	v0 := buses[0].Versions()
+	v1 := buses[1].Versions()
+
+	// Both instances cache version 0 of alice.
+	for i, v := range []*Versions{v0, v1} {
+		if ver, err := v.Version("alice"); ver != 0 || err != nil {
+			t.Fatalf("versions[%d].Version(\"alice\") = %d, %v; expected 0, <nil>", i, ver, err)
+		}
+	}
+	if ver, err := v0.Increment("alice"); ver != 1 || err != nil {
+		t.Fatalf("Increment(\"alice\") = %d, %v; expected 1, <nil>", ver, err)
+	}
+	if ver, err := v1.Version("alice"); ver != 1 || err != nil {
+		t.Errorf("Version(\"alice\") on another instance = %d, %v; expected 1, <nil>", ver, err)
+	}
+}
+
+func TestBusVersionsRefresh(t *testing.T) {
+	store := sessions.NewMemoryVersionStore()
+	b := New(NewMemoryPubSub(), nil, securetoken.NewMemoryRevocationStore(), store)
+	now := time.Unix(0, 0)
+	v := b.Versions()
+	v.now = func() time.Time { return now }
+
+	if ver, err := v.Version("alice"); ver != 0 || err != nil {
+		t.Fatalf("Version(\"alice\") = %d, %v; expected 0, <nil>", ver, err)
+	}
+	// An increment whose event never arrived.
+	if _, err := store.Increment("alice"); err != nil {
+		t.Fatal(err)
+	}
+	if ver, _ := v.Version("alice"); ver != 0 {
+		t.Errorf("Version(\"alice\") within the VersionTTL = %d; expected the cached 0", ver)
+	}
+	now = now.Add(DefaultVersionTTL)
+	if ver, err := v.Version("alice"); ver != 1 || err != nil {
+		t.Errorf("Version(\"alice\") after the VersionTTL = %d, %v; expected 1, <nil>", ver, err)
+	}
+
+	noStore := New(NewMemoryPubSub(), nil, securetoken.NewMemoryRevocationStore(), nil)
+	if _, err := noStore.Versions().Version("alice"); err != errNoVersionStore {
+		t.Errorf("Version() without a version store returned %v; expected %s", err, errNoVersionStore)
+	}
+}
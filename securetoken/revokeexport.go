@@ -0,0 +1,79 @@
+package securetoken
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// A RevocationLister lists the IDs in a revocation or once store,
+// such as a MemoryRevocationStore, so that they can be exported.
+type RevocationLister interface {
+	// Each calls f with every ID and the time until which it must be
+	// remembered, until f returns an error.
+	Each(f func(id string, until time.Time) error) error
+}
+
+type revocationLine struct {
+	ID    string    `json:"id"`
+	Until time.Time `json:"until"`
+}
+
+// ExportRevocations writes the IDs listed by l to w as JSON lines of the form
+// {"id":"...","until":"2006-01-02T15:04:05Z"}, e.g. to migrate them to
+// another store or to replicate them to a standby region.
+func ExportRevocations(w io.Writer, l RevocationLister) error {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	if err := l.Each(func(id string, until time.Time) error {
+		return enc.Encode(revocationLine{id, until})
+	}); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// ImportRevocations reads the JSON lines written by ExportRevocations from r
+// and calls add with each ID that has not expired yet, such as the Revoke
+// method of a RevocationStore. It returns the number of IDs added.
+func ImportRevocations(r io.Reader, add func(id string, until time.Time) error) (int, error) {
+	dec := json.NewDecoder(r)
+	now := timeNow()
+	n := 0
+	for line := 1; ; line++ {
+		var l revocationLine
+		if err := dec.Decode(&l); err == io.EOF {
+			return n, nil
+		} else if err != nil {
+			return n, fmt.Errorf("securetoken: revocation %d: %w", line, err)
+		}
+		if l.ID == "" {
+			return n, fmt.Errorf("securetoken: revocation %d has no id", line)
+		}
+		if now.After(l.Until) {
+			continue
+		}
+		if err := add(l.ID, l.Until); err != nil {
+			return n, err
+		}
+		n++
+	}
+}
+
+// Each implements RevocationLister.
+func (s *MemoryRevocationStore) Each(f func(id string, until time.Time) error) error {
+	s.mu.Lock()
+	ids := make(map[string]time.Time, len(s.ids))
+	for id, until := range s.ids {
+		ids[id] = until
+	}
+	s.mu.Unlock()
+	for id, until := range ids {
+		if err := f(id, until); err != nil {
+			return err
+		}
+	}
+	return nil
+}
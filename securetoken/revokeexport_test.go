@@ -0,0 +1,45 @@
+package securetoken
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExportImportRevocations(t *testing.T) {
+	now := time.Unix(1000, 0).UTC()
+	setNow(now)
+	defer restoreNow()
+
+	src := NewMemoryRevocationStore()
+	src.Revoke("a", now.Add(time.Hour))
+	src.Revoke("b", now.Add(2*time.Hour))
+	var buf bytes.Buffer
+	if err := ExportRevocations(&buf, src); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines != 2 {
+		t.Errorf("ExportRevocations() wrote %d lines; expected 2:\n%s", lines, buf.String())
+	}
+	buf.WriteString(`{"id":"expired","until":"1970-01-01T00:00:01Z"}` + "\n")
+
+	dst := NewMemoryRevocationStore()
+	if n, err := ImportRevocations(&buf, dst.Revoke); n != 2 || err != nil {
+		t.Errorf("ImportRevocations() = %d, %v; expected 2, <nil>", n, err)
+	}
+	for id, expected := range map[string]bool{"a": true, "b": true, "expired": false, "c": false} {
+		if revoked, _ := dst.Revoked(id); revoked != expected {
+			t.Errorf("Revoked(%q) = %t after import; expected %t", id, revoked, expected)
+		}
+	}
+	if until := dst.ids["b"]; !until.Equal(now.Add(2 * time.Hour)) {
+		t.Errorf("imported b until %s; expected %s", until, now.Add(2*time.Hour))
+	}
+
+	for _, in := range []string{`{"id":"a","until":"soon"}`, `{"until":"2030-01-01T00:00:00Z"}`, `not json`} {
+		if _, err := ImportRevocations(strings.NewReader(in), dst.Revoke); err == nil {
+			t.Errorf("ImportRevocations(%q) returned <nil>; expected an error", in)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package securetoken
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// A ClaimsSchema describes the valid claims of a purpose.
+// Zero fields are not checked.
+type ClaimsSchema struct {
+	// MaxSize is the largest size of the claims encoded as JSON.
+	MaxSize int
+
+	// Required lists the JSON names of the claims that must be set,
+	// e.g. "sub" and "aud".
+	Required []string
+
+	// Scopes lists the scopes that the claims may have.
+	Scopes []string
+
+	// MaxScopes is the largest number of scopes.
+	MaxScopes int
+
+	// Data validates the application defined data of the claims.
+	Data func(data json.RawMessage) error
+}
+
+// claimIsSet reports whether the claim of c with the given JSON name
+// is set, and whether the name is known.
+func claimIsSet(c *Claims, name string) (set, known bool) {
+	switch name {
+	case "jti":
+		return c.ID != "", true
+	case "sub":
+		return c.Subject != "", true
+	case "aud":
+		return c.Audience != "", true
+	case "pur":
+		return c.Purpose != "", true
+	case "scp":
+		return len(c.Scopes) > 0, true
+	case "ttl":
+		return c.TTL > 0, true
+	case "sv":
+		return c.SessionVersion != 0, true
+	case "amr":
+		return len(c.AuthMethods) > 0, true
+	case "auth_time":
+		return c.AuthTime != 0, true
+	case "act":
+		return c.Actor != nil, true
+	case "cnf":
+		return c.Confirmation != nil, true
+	case "ctx":
+		return len(c.Context) > 0, true
+	case "dat":
+		return len(c.Data) > 0, true
+	}
+	return false, false
+}
+
+// validate returns an error wrapping ErrInvalidClaims if c, whose JSON
+// encoding is size bytes long, does not match s.
+func (s *ClaimsSchema) validate(c *Claims, size int) error {
+	if s.MaxSize > 0 && size > s.MaxSize {
+		return fmt.Errorf("%w: %d bytes is larger than %d", ErrInvalidClaims, size, s.MaxSize)
+	}
+	for _, name := range s.Required {
+		if set, _ := claimIsSet(c, name); !set {
+			return fmt.Errorf("%w: %s is required", ErrInvalidClaims, name)
+		}
+	}
+	if s.MaxScopes > 0 && len(c.Scopes) > s.MaxScopes {
+		return fmt.Errorf("%w: %d scopes is more than %d", ErrInvalidClaims, len(c.Scopes), s.MaxScopes)
+	}
+	if s.Scopes != nil {
+		for _, scope := range c.Scopes {
+			if !slices.Contains(s.Scopes, scope) {
+				return fmt.Errorf("%w: scope %q is not allowed", ErrInvalidClaims, scope)
+			}
+		}
+	}
+	if s.Data != nil {
+		if err := s.Data(c.Data); err != nil {
+			return fmt.Errorf("%w: data: %v", ErrInvalidClaims, err)
+		}
+	}
+	return nil
+}
+
+// WithClaimsSchema returns an Option that makes SealClaims and UnsealClaims
+// reject claims whose Purpose is purpose unless they match s, with an error
+// wrapping ErrInvalidClaims, so that malformed or oversized claims are
+// stopped at the boundary rather than deep in handlers.
+// Uses of WithClaimsSchema and WithClaimsValidator accumulate.
+func WithClaimsSchema(purpose string, s ClaimsSchema) Option {
+	return func(t *Tokener) error {
+		for _, name := range s.Required {
+			if _, known := claimIsSet(&Claims{}, name); !known {
+				return fmt.Errorf("securetoken: unknown claim %q", name)
+			}
+		}
+		s.Required = append([]string(nil), s.Required...)
+		s.Scopes = append([]string(nil), s.Scopes...)
+		if len(s.Scopes) == 0 {
+			s.Scopes = nil
+		}
+		t.addClaimsCheck(purpose, s.validate)
+		return nil
+	}
+}
+
+// WithClaimsValidator is similar to WithClaimsSchema except claims are
+// checked by v. Errors of v that do not wrap ErrInvalidClaims are wrapped.
+func WithClaimsValidator(purpose string, v func(c *Claims) error) Option {
+	return func(t *Tokener) error {
+		t.addClaimsCheck(purpose, func(c *Claims, size int) error {
+			return v(c)
+		})
+		return nil
+	}
+}
+
+// addClaimsCheck adds check to the checks of purpose,
+// copying the checks so that clones do not share them.
+func (t *Tokener) addClaimsCheck(purpose string, check func(c *Claims, size int) error) {
+	schemas := make(map[string][]func(*Claims, int) error, len(t.schemas)+1)
+	for p, checks := range t.schemas {
+		schemas[p] = checks
+	}
+	checks := schemas[purpose]
+	schemas[purpose] = append(checks[:len(checks):len(checks)], check)
+	t.schemas = schemas
+}
+
+// checkClaims runs the checks of the purpose of c,
+// whose JSON encoding is size bytes long.
+func (t *Tokener) checkClaims(c *Claims, size int) error {
+	for _, check := range t.schemas[c.Purpose] {
+		if err := check(c, size); err != nil {
+			if !errors.Is(err, ErrInvalidClaims) {
+				err = fmt.Errorf("%w: %v", ErrInvalidClaims, err)
+			}
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package securetoken
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestClaimsSchema(t *testing.T) {
+	tok, err := NewTokener(key, ttl,
+		WithClaimsSchema("session", ClaimsSchema{
+			MaxSize:   200,
+			Required:  []string{"sub"},
+			Scopes:    []string{"read", "write"},
+			MaxScopes: 2,
+			Data: func(data json.RawMessage) error {
+				if len(data) > 0 && data[0] != '{' {
+					return errors.New("not an object")
+				}
+				return nil
+			},
+		}),
+		WithClaimsValidator("session", func(c *Claims) error {
+			if c.Subject == "root" {
+				return errors.New("root sessions are not allowed")
+			}
+			return nil
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	valid := []*Claims{
+		{Purpose: "session", Subject: "alice", Scopes: []string{"read"}, Data: json.RawMessage(`{"a":1}`)},
+		{Purpose: "other"},
+		{Subject: strings.Repeat("a", 300)},
+	}
+	for _, c := range valid {
+		sealed, err := tok.SealClaims(c)
+		if err != nil {
+			t.Errorf("SealClaims(%+v) returned %v", c, err)
+			continue
+		}
+		if _, err := tok.UnsealClaims(sealed); err != nil {
+			t.Errorf("UnsealClaims(SealClaims(%+v)) returned %v", c, err)
+		}
+	}
+
+	invalid := []*Claims{
+		{Purpose: "session"},
+		{Purpose: "session", Subject: "alice", Scopes: []string{"admin"}},
+		{Purpose: "session", Subject: "alice", Scopes: []string{"read", "write", "read"}},
+		{Purpose: "session", Subject: "alice", Data: json.RawMessage(`[1]`)},
+		{Purpose: "session", Subject: strings.Repeat("a", 300)},
+		{Purpose: "session", Subject: "root"},
+	}
+	for _, c := range invalid {
+		if _, err := tok.SealClaims(c); !errors.Is(err, ErrInvalidClaims) {
+			t.Errorf("SealClaims(%+v) returned %v; expected %s", c, err, ErrInvalidClaims)
+		}
+	}
+
+	// Claims sealed by a Tokener without the schema are checked when unsealed.
+	lax, err := NewTokener(key, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := lax.SealClaims(invalid[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tok.UnsealClaims(sealed); !errors.Is(err, ErrInvalidClaims) {
+		t.Errorf("UnsealClaims() of claims that do not match the schema returned %v; expected %s", err, ErrInvalidClaims)
+	}
+	if _, err := tok.UnsealSubject(sealed); !errors.Is(err, ErrInvalidClaims) {
+		t.Errorf("UnsealSubject() of claims that do not match the schema returned %v; expected %s", err, ErrInvalidClaims)
+	}
+
+	if _, err := NewTokener(key, ttl, WithClaimsSchema("session", ClaimsSchema{Required: []string{"subject"}})); err == nil {
+		t.Error("NewTokener(WithClaimsSchema()) with an unknown claim returned nil error")
+	}
+}
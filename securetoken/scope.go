@@ -0,0 +1,40 @@
+package securetoken
+
+import "errors"
+
+// ErrInsufficientScope is returned by RequireScopes when a token
+// lacks a required scope.
+var ErrInsufficientScope = errors.New("securetoken: insufficient scope")
+
+// HasScope reports whether c has scope.
+func (c *Claims) HasScope(scope string) bool {
+	for _, s := range c.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// RequireScopes returns ErrInsufficientScope unless c has every one of scopes.
+func (c *Claims) RequireScopes(scopes ...string) error {
+	for _, s := range scopes {
+		if !c.HasScope(s) {
+			return ErrInsufficientScope
+		}
+	}
+	return nil
+}
+
+// RequireScopes unseals the claims of token with u and returns them
+// if they have every one of scopes, or ErrInsufficientScope if they do not.
+func RequireScopes(u ClaimsUnsealer, token []byte, scopes ...string) (*Claims, error) {
+	c, err := u.UnsealClaims(token)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.RequireScopes(scopes...); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
@@ -0,0 +1,33 @@
+package securetoken
+
+import "testing"
+
+func TestRequireScopes(t *testing.T) {
+	tok, err := NewTokener(key, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := NewClaims().Subject("svc").Scope("orders:read", "orders:write").Seal(tok)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		scopes []string
+		err    error
+	}{
+		{nil, nil},
+		{[]string{"orders:read"}, nil},
+		{[]string{"orders:read", "orders:write"}, nil},
+		{[]string{"orders:read", "admin"}, ErrInsufficientScope},
+		{[]string{"orders"}, ErrInsufficientScope},
+	}
+	for _, test := range tests {
+		c, err := RequireScopes(tok, sealed, test.scopes...)
+		if err != test.err || (err == nil && c.Subject != "svc") {
+			t.Errorf("RequireScopes(%q) = %+v, %v; expected %v", test.scopes, c, err, test.err)
+		}
+	}
+	if _, err := RequireScopes(tok, []byte("forged"), "orders:read"); err != ErrTokenInvalid {
+		t.Errorf("RequireScopes(\"forged\") returned %v; expected %s", err, ErrTokenInvalid)
+	}
+}
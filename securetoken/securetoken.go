@@ -1,131 +1,451 @@
 // Package securetoken implements cryptographically secure tokens
 // that provide data confidentiality, integrity, and expiration.
+//
+// Tokens are sealed with symmetric AEAD keys, so any holder of a key can
+// both unseal and seal tokens with it; there is no verify-only key.
+// Services that must accept tokens without being able to issue them,
+// such as edge nodes, should send tokens to a service that holds the keys
+// rather than receive the keys themselves.
 package securetoken
 
 import (
-	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
 	"encoding/base64"
 	"encoding/binary"
 	"errors"
 	"io"
+	"log/slog"
 	"time"
 )
 
-var sealVersion uint8 = 1
-
 // Alias time.Now for testability.
 var timeNow = time.Now
 
 var (
-	errTokenInvalid = errors.New("securetoken: token invalid")
-	errTokenExpired = errors.New("securetoken: token expired")
+	// ErrTokenInvalid is returned by Unseal when a token is malformed
+	// or was not sealed by a known key.
+	ErrTokenInvalid = errors.New("securetoken: token invalid")
+
+	// ErrTokenExpired is returned by Unseal when a token is older than the ttl.
+	ErrTokenExpired = errors.New("securetoken: token expired")
+
+	// ErrTokenTooLong is returned by Unseal when a token is longer than
+	// the maximum length of the Tokener. The token is not decoded.
+	ErrTokenTooLong = errors.New("securetoken: token too long")
+
+	// ErrVersionRejected is returned by Unseal when a token is well formed
+	// but its version is older than the minimum accepted version.
+	ErrVersionRejected = errors.New("securetoken: token version rejected")
 )
 
+// A Sealer seals plaintext into tokens.
+type Sealer interface {
+	Seal(plaintext []byte) ([]byte, error)
+}
+
+// An Unsealer unseals tokens produced by a Sealer.
+type Unsealer interface {
+	Unseal(sealed []byte, opts ...UnsealOption) ([]byte, error)
+}
+
+// A SealUnsealer both seals and unseals tokens.
+// Code that issues and verifies tokens should depend on this interface
+// rather than on *Tokener so that fakes can be substituted in tests.
+type SealUnsealer interface {
+	Sealer
+	Unsealer
+}
+
+var _ SealUnsealer = (*Tokener)(nil)
+
 // A Tokener encodes and decodes tokens.
 // It is goroutine safe.
 type Tokener struct {
-	aead     cipher.AEAD
-	encoding *base64.Encoding
-	ttl      time.Duration
+	keys       *Keyring
+	version    uint8
+	encoding   Encoding
+	ttl        time.Duration
+	softTTL    time.Duration
+	leeway     time.Duration
+	minVersion uint8
+	clock      func() time.Time
+	random     io.Reader
+	nonces     NonceSource
+	limiter    *FailureLimiter
+	purpose    string
+	checkKey   bool
+	autoAEAD   bool
+	selfTest   bool
+	lenient    bool
+	embedTTL   bool
+	truncate   time.Duration
+	encContext map[string]string
+	ttlPolicy  TTLPolicy
+	region     Region
+	corrKey    []byte
+	padding    int
+	schemas    map[string][]func(*Claims, int) error
+	maxLength  int
+	canaryHook func(*Claims)
+	auditHook  func(*Claims)
+	logger     *slog.Logger
+	revoked    RevocationStore
+	adCache    *adCache
+	live       *livePolicy
+	health     []HealthChecker
+	quota      *IssuanceQuota
+	metrics    func(TokenEvent)
+	breakGlass *breakGlassPolicy
+
+	beforeSeal  []func(*Claims) error
+	afterUnseal []func(*Claims, *RequestInfo) error
 }
 
 // NewTokener returns a Tokener that seals and unseals tokens.
 // key is a cryptographic key that must be either 16, 24, or 32 bytes.
 // ttl is the duration that tokens are valid.
-func NewTokener(key []byte, ttl time.Duration) (*Tokener, error) {
-	c, err := aes.NewCipher(key)
+// opts are applied in order after the cipher has been set up.
+func NewTokener(key []byte, ttl time.Duration, opts ...Option) (*Tokener, error) {
+	kr := NewKeyring()
+	if err := kr.AddKey(0, key); err != nil {
+		return nil, err
+	}
+	t, err := newTokener(kr, Version1, ttl, opts)
 	if err != nil {
 		return nil, err
 	}
-	aead, err := cipher.NewGCM(c)
+	if t.autoAEAD {
+		if err := addAutoKeys(kr, key); err != nil {
+			return nil, err
+		}
+		t.version = Version2
+	}
+	if err := t.checkOptions(); err != nil {
+		return nil, err
+	}
+	if t.checkKey {
+		if err := CheckKey(key); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// NewKeyringTokener returns a Tokener that seals version 2 tokens
+// with the primary key of kr and unseals tokens sealed with any key in kr.
+// Version 1 tokens produced by NewTokener are unsealed with key 0,
+// so a Tokener created by NewTokener can be migrated to a keyring
+// by adding its key with id 0.
+// ttl is the duration that tokens are valid.
+func NewKeyringTokener(kr *Keyring, ttl time.Duration, opts ...Option) (*Tokener, error) {
+	if _, _, err := kr.primaryKey(); err != nil {
+		return nil, err
+	}
+	t, err := newTokener(kr, Version2, ttl, opts)
 	if err != nil {
 		return nil, err
 	}
-	return &Tokener{aead, base64.URLEncoding, ttl}, nil
+	if t.autoAEAD {
+		return nil, errAutoAEADKeyring
+	}
+	if t.checkKey {
+		return nil, errKeyCheckKeyring
+	}
+	if err := t.checkOptions(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func newTokener(kr *Keyring, version uint8, ttl time.Duration, opts []Option) (*Tokener, error) {
+	t := &Tokener{
+		keys:       kr,
+		version:    version,
+		encoding:   base64.URLEncoding,
+		ttl:        ttl,
+		minVersion: Version1,
+		nonces:     NewRandomNonceSource(rand.Reader),
+		adCache:    &adCache{},
+	}
+	for _, opt := range opts {
+		if err := opt(t); err != nil {
+			return nil, err
+		}
+	}
+	t.publishPolicy()
+	if t.logger != nil {
+		t.logger.Info("securetoken: tokener created", "tokener", t)
+	}
+	return t, nil
 }
 
 // SealString is similar to Seal except its input is a string
-// and it returns a string.
+// and it returns a string. The string is empty if and only if
+// the error is not nil.
 func (t *Tokener) SealString(plaintext string) (string, error) {
 	tok, err := t.Seal([]byte(plaintext))
-	return string(tok), err
+	if err != nil {
+		return "", err
+	}
+	return string(tok), nil
 }
 
 // Seal encrypts plaintext in a way that provides confidentiality,
 // data integrity, and expiration.
 func (t *Tokener) Seal(plaintext []byte) ([]byte, error) {
-	tok := make([]byte, 0, t.sealedLength(plaintext, false))
-	tok = append(tok, sealVersion)
-	tok, err := t.appendNonce(tok)
+	return t.SealAAD(plaintext, nil)
+}
+
+// SealAAD is similar to Seal except the token is also bound to aad,
+// additional data that is authenticated but not stored in the token.
+// The token can only be unsealed by passing the same aad to WithAAD,
+// e.g. to bind a token to the resource that it was issued for.
+func (t *Tokener) SealAAD(plaintext, aad []byte) ([]byte, error) {
+	// The raw and encoded token share one buffer.
+	return t.AppendSeal(nil, plaintext, aad)
+}
+
+// UnsealString is similar to Unseal except its input is a string
+// and it returns a string. The string is empty if the error is not nil,
+// so it never holds part of an invalid token.
+func (t *Tokener) UnsealString(encoded string, opts ...UnsealOption) (string, error) {
+	buf, err := t.Unseal([]byte(encoded), opts...)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-	tok = t.aead.Seal(tok, tok[1:], plaintext, nil)
-	return t.encode(tok), nil
+	return string(buf), nil
 }
 
-// UnsealString is similar to Unseal except its input is a string
-// and it returns a string.
-func (t *Tokener) UnsealString(encoded string) (string, error) {
-	buf, err := t.Unseal([]byte(encoded))
-	return string(buf), err
+// UnsealStringOr is similar to UnsealString except it returns def
+// instead of an error, for callers that treat every invalid token alike,
+// e.g. to fall back to a default preference.
+func (t *Tokener) UnsealStringOr(encoded, def string, opts ...UnsealOption) string {
+	s, err := t.UnsealString(encoded, opts...)
+	if err != nil {
+		return def
+	}
+	return s
 }
 
 // Unseal decrypts and verifies the ciphertext produced by Seal.
 // It returns an error if sealed bytes are invalid or if the
 // timestamp is older than the ttl.
-func (t *Tokener) Unseal(sealed []byte) ([]byte, error) {
-	decoded, err := t.decode(sealed)
-	if err != nil {
+//
+// Malformed tokens and tokens sealed with an unknown key take about as long
+// to reject as authentic tokens take to open, and the version and expiry of
+// a token are only checked once it has been authenticated, so neither the
+// timing nor the error reveals anything about forged tokens.
+//
+// opts adjust the checks made for this call only.
+func (t *Tokener) Unseal(sealed []byte, opts ...UnsealOption) ([]byte, error) {
+	return t.UnsealFor("", sealed, opts...)
+}
+
+// UnsealFor is similar to Unseal except failures are also counted
+// against caller, which identifies the client (e.g. its IP address),
+// by the FailureLimiter of the Tokener.
+func (t *Tokener) UnsealFor(caller string, sealed []byte, opts ...UnsealOption) ([]byte, error) {
+	cfg := newUnsealConfig(opts)
+	if err := cfg.needsClaims(); err != nil {
 		return nil, err
 	}
-	if len(decoded) < t.sealedLength(nil, false) {
-		return nil, errTokenInvalid
+	plaintext, _, err := t.unsealFor(caller, sealed, cfg)
+	t.logUnseal(caller, len(sealed), nil, err)
+	t.reportUnseal(nil, cfg, len(sealed), err)
+	return plaintext, err
+}
+
+// unsealFor is similar to UnsealFor except it also returns
+// the structural fields of the token.
+func (t *Tokener) unsealFor(caller string, sealed []byte, cfg *unsealConfig) ([]byte, *RawToken, error) {
+	if t.limiter == nil {
+		return t.unseal(sealed, cfg)
 	}
-	ver, nc := decoded[0], decoded[1:]
-	if ver != 1 {
-		return nil, errTokenInvalid
+	now := t.now()
+	if !t.limiter.allow(caller, now) {
+		return nil, nil, ErrRateLimited
 	}
-	nonce, ciphertext := nc[:t.aead.NonceSize()], nc[t.aead.NonceSize():]
-	ts := getTimestamp(nonce)
-	if err := t.checkTTL(ts); err != nil {
-		return nil, err
+	plaintext, raw, err := t.unseal(sealed, cfg)
+	if err != nil {
+		t.limiter.fail(caller, now)
 	}
-	return t.aead.Open(nil, nonce, ciphertext, nil)
+	return plaintext, raw, err
 }
 
-// sealedLength returns the number of bytes required to seal plaintext.
+func (t *Tokener) unseal(sealed []byte, cfg *unsealConfig) ([]byte, *RawToken, error) {
+	if t.maxLength > 0 && len(sealed) > t.maxLength {
+		return nil, nil, ErrTokenTooLong
+	}
+	if t.lenient {
+		sealed = clean(sealed)
+	}
+	dst, decoded, err := t.decodeAppend(cfg.dst, sealed)
+	if err != nil {
+		t.openDummy(len(sealed))
+		return nil, nil, ErrTokenInvalid
+	}
+	aead, raw := t.parse(decoded)
+	if raw == nil {
+		t.openDummy(len(decoded))
+		return nil, nil, ErrTokenInvalid
+	}
+	if dst == nil {
+		// The decoded token is a new buffer, so open it in place.
+		dst = raw.Ciphertext[:0]
+	}
+	plaintext, err := aead.Open(dst, raw.Nonce, raw.Ciphertext, t.additionalData(raw.Version, raw.Header, cfg.aad))
+	if err != nil {
+		return nil, nil, ErrTokenInvalid
+	}
+	plaintext, ok := t.unpad(plaintext, len(dst))
+	if !ok {
+		return nil, nil, ErrTokenInvalid
+	}
+	cfg.payloadLen = len(plaintext) - len(dst)
+	if cfg.claims && t.tripCanary(plaintext, raw) {
+		return nil, nil, ErrCanary
+	}
+	p := t.policy()
+	if raw.Version < p.minVersion {
+		return nil, nil, ErrVersionRejected
+	}
+	now := t.now()
+	if err := cfg.checkAge(now, raw.Timestamp, p.ttl+p.leeway); err != nil {
+		return nil, nil, err
+	}
+	cfg.setStale(p.softTTL > 0 && now.Sub(raw.Timestamp) > p.softTTL)
+	return plaintext, raw, nil
+}
+
+// parse returns the AEAD and structural fields of a decoded token,
+// or nil if it is malformed or its key is unknown.
+func (t *Tokener) parse(decoded []byte) (cipher.AEAD, *RawToken) {
+	_, id, err := parseHeader(decoded)
+	if err != nil || (t.region != 0 && t.keys.regionOf(id) != t.region) {
+		return nil, nil
+	}
+	aead := t.keys.lookup(id, t.now())
+	if aead == nil {
+		return nil, nil
+	}
+	raw, err := ParseRaw(decoded, aead.NonceSize())
+	if err != nil || len(raw.Ciphertext) < aead.Overhead() {
+		return nil, nil
+	}
+	return aead, raw
+}
+
+// openDummy spends about as long as opening a token of n bytes does.
+func (t *Tokener) openDummy(n int) {
+	_, aead, err := t.keys.primaryKey()
+	if err != nil {
+		return
+	}
+	if size := aead.NonceSize() + aead.Overhead(); n < size {
+		n = size
+	}
+	buf := make([]byte, n)
+	aead.Open(nil, buf[:aead.NonceSize()], buf[aead.NonceSize():], nil)
+}
+
+// sealKey returns the id and AEAD of the key to seal a token with,
+// counting the seal against the key limits and the issuance quota.
+func (t *Tokener) sealKey() (uint32, cipher.AEAD, error) {
+	now := t.now()
+	id, aead, err := t.keys.sealKey(now, t.purpose)
+	if err != nil {
+		return 0, nil, err
+	}
+	if t.region != 0 && t.keys.regionOf(id) != t.region {
+		return 0, nil, ErrWrongRegion
+	}
+	if t.quota != nil && !t.quota.take(t.purpose, id, now) {
+		return 0, nil, ErrQuotaExceeded
+	}
+	return id, aead, nil
+}
+
+// appendHeader appends the token header for the key with the given id to dst.
+func (t *Tokener) appendHeader(dst []byte, id uint32) []byte {
+	dst = append(dst, t.version)
+	if t.version >= Version2 {
+		dst = append(dst, byte(id>>24), byte(id>>16), byte(id>>8), byte(id))
+	}
+	return dst
+}
+
+// additionalData returns the data authenticated along with version ver tokens,
+// which is the header followed by the purpose of the Tokener (marked if the
+// Tokener pads, see WithPadding) and aad.
+// Version 1 tokens do not authenticate their header.
+func (t *Tokener) additionalData(ver uint8, header, aad []byte) []byte {
+	if ver < Version2 {
+		header = nil
+	}
+	purpose := t.purpose
+	if t.padding > 0 {
+		purpose += paddedMarker
+	}
+	if purpose == "" && len(aad) == 0 {
+		return header
+	}
+	if len(aad) == 0 && t.adCache != nil {
+		return t.adCache.get(header, purpose)
+	}
+	ad := make([]byte, 0, len(header)+len(purpose)+len(aad))
+	ad = append(ad, header...)
+	ad = append(ad, purpose...)
+	return append(ad, aad...)
+}
+
+// sealedLength returns the number of bytes required to seal plaintext
+// with the primary key.
 func (t *Tokener) sealedLength(plaintext []byte, encoded bool) int {
-	length := 1 + t.aead.NonceSize() + len(plaintext) + t.aead.Overhead()
+	_, aead, err := t.keys.primaryKey()
+	if err != nil {
+		return 0
+	}
+	return t.sealedLengthWith(aead, plaintext, encoded)
+}
+
+// sealedLengthWith returns the number of bytes required to seal plaintext with aead.
+func (t *Tokener) sealedLengthWith(aead cipher.AEAD, plaintext []byte, encoded bool) int {
+	length := HeaderLength(t.version) + aead.NonceSize() + len(plaintext) + aead.Overhead()
 	if encoded {
 		length = t.encoding.EncodedLen(length)
 	}
 	return length
 }
 
-// appendNonce appends a nonce to dst and returns the new slice.
-func (t *Tokener) appendNonce(dst []byte) ([]byte, error) {
-	nonce := dst[len(dst) : len(dst)+t.aead.NonceSize()]
-	putTimestamp(nonce[:8])
-	err := putRandom(nonce[8:])
-	return dst[:len(dst)+t.aead.NonceSize()], err
-}
-
-func putTimestamp(dst []byte) {
-	now := timeNow().UnixNano()
-	binary.LittleEndian.PutUint64(dst, uint64(now))
+// appendNonce appends a nonce of the given size to dst and returns the new slice.
+func (t *Tokener) appendNonce(dst []byte, size int) ([]byte, error) {
+	nonce := dst[len(dst) : len(dst)+size]
+	now, err := t.timestamp(t.now())
+	if err != nil {
+		return nil, err
+	}
+	if err := t.nonces.PutNonce(nonce, now); err != nil {
+		return nil, err
+	}
+	if int64(binary.LittleEndian.Uint64(nonce)) != now.UnixNano() {
+		return nil, errNonceTimestamp
+	}
+	return dst[:len(dst)+size], nil
 }
 
-func getTimestamp(buf []byte) int64 {
-	return int64(binary.LittleEndian.Uint64(buf[:8]))
+// Now returns the current time according to the clock of t (see WithClock),
+// for code that must agree with t on when tokens expire.
+func (t *Tokener) Now() time.Time {
+	return t.now()
 }
 
-// putRandom fills dst with random bytes.
-func putRandom(dst []byte) error {
-	_, err := io.ReadFull(rand.Reader, dst)
-	return err
+// now returns the current time according to the Tokener's clock.
+func (t *Tokener) now() time.Time {
+	if t.clock != nil {
+		return t.clock()
+	}
+	return timeNow()
 }
 
 func (t *Tokener) encode(src []byte) []byte {
@@ -139,11 +459,3 @@ func (t *Tokener) decode(src []byte) ([]byte, error) {
 	n, err := t.encoding.Decode(buf, src)
 	return buf[:n], err
 }
-
-// checkTTL returns an error if ts older than the ttl.
-func (t *Tokener) checkTTL(ts int64) error {
-	if timeNow().Add(-t.ttl).UnixNano() > ts {
-		return errTokenExpired
-	}
-	return nil
-}
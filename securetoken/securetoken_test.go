@@ -107,7 +107,7 @@ func TestUnsealValidTokens(t *testing.T) {
 	}
 }
 
-// TestUnsealExpiredToken tests that Unseal returns errTokenExpired
+// TestUnsealExpiredToken tests that Unseal returns ErrTokenExpired
 // if the token is older than its ttl.
 func TestUnsealExpiredToken(t *testing.T) {
 	setNow(time.Unix(1, 0))
@@ -123,13 +123,13 @@ func TestUnsealExpiredToken(t *testing.T) {
 	setNow(timeNow().Add(ttl + 1*time.Nanosecond))
 
 	unsealed, err := tok.Unseal(token)
-	if unsealed != nil || err != errTokenExpired {
-		t.Fatalf("Unseal(%q) = %q, %s; expected <nil>, %s", token, unsealed, err, errTokenExpired)
+	if unsealed != nil || err != ErrTokenExpired {
+		t.Fatalf("Unseal(%q) = %q, %s; expected <nil>, %s", token, unsealed, err, ErrTokenExpired)
 	}
 }
 
 // TestUnsealInvalidToken tests that Unseal returns
-// errTokenInvalid for invalid tokens.
+// ErrTokenInvalid for invalid tokens.
 func TestUnsealInvalidToken(t *testing.T) {
 	setNow(time.Unix(1, 0))
 	defer restoreNow()
@@ -155,6 +155,12 @@ func TestUnsealInvalidToken(t *testing.T) {
 			t.Errorf("Unseal(%q) = %q, %s; expected nil, error", token, data, err)
 			continue
 		}
+		if s, err := tok.UnsealString(token); s != "" || err == nil {
+			t.Errorf("UnsealString(%q) = %q, %v; expected \"\", error", token, s, err)
+		}
+		if s := tok.UnsealStringOr(token, "default"); s != "default" {
+			t.Errorf("UnsealStringOr(%q, %q) = %q; expected %q", token, "default", s, "default")
+		}
 	}
 }
 
@@ -197,3 +203,34 @@ func BenchmarkUnseal(b *testing.B) {
 		}
 	}
 }
+
+// TestUnsealErrorOrdering tests that a forged token is reported as invalid
+// even if its timestamp would make it expired.
+func TestUnsealErrorOrdering(t *testing.T) {
+	setNow(time.Unix(1, 0))
+	defer restoreNow()
+
+	tok, err := NewTokener(key, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := tok.Seal([]byte("data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := tok.decode(sealed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded[len(decoded)-1] ^= 1
+	forged := tok.encode(decoded)
+
+	setNow(timeNow().Add(ttl + 1*time.Nanosecond))
+
+	if data, err := tok.Unseal(forged); data != nil || err != ErrTokenInvalid {
+		t.Errorf("Unseal(%q) = %q, %v; expected <nil>, %s", forged, data, err, ErrTokenInvalid)
+	}
+	if data, err := tok.Unseal(sealed); data != nil || err != ErrTokenExpired {
+		t.Errorf("Unseal(%q) = %q, %v; expected <nil>, %s", sealed, data, err, ErrTokenExpired)
+	}
+}
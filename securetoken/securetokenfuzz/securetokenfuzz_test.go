@@ -0,0 +1,26 @@
+package securetokenfuzz_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nicksnyder/go-securetoken/securetoken"
+	"github.com/nicksnyder/go-securetoken/securetoken/securetokenfuzz"
+)
+
+func FuzzUnseal(f *testing.F)       { securetokenfuzz.FuzzUnseal(f) }
+func FuzzUnsealClaims(f *testing.F) { securetokenfuzz.FuzzUnsealClaims(f) }
+func FuzzParseRaw(f *testing.F)     { securetokenfuzz.FuzzParseRaw(f) }
+func FuzzDecode(f *testing.F)       { securetokenfuzz.FuzzDecode(f) }
+
+func TestVectors(t *testing.T) {
+	for _, v := range securetokenfuzz.Vectors {
+		tok, err := securetoken.NewTokener(v.Key, time.Minute, securetoken.WithClock(func() time.Time { return v.Time }))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p, err := tok.UnsealString(v.Token); p != v.Plaintext || err != nil {
+			t.Errorf("UnsealString(%q) = %q, %v; expected %q, <nil>", v.Token, p, err, v.Plaintext)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+// Package securetokentest provides utilities for testing code that uses securetoken.
+//
+// Tokens sealed by a Tokener from this package are deterministic:
+// the same sequence of calls always produces the same tokens,
+// so they can be compared against golden values.
+package securetokentest
+
+import (
+	"bytes"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nicksnyder/go-securetoken/securetoken"
+)
+
+// Key is the key used by Tokeners from this package.
+// It is public knowledge and must never be used outside of tests.
+var Key = []byte("securetokentest!")
+
+// Now is the initial time of the Clock of Tokeners from this package.
+var Now = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// TTL is the ttl of Tokeners from this package.
+const TTL = 1 * time.Hour
+
+// Seed seeds the nonce source of Tokeners from this package.
+const Seed = 1
+
+// A Clock is a manually advanced clock.
+// It is goroutine safe.
+type Clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewClock returns a Clock set to now.
+func NewClock(now time.Time) *Clock {
+	return &Clock{now: now}
+}
+
+// Now returns the current time of the Clock.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance moves the Clock forward by d.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// Set sets the Clock to now.
+func (c *Clock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = now
+}
+
+// A Tokener is a deterministic securetoken.Tokener
+// together with the Clock that it reads.
+type Tokener struct {
+	*securetoken.Tokener
+	Clock *Clock
+}
+
+// NewTokener returns a deterministic Tokener that uses Key and TTL,
+// a Clock set to Now, and a nonce source seeded with Seed.
+// opts are applied after the options that make it deterministic.
+// It fails the test if the Tokener can not be created.
+func NewTokener(tb testing.TB, opts ...securetoken.Option) *Tokener {
+	tb.Helper()
+	clock := NewClock(Now)
+	opts = append([]securetoken.Option{
+		securetoken.WithClock(clock.Now),
+		securetoken.WithRandom(&lockedReader{r: rand.New(rand.NewSource(Seed))}),
+	}, opts...)
+	tok, err := securetoken.NewTokener(Key, TTL, opts...)
+	if err != nil {
+		tb.Fatalf("securetokentest: %s", err)
+	}
+	return &Tokener{Tokener: tok, Clock: clock}
+}
+
+// Golden returns the token that a new Tokener from this package
+// seals for plaintext as its first token.
+func Golden(tb testing.TB, plaintext []byte) []byte {
+	tb.Helper()
+	return Seal(tb, NewTokener(tb), plaintext)
+}
+
+// GenerateToken returns a token for plaintext sealed with key as if it
+// were issued at issued, which may be in the past or the future.
+// It does not change any global state, so it is safe to use in parallel tests.
+// opts are applied after the option that sets the clock.
+func GenerateToken(tb testing.TB, key, plaintext []byte, issued time.Time, opts ...securetoken.Option) []byte {
+	tb.Helper()
+	opts = append([]securetoken.Option{
+		securetoken.WithClock(func() time.Time { return issued }),
+	}, opts...)
+	tok, err := securetoken.NewTokener(key, TTL, opts...)
+	if err != nil {
+		tb.Fatalf("securetokentest: %s", err)
+	}
+	return Seal(tb, tok, plaintext)
+}
+
+// Seal seals plaintext with tok and fails the test on error.
+func Seal(tb testing.TB, tok securetoken.Sealer, plaintext []byte) []byte {
+	tb.Helper()
+	sealed, err := tok.Seal(plaintext)
+	if err != nil {
+		tb.Fatalf("Seal(%q) returned error: %s", plaintext, err)
+	}
+	return sealed
+}
+
+// AssertUnseals fails the test unless tok unseals sealed to expected.
+func AssertUnseals(tb testing.TB, tok securetoken.Unsealer, sealed, expected []byte) {
+	tb.Helper()
+	data, err := tok.Unseal(sealed)
+	if err != nil {
+		tb.Errorf("Unseal(%q) returned error: %s", sealed, err)
+		return
+	}
+	if !bytes.Equal(data, expected) {
+		tb.Errorf("Unseal(%q) = %q; expected %q", sealed, data, expected)
+	}
+}
+
+// AssertExpired fails the test unless tok rejects sealed as expired.
+func AssertExpired(tb testing.TB, tok securetoken.Unsealer, sealed []byte) {
+	tb.Helper()
+	AssertError(tb, tok, sealed, securetoken.ErrTokenExpired)
+}
+
+// AssertInvalid fails the test unless tok rejects sealed as invalid.
+func AssertInvalid(tb testing.TB, tok securetoken.Unsealer, sealed []byte) {
+	tb.Helper()
+	if data, err := tok.Unseal(sealed); data != nil || err == nil || err == securetoken.ErrTokenExpired {
+		tb.Errorf("Unseal(%q) = %q, %v; expected <nil>, invalid token error", sealed, data, err)
+	}
+}
+
+// AssertError fails the test unless tok rejects sealed with expected.
+func AssertError(tb testing.TB, tok securetoken.Unsealer, sealed []byte, expected error) {
+	tb.Helper()
+	if data, err := tok.Unseal(sealed); data != nil || err != expected {
+		tb.Errorf("Unseal(%q) = %q, %v; expected <nil>, %s", sealed, data, err, expected)
+	}
+}
+
+// lockedReader makes a math/rand source safe for concurrent use.
+type lockedReader struct {
+	mu sync.Mutex
+	r  *rand.Rand
+}
+
+func (l *lockedReader) Read(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.Read(p)
+}
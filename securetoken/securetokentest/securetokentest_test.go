@@ -0,0 +1,52 @@
+package securetokentest
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestDeterministic(t *testing.T) {
+	a := NewTokener(t)
+	b := NewTokener(t)
+	for _, data := range []string{"", "one", "two"} {
+		x, y := Seal(t, a, []byte(data)), Seal(t, b, []byte(data))
+		if !bytes.Equal(x, y) {
+			t.Errorf("Seal(%q) = %q and %q; expected identical tokens", data, x, y)
+		}
+	}
+	if x, y := Golden(t, []byte("data")), Golden(t, []byte("data")); !bytes.Equal(x, y) {
+		t.Errorf("Golden() = %q and %q; expected identical tokens", x, y)
+	}
+}
+
+func TestAssertions(t *testing.T) {
+	tok := NewTokener(t)
+	sealed := Seal(t, tok, []byte("data"))
+	AssertUnseals(t, tok, sealed, []byte("data"))
+	AssertInvalid(t, tok, sealed[1:])
+
+	tok.Clock.Advance(TTL + 1)
+	AssertExpired(t, tok, sealed)
+}
+
+func TestGenerateToken(t *testing.T) {
+	tok := NewTokener(t)
+	tests := []struct {
+		issued  time.Time
+		expired bool
+	}{
+		{Now, false},
+		{Now.Add(-TTL + time.Second), false},
+		{Now.Add(-TTL - time.Second), true},
+		{Now.Add(time.Hour), false},
+	}
+	for _, test := range tests {
+		sealed := GenerateToken(t, Key, []byte("data"), test.issued)
+		if test.expired {
+			AssertExpired(t, tok, sealed)
+		} else {
+			AssertUnseals(t, tok, sealed, []byte("data"))
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package securetoken
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+)
+
+// An aeadKAT is a known-answer test of an AEAD.
+type aeadKAT struct {
+	name                              string
+	new                               func(key []byte) (cipher.AEAD, error)
+	key, nonce, ad, plaintext, sealed string
+}
+
+// aeadKATs are the known-answer tests that SelfTest runs. The first is
+// test case 2 of the GCM specification: the all zero 128 bit key, nonce,
+// and plaintext. selftest_xcrypto.go adds ChaCha20-Poly1305.
+var aeadKATs = []aeadKAT{{
+	name:      "AES-GCM",
+	new:       newAESGCM,
+	key:       "00000000000000000000000000000000",
+	nonce:     "000000000000000000000000",
+	plaintext: "00000000000000000000000000000000",
+	sealed:    "0388dace60b6a392f328c2b971b2fe78ab6e47d42cec13bdf53a67b21257bddf",
+}}
+
+// SelfTest checks that the Tokener can safely seal tokens.
+// It checks that crypto/rand (or the source given to WithRandom)
+// returns distinct, non-zero output, runs a known-answer test of
+// AES-GCM (and ChaCha20-Poly1305 when built with the xcrypto tag),
+// and checks that each of these AEADs, and the AEAD of every key in the
+// keyring, round trips a message under a throwaway key and rejects a
+// tampered one. AEADs given to Keyring.Add can not be made with another
+// key, so they are checked under their own key.
+// It never seals through the keyring, so it does not count against key limits.
+func (t *Tokener) SelfTest() error {
+	r := t.random
+	if r == nil {
+		r = rand.Reader
+	}
+	if err := testRandom(r); err != nil {
+		return err
+	}
+	for _, kat := range aeadKATs {
+		if err := testKAT(kat); err != nil {
+			return err
+		}
+	}
+	for id, e := range t.keys.load().keys {
+		if err := testKeyEntry(e); err != nil {
+			return fmt.Errorf("securetoken: key %d self test failed: %s", id, err)
+		}
+	}
+	return nil
+}
+
+// WithSelfTest returns an Option that makes NewTokener (or Clone) fail
+// if SelfTest fails. SelfTest runs once all options have been applied,
+// so it checks the random source and keys that they configure.
+func WithSelfTest() Option {
+	return func(t *Tokener) error {
+		t.selfTest = true
+		return nil
+	}
+}
+
+// testKeyEntry runs a round trip with the AEAD of e under a throwaway key.
+func testKeyEntry(e *keyEntry) error {
+	if e.newAEAD == nil {
+		return testRoundTrip(e.aead)
+	}
+	key := make([]byte, e.keySize)
+	if _, err := rand.Read(key); err != nil {
+		return err
+	}
+	aead, err := e.newAEAD(key)
+	if err != nil {
+		return err
+	}
+	return testRoundTrip(aead)
+}
+
+func testRandom(r io.Reader) error {
+	a, b := make([]byte, 32), make([]byte, 32)
+	if _, err := io.ReadFull(r, a); err != nil {
+		return fmt.Errorf("securetoken: random source failed: %s", err)
+	}
+	if _, err := io.ReadFull(r, b); err != nil {
+		return fmt.Errorf("securetoken: random source failed: %s", err)
+	}
+	if bytes.Equal(a, b) || bytes.Equal(a, make([]byte, len(a))) {
+		return fmt.Errorf("securetoken: random source is not random")
+	}
+	return nil
+}
+
+// testKAT runs kat and then a round trip under a random key.
+func testKAT(kat aeadKAT) error {
+	key, _ := hex.DecodeString(kat.key)
+	nonce, _ := hex.DecodeString(kat.nonce)
+	ad, _ := hex.DecodeString(kat.ad)
+	plaintext, _ := hex.DecodeString(kat.plaintext)
+	aead, err := kat.new(key)
+	if err != nil {
+		return err
+	}
+	if sealed := hex.EncodeToString(aead.Seal(nil, nonce, plaintext, ad)); sealed != kat.sealed {
+		return fmt.Errorf("securetoken: %s known answer test failed", kat.name)
+	}
+
+	if _, err := rand.Read(key); err != nil {
+		return err
+	}
+	if aead, err = kat.new(key); err != nil {
+		return err
+	}
+	if err := testRoundTrip(aead); err != nil {
+		return fmt.Errorf("securetoken: %s self test failed: %s", kat.name, err)
+	}
+	return nil
+}
+
+func testRoundTrip(aead cipher.AEAD) error {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	plaintext := []byte("securetoken self test")
+	ad := []byte("additional data")
+	sealed := aead.Seal(nil, nonce, plaintext, ad)
+	if bytes.Contains(sealed, plaintext) {
+		return fmt.Errorf("ciphertext contains plaintext")
+	}
+	opened, err := aead.Open(nil, nonce, sealed, ad)
+	if err != nil || !bytes.Equal(opened, plaintext) {
+		return fmt.Errorf("round trip failed")
+	}
+	sealed[0] ^= 1
+	if _, err := aead.Open(nil, nonce, sealed, ad); err == nil {
+		return fmt.Errorf("tampered message accepted")
+	}
+	return nil
+}
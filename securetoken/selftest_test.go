@@ -0,0 +1,79 @@
+package securetoken
+
+import (
+	"crypto/cipher"
+	"testing"
+)
+
+func TestSelfTest(t *testing.T) {
+	tok, err := NewTokener(key, ttl, WithSelfTest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tok.SelfTest(); err != nil {
+		t.Error(err)
+	}
+}
+
+// brokenAEAD does not encrypt or authenticate.
+type brokenAEAD struct {
+	cipher.AEAD
+}
+
+func (brokenAEAD) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	return append(append(dst, plaintext...), make([]byte, 16)...)
+}
+
+func (brokenAEAD) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	return append(dst, ciphertext[:len(ciphertext)-16]...), nil
+}
+
+func TestTestRoundTripBrokenAEAD(t *testing.T) {
+	if err := testRoundTrip(brokenAEAD{newGCM16(t, key)}); err == nil {
+		t.Errorf("testRoundTrip(brokenAEAD) returned nil error")
+	}
+}
+
+func TestTestKAT(t *testing.T) {
+	kat := aeadKATs[0]
+	kat.sealed = "00" + kat.sealed[2:]
+	if err := testKAT(kat); err == nil {
+		t.Errorf("testKAT() with a wrong answer returned nil error")
+	}
+}
+
+func TestTestRandom(t *testing.T) {
+	if err := testRandom(zeroReader{}); err == nil {
+		t.Errorf("testRandom(zeroReader) returned nil error")
+	}
+}
+
+func TestWithSelfTestOrder(t *testing.T) {
+	// The self test runs after WithRandom even when it is given first.
+	if _, err := NewTokener(key, ttl, WithSelfTest(), WithRandom(zeroReader{})); err == nil {
+		t.Error("NewTokener(WithSelfTest(), WithRandom(zeroReader)) returned <nil>")
+	}
+	tok, err := NewTokener(key, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tok.Clone(WithSelfTest(), WithRandom(zeroReader{})); err == nil {
+		t.Error("Clone(WithSelfTest(), WithRandom(zeroReader)) returned <nil>")
+	}
+}
+
+func TestSelfTestKeyring(t *testing.T) {
+	kr := NewKeyring()
+	if err := kr.AddKey(1, key); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewKeyringTokener(kr, ttl, WithSelfTest()); err != nil {
+		t.Fatal(err)
+	}
+	if err := kr.Add(2, brokenAEAD{newGCM16(t, key)}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewKeyringTokener(kr, ttl, WithSelfTest()); err == nil {
+		t.Error("NewKeyringTokener(WithSelfTest()) with a broken AEAD in the keyring returned <nil>")
+	}
+}
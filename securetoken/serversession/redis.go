@@ -0,0 +1,42 @@
+//go:build redis
+
+package serversession
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// This file is only built with the redis build tag, so that the package
+// does not depend on go-redis unless it is used:
+//
+//	go build -tags redis
+
+// A RedisStore is a Store that keeps sessions in Redis,
+// under keys made of Prefix and the session id.
+type RedisStore struct {
+	Client redis.Cmdable
+	Prefix string
+}
+
+// Get implements Store.
+func (s *RedisStore) Get(ctx context.Context, id string) ([]byte, error) {
+	data, err := s.Client.Get(ctx, s.Prefix+id).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+// Set implements Store.
+func (s *RedisStore) Set(ctx context.Context, id string, data []byte, ttl time.Duration) error {
+	return s.Client.Set(ctx, s.Prefix+id, data, ttl).Err()
+}
+
+// Delete implements Store.
+func (s *RedisStore) Delete(ctx context.Context, id string) error {
+	return s.Client.Del(ctx, s.Prefix+id).Err()
+}
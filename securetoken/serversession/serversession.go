@@ -0,0 +1,127 @@
+// Package serversession keeps session data on the server, such as in Redis,
+// with only a sealed session id in the token that the client holds.
+//
+// It is meant for sessions whose data outgrows a cookie. The token still
+// comes from a securetoken.Tokener, so it is authenticated and expires
+// like any other token, and the data is stored with a ttl that ends when
+// the token expires.
+package serversession
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"time"
+
+	"github.com/nicksnyder/go-securetoken/securetoken"
+)
+
+// Purpose is the purpose of session id tokens.
+const Purpose = "securetoken.server-session"
+
+// ErrNotFound is returned when the data of a session has expired or been deleted.
+var ErrNotFound = errors.New("serversession: session not found")
+
+var errInvalidTTL = errors.New("serversession: TTL must be positive")
+
+// A Store holds session data by session id.
+// Implementations must be goroutine safe.
+type Store interface {
+	// Get returns the data of session id, or ErrNotFound.
+	Get(ctx context.Context, id string) ([]byte, error)
+
+	// Set sets the data of session id, to be deleted after ttl.
+	Set(ctx context.Context, id string, data []byte, ttl time.Duration) error
+
+	// Delete deletes session id.
+	Delete(ctx context.Context, id string) error
+}
+
+// A Manager creates and loads server side sessions.
+type Manager struct {
+	// Tokener seals session id tokens.
+	Tokener securetoken.ClaimsSealUnsealer
+
+	// Store holds session data.
+	Store Store
+
+	// TTL is how long a session lasts. It must be positive, since some
+	// stores (e.g. Redis) keep data with a ttl of 0 forever, and should be
+	// no longer than the ttl of the Tokener.
+	TTL time.Duration
+}
+
+// New stores data as a new session and returns the token that identifies it.
+func (m *Manager) New(ctx context.Context, data []byte) ([]byte, error) {
+	if m.TTL <= 0 {
+		return nil, errInvalidTTL
+	}
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	id := base64.RawURLEncoding.EncodeToString(buf)
+	if err := m.Store.Set(ctx, id, data, m.TTL); err != nil {
+		return nil, err
+	}
+	return m.Tokener.SealClaims(&securetoken.Claims{ID: id, Purpose: Purpose, TTL: m.TTL})
+}
+
+// Load returns the id and data of the session identified by token.
+func (m *Manager) Load(ctx context.Context, token []byte) (id string, data []byte, err error) {
+	c, err := m.unseal(token)
+	if err != nil {
+		return "", nil, err
+	}
+	data, err = m.Store.Get(ctx, c.ID)
+	if err != nil {
+		return "", nil, err
+	}
+	return c.ID, data, nil
+}
+
+// Save replaces the data of the session identified by token.
+// The data expires when the token does, by the clock of the Tokener.
+func (m *Manager) Save(ctx context.Context, token []byte, data []byte) error {
+	if m.TTL <= 0 {
+		return errInvalidTTL
+	}
+	c, err := m.unseal(token)
+	if err != nil {
+		return err
+	}
+	ttl := m.TTL - m.now().Sub(c.IssuedAt)
+	if ttl <= 0 {
+		return securetoken.ErrTokenExpired
+	}
+	return m.Store.Set(ctx, c.ID, data, ttl)
+}
+
+// Destroy deletes the session identified by token, e.g. at logout.
+func (m *Manager) Destroy(ctx context.Context, token []byte) error {
+	c, err := m.unseal(token)
+	if err != nil {
+		return err
+	}
+	return m.Store.Delete(ctx, c.ID)
+}
+
+func (m *Manager) unseal(token []byte) (*securetoken.Claims, error) {
+	c, err := m.Tokener.UnsealClaims(token)
+	if err != nil {
+		return nil, err
+	}
+	if c.Purpose != Purpose || c.ID == "" {
+		return nil, securetoken.ErrTokenInvalid
+	}
+	return c, nil
+}
+
+// now returns the time on the clock of the Tokener if it has one.
+func (m *Manager) now() time.Time {
+	if c, ok := m.Tokener.(interface{ Now() time.Time }); ok {
+		return c.Now()
+	}
+	return time.Now()
+}
@@ -0,0 +1,84 @@
+package serversession
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nicksnyder/go-securetoken/securetoken"
+	"github.com/nicksnyder/go-securetoken/securetoken/securetokentest"
+)
+
+func TestManager(t *testing.T) {
+	ctx := context.Background()
+	tok := securetokentest.NewTokener(t)
+	store := NewMemoryStore()
+	store.now = tok.Clock.Now
+	m := &Manager{Tokener: tok, Store: store, TTL: 10 * time.Minute}
+
+	token, err := m.New(ctx, []byte("cart=1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, data, err := m.Load(ctx, token)
+	if id == "" || string(data) != "cart=1" || err != nil {
+		t.Errorf("Load() = %q, %q, %v; expected the session data", id, data, err)
+	}
+
+	tok.Clock.Advance(5 * time.Minute)
+	if err := m.Save(ctx, token, []byte("cart=2")); err != nil {
+		t.Fatal(err)
+	}
+	if _, data, err := m.Load(ctx, token); string(data) != "cart=2" || err != nil {
+		t.Errorf("Load() after Save = %q, %v; expected \"cart=2\", <nil>", data, err)
+	}
+
+	// The data expires with the token, not 10 minutes after Save.
+	tok.Clock.Advance(6 * time.Minute)
+	if _, err := store.Get(ctx, id); err != ErrNotFound {
+		t.Errorf("Get(%q) after the token expired returned %v; expected %s", id, err, ErrNotFound)
+	}
+	if _, _, err := m.Load(ctx, token); err != securetoken.ErrTokenExpired {
+		t.Errorf("Load() after the token expired returned %v; expected %s", err, securetoken.ErrTokenExpired)
+	}
+}
+
+func TestDestroy(t *testing.T) {
+	ctx := context.Background()
+	tok := securetokentest.NewTokener(t)
+	m := &Manager{Tokener: tok, Store: NewMemoryStore(), TTL: time.Hour}
+	token, err := m.New(ctx, []byte("x"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Destroy(ctx, token); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := m.Load(ctx, token); err != ErrNotFound {
+		t.Errorf("Load() after Destroy returned %v; expected %s", err, ErrNotFound)
+	}
+	other, err := tok.SealClaims(&securetoken.Claims{ID: "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := m.Load(ctx, other); err != securetoken.ErrTokenInvalid {
+		t.Errorf("Load() of a token with another purpose returned %v; expected %s", err, securetoken.ErrTokenInvalid)
+	}
+}
+
+func TestInvalidTTL(t *testing.T) {
+	ctx := context.Background()
+	tok := securetokentest.NewTokener(t)
+	m := &Manager{Tokener: tok, Store: NewMemoryStore(), TTL: time.Hour}
+	token, err := m.New(ctx, []byte("x"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.TTL = 0
+	if _, err := m.New(ctx, []byte("x")); err != errInvalidTTL {
+		t.Errorf("New() with a TTL of 0 returned %v; expected %s", err, errInvalidTTL)
+	}
+	if err := m.Save(ctx, token, []byte("y")); err != errInvalidTTL {
+		t.Errorf("Save() with a TTL of 0 returned %v; expected %s", err, errInvalidTTL)
+	}
+}
@@ -0,0 +1,53 @@
+package serversession
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// A MemoryStore is a Store that keeps sessions in memory.
+// It is goroutine safe.
+type MemoryStore struct {
+	mu       sync.Mutex
+	sessions map[string]memoryEntry
+	now      func() time.Time
+}
+
+type memoryEntry struct {
+	data    []byte
+	expires time.Time
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{sessions: make(map[string]memoryEntry), now: time.Now}
+}
+
+// Get implements Store.
+func (s *MemoryStore) Get(ctx context.Context, id string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.sessions[id]
+	if !ok || !s.now().Before(e.expires) {
+		delete(s.sessions, id)
+		return nil, ErrNotFound
+	}
+	return e.data, nil
+}
+
+// Set implements Store.
+func (s *MemoryStore) Set(ctx context.Context, id string, data []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions[id] = memoryEntry{data: append([]byte(nil), data...), expires: s.now().Add(ttl)}
+	return nil
+}
+
+// Delete implements Store.
+func (s *MemoryStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, id)
+	return nil
+}
@@ -0,0 +1,240 @@
+package sessions
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/nicksnyder/go-securetoken/securetoken"
+)
+
+// ErrTooManySessions is returned by Limiter.SealClaims when the subject
+// already has the maximum number of active sessions.
+var ErrTooManySessions = errors.New("sessions: too many active sessions")
+
+var errInvalidMax = errors.New("sessions: Limiter.Max must be positive")
+
+// A Session is an active session of a subject.
+type Session struct {
+	ID      string
+	Created time.Time
+
+	// Expires is when the token of the session expires,
+	// or zero if the Tokener does not say.
+	Expires time.Time
+}
+
+// An ActiveStore holds the active sessions of each subject.
+// Implementations must be goroutine safe.
+type ActiveStore interface {
+	// Active returns the active sessions of subject, oldest first.
+	Active(subject string) ([]Session, error)
+
+	// Add adds a session to subject.
+	Add(subject string, s Session) error
+
+	// Remove removes the session with the given id from subject.
+	Remove(subject, id string) error
+}
+
+// A Limiter limits the number of concurrent sessions of each subject.
+// Every token it seals gets a unique ID that is recorded in Store,
+// and only tokens whose ID is still recorded are accepted.
+// Sessions stop counting against Max once their token has expired,
+// by the clock and ttl of the Tokener if it has them (as a
+// *securetoken.Tokener does); otherwise only End and EvictOldest end them.
+//
+// A Limiter checks and records the sessions of a subject under a lock,
+// but ActiveStore has no atomic check-and-add: Limiters in several
+// processes that share a Store can each let a subject start a session
+// at the same time, so the subject can briefly exceed Max.
+type Limiter struct {
+	// Tokener seals and unseals the claims.
+	Tokener securetoken.ClaimsSealUnsealer
+
+	// Store holds the active sessions.
+	Store ActiveStore
+
+	// Max is the maximum number of active sessions per subject.
+	Max int
+
+	// EvictOldest makes SealClaims end the oldest session of a subject that
+	// has Max active sessions, instead of returning ErrTooManySessions.
+	EvictOldest bool
+
+	locks [64]sync.Mutex
+}
+
+// SealClaims starts a new session for the subject of c and seals c
+// with the ID of the session.
+// It returns an error if Max is not positive.
+func (l *Limiter) SealClaims(c *securetoken.Claims) ([]byte, error) {
+	if l.Max <= 0 {
+		return nil, errInvalidMax
+	}
+	mu := l.lock(c.Subject)
+	mu.Lock()
+	defer mu.Unlock()
+	now := l.now()
+	active, err := l.prune(c.Subject, now)
+	if err != nil {
+		return nil, err
+	}
+	for ; len(active) >= l.Max; active = active[1:] {
+		if !l.EvictOldest {
+			return nil, ErrTooManySessions
+		}
+		if err := l.Store.Remove(c.Subject, active[0].ID); err != nil {
+			return nil, err
+		}
+	}
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+	if err := l.Store.Add(c.Subject, Session{ID: id, Created: now, Expires: l.expires(c, now)}); err != nil {
+		return nil, err
+	}
+	prev := c.ID
+	c.ID = id
+	sealed, err := l.Tokener.SealClaims(c)
+	if err != nil {
+		c.ID = prev
+		if rerr := l.Store.Remove(c.Subject, id); rerr != nil {
+			return nil, errors.Join(err, rerr)
+		}
+		return nil, err
+	}
+	return sealed, nil
+}
+
+// UnsealClaims unseals a token and returns ErrRevoked
+// if its session is no longer active.
+func (l *Limiter) UnsealClaims(sealed []byte, opts ...securetoken.UnsealOption) (*securetoken.Claims, error) {
+	c, err := l.Tokener.UnsealClaims(sealed, opts...)
+	if err != nil {
+		return nil, err
+	}
+	active, err := l.Store.Active(c.Subject)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range active {
+		if s.ID == c.ID {
+			return c, nil
+		}
+	}
+	return nil, ErrRevoked
+}
+
+// End ends the session of the token with claims c, e.g. at logout.
+func (l *Limiter) End(c *securetoken.Claims) error {
+	return l.Store.Remove(c.Subject, c.ID)
+}
+
+// prune removes the expired sessions of subject and returns the rest.
+func (l *Limiter) prune(subject string, now time.Time) ([]Session, error) {
+	active, err := l.Store.Active(subject)
+	if err != nil {
+		return nil, err
+	}
+	rest := active[:0]
+	for _, s := range active {
+		if !s.Expires.IsZero() && now.After(s.Expires) {
+			if err := l.Store.Remove(subject, s.ID); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		rest = append(rest, s)
+	}
+	return rest, nil
+}
+
+// expires returns when the token of a session that starts at now
+// with claims c expires, allowing for the leeway of the Tokener,
+// or zero if the Tokener has no ttl.
+func (l *Limiter) expires(c *securetoken.Claims, now time.Time) time.Time {
+	t, ok := l.Tokener.(interface{ TTL() time.Duration })
+	if !ok {
+		return time.Time{}
+	}
+	ttl := t.TTL()
+	if c.TTL > ttl {
+		ttl = c.TTL // An embedded ttl may be longer (see WithEmbeddedTTL).
+	}
+	if t, ok := l.Tokener.(interface{ Leeway() time.Duration }); ok {
+		ttl += t.Leeway()
+	}
+	return now.Add(ttl)
+}
+
+// lock returns the lock of the sessions of subject.
+func (l *Limiter) lock(subject string) *sync.Mutex {
+	h := fnv.New32a()
+	h.Write([]byte(subject))
+	return &l.locks[h.Sum32()%uint32(len(l.locks))]
+}
+
+// now returns the time on the clock of the Tokener if it has one.
+func (l *Limiter) now() time.Time {
+	if c, ok := l.Tokener.(interface{ Now() time.Time }); ok {
+		return c.Now()
+	}
+	return time.Now()
+}
+
+func newID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// A MemoryActiveStore is an ActiveStore that keeps sessions in memory.
+// It is goroutine safe.
+type MemoryActiveStore struct {
+	mu       sync.Mutex
+	sessions map[string][]Session
+}
+
+// NewMemoryActiveStore returns an empty MemoryActiveStore.
+func NewMemoryActiveStore() *MemoryActiveStore {
+	return &MemoryActiveStore{sessions: make(map[string][]Session)}
+}
+
+// Active implements ActiveStore.
+func (s *MemoryActiveStore) Active(subject string) ([]Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Session(nil), s.sessions[subject]...), nil
+}
+
+// Add implements ActiveStore.
+func (s *MemoryActiveStore) Add(subject string, session Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions[subject] = append(s.sessions[subject], session)
+	return nil
+}
+
+// Remove implements ActiveStore.
+func (s *MemoryActiveStore) Remove(subject, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sessions := s.sessions[subject]
+	for i, session := range sessions {
+		if session.ID == id {
+			s.sessions[subject] = append(sessions[:i:i], sessions[i+1:]...)
+			break
+		}
+	}
+	if len(s.sessions[subject]) == 0 {
+		delete(s.sessions, subject)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package sessions
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nicksnyder/go-securetoken/securetoken"
+	"github.com/nicksnyder/go-securetoken/securetoken/securetokentest"
+)
+
+func TestLimiterReject(t *testing.T) {
+	tok := securetokentest.NewTokener(t)
+	l := &Limiter{Tokener: tok, Store: NewMemoryActiveStore(), Max: 2}
+	for i := 0; i < 2; i++ {
+		if _, err := l.SealClaims(&securetoken.Claims{Subject: "alice"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := l.SealClaims(&securetoken.Claims{Subject: "alice"}); err != ErrTooManySessions {
+		t.Errorf("SealClaims() returned %v; expected %s", err, ErrTooManySessions)
+	}
+	if _, err := l.SealClaims(&securetoken.Claims{Subject: "bob"}); err != nil {
+		t.Errorf("SealClaims() returned %s", err)
+	}
+
+	// Expired sessions do not count.
+	tok.Clock.Advance(securetokentest.TTL + time.Second)
+	if _, err := l.SealClaims(&securetoken.Claims{Subject: "alice"}); err != nil {
+		t.Errorf("SealClaims() returned %s", err)
+	}
+}
+
+func TestLimiterEvictOldest(t *testing.T) {
+	tok := securetokentest.NewTokener(t)
+	l := &Limiter{Tokener: tok, Store: NewMemoryActiveStore(), Max: 2, EvictOldest: true}
+	var sealed [][]byte
+	for i := 0; i < 3; i++ {
+		s, err := l.SealClaims(&securetoken.Claims{Subject: "alice"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		sealed = append(sealed, s)
+	}
+	if c, err := l.UnsealClaims(sealed[0]); c != nil || err != ErrRevoked {
+		t.Errorf("UnsealClaims(oldest) = %+v, %v; expected <nil>, %s", c, err, ErrRevoked)
+	}
+	for _, s := range sealed[1:] {
+		c, err := l.UnsealClaims(s)
+		if err != nil {
+			t.Fatalf("UnsealClaims(%q) returned %s", s, err)
+		}
+		if err := l.End(c); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := l.UnsealClaims(s); err != ErrRevoked {
+			t.Errorf("UnsealClaims() after End returned %v; expected %s", err, ErrRevoked)
+		}
+	}
+}
+
+func TestLimiterInvalidMax(t *testing.T) {
+	tok := securetokentest.NewTokener(t)
+	l := &Limiter{Tokener: tok, Store: NewMemoryActiveStore(), EvictOldest: true}
+	if _, err := l.SealClaims(&securetoken.Claims{Subject: "alice"}); err != errInvalidMax {
+		t.Errorf("SealClaims() with Max 0 returned %v; expected %s", err, errInvalidMax)
+	}
+}
+
+func TestLimiterConcurrent(t *testing.T) {
+	tok := securetokentest.NewTokener(t)
+	store := NewMemoryActiveStore()
+	l := &Limiter{Tokener: tok, Store: store, Max: 2}
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.SealClaims(&securetoken.Claims{Subject: "alice"})
+		}()
+	}
+	wg.Wait()
+	if active, _ := store.Active("alice"); len(active) != 2 {
+		t.Errorf("%d active sessions after concurrent SealClaims; expected 2", len(active))
+	}
+}
+
+// failingSealer fails to seal claims.
+type failingSealer struct {
+	securetoken.ClaimsSealUnsealer
+}
+
+var errSeal = errors.New("seal failed")
+
+func (failingSealer) SealClaims(c *securetoken.Claims) ([]byte, error) {
+	return nil, errSeal
+}
+
+func TestLimiterSealFails(t *testing.T) {
+	store := NewMemoryActiveStore()
+	l := &Limiter{Tokener: failingSealer{securetokentest.NewTokener(t)}, Store: store, Max: 1}
+	if _, err := l.SealClaims(&securetoken.Claims{Subject: "alice"}); err != errSeal {
+		t.Fatalf("SealClaims() returned %v; expected %s", err, errSeal)
+	}
+	if active, _ := store.Active("alice"); len(active) != 0 {
+		t.Errorf("Active() after a failed SealClaims = %+v; expected no sessions", active)
+	}
+}
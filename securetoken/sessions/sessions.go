@@ -0,0 +1,104 @@
+// Package sessions implements "sign out everywhere" for claims tokens.
+//
+// Every subject has a session version, kept in a VersionStore.
+// Tokens record the version that was current when they were sealed,
+// and are rejected once the version has moved on, so incrementing
+// the version revokes every outstanding token of the subject
+// without keeping a list of revoked tokens.
+package sessions
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/nicksnyder/go-securetoken/securetoken"
+)
+
+// ErrRevoked is returned by Unseal when the session version of a token
+// is older than the current version of its subject.
+var ErrRevoked = errors.New("sessions: session revoked")
+
+// A VersionStore holds the current session version of each subject.
+// Implementations must be goroutine safe.
+type VersionStore interface {
+	// Version returns the current version of subject, which is 0 for
+	// subjects that have never been invalidated.
+	Version(subject string) (uint64, error)
+
+	// Increment increments the version of subject and returns the new version.
+	Increment(subject string) (uint64, error)
+}
+
+// A Versioner seals and unseals claims tokens that are bound to
+// the session version of their subject.
+type Versioner struct {
+	tokener securetoken.ClaimsSealUnsealer
+	store   VersionStore
+}
+
+// New returns a Versioner that seals tokens with tokener
+// and looks up versions in store.
+func New(tokener securetoken.ClaimsSealUnsealer, store VersionStore) *Versioner {
+	return &Versioner{tokener: tokener, store: store}
+}
+
+// SealClaims sets the session version of c to the current version
+// of its subject and seals it.
+func (v *Versioner) SealClaims(c *securetoken.Claims) ([]byte, error) {
+	ver, err := v.store.Version(c.Subject)
+	if err != nil {
+		return nil, err
+	}
+	c.SessionVersion = ver
+	return v.tokener.SealClaims(c)
+}
+
+// UnsealClaims unseals a token and returns ErrRevoked if its
+// session version is no longer current.
+func (v *Versioner) UnsealClaims(sealed []byte, opts ...securetoken.UnsealOption) (*securetoken.Claims, error) {
+	c, err := v.tokener.UnsealClaims(sealed, opts...)
+	if err != nil {
+		return nil, err
+	}
+	ver, err := v.store.Version(c.Subject)
+	if err != nil {
+		return nil, err
+	}
+	if c.SessionVersion != ver {
+		return nil, ErrRevoked
+	}
+	return c, nil
+}
+
+// InvalidateUser revokes every token of subject that has been sealed so far.
+func (v *Versioner) InvalidateUser(subject string) error {
+	_, err := v.store.Increment(subject)
+	return err
+}
+
+// A MemoryVersionStore is a VersionStore that keeps versions in memory.
+// It is goroutine safe.
+type MemoryVersionStore struct {
+	mu       sync.Mutex
+	versions map[string]uint64
+}
+
+// NewMemoryVersionStore returns an empty MemoryVersionStore.
+func NewMemoryVersionStore() *MemoryVersionStore {
+	return &MemoryVersionStore{versions: make(map[string]uint64)}
+}
+
+// Version implements VersionStore.
+func (s *MemoryVersionStore) Version(subject string) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.versions[subject], nil
+}
+
+// Increment implements VersionStore.
+func (s *MemoryVersionStore) Increment(subject string) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.versions[subject]++
+	return s.versions[subject], nil
+}
@@ -0,0 +1,37 @@
+package sessions
+
+import (
+	"testing"
+
+	"github.com/nicksnyder/go-securetoken/securetoken"
+	"github.com/nicksnyder/go-securetoken/securetoken/securetokentest"
+)
+
+func TestInvalidateUser(t *testing.T) {
+	v := New(securetokentest.NewTokener(t), NewMemoryVersionStore())
+	seal := func(subject string) []byte {
+		sealed, err := v.SealClaims(&securetoken.Claims{Subject: subject})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sealed
+	}
+	alice1, alice2, bob := seal("alice"), seal("alice"), seal("bob")
+
+	if err := v.InvalidateUser("alice"); err != nil {
+		t.Fatal(err)
+	}
+	for _, sealed := range [][]byte{alice1, alice2} {
+		if c, err := v.UnsealClaims(sealed); c != nil || err != ErrRevoked {
+			t.Errorf("UnsealClaims(%q) = %+v, %v; expected <nil>, %s", sealed, c, err, ErrRevoked)
+		}
+	}
+	if c, err := v.UnsealClaims(bob); err != nil || c.Subject != "bob" {
+		t.Errorf("UnsealClaims(%q) = %+v, %v; expected bob, <nil>", bob, c, err)
+	}
+
+	alice3 := seal("alice")
+	if c, err := v.UnsealClaims(alice3); err != nil || c.SessionVersion != 1 {
+		t.Errorf("UnsealClaims(%q) = %+v, %v; expected version 1, <nil>", alice3, c, err)
+	}
+}
@@ -0,0 +1,210 @@
+package securetoken
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Short token layout: a 3 byte timestamp in seconds since the epoch of the
+// key, a 4 byte counter, the ciphertext and a 12 byte GCM tag.
+const (
+	shortTimestampLength = 3
+	shortCounterLength   = 4
+	shortHeaderLength    = shortTimestampLength + shortCounterLength
+	shortTagSize         = 12
+	shortMaxTTL          = (1 << 23) * time.Second
+	shortKeyLifetime     = (1 << 24) * time.Second
+)
+
+// A CounterStore hands out the counters that ShortTokener uses as nonces.
+// Next must never return the same value twice for the lifetime of a key,
+// including across restarts, or confidentiality and integrity are lost.
+// Implementations must be goroutine safe.
+type CounterStore interface {
+	Next() (uint64, error)
+}
+
+// A ShortTokener seals very short tokens, e.g. for SMS links or NFC tags,
+// where a token from Tokener is too long. An empty payload seals to
+// 26 characters and each byte of payload adds about 1.3 characters.
+//
+// To get there it uses a counter from a CounterStore as the nonce instead of
+// random bytes, stores timestamps with second precision relative to the
+// epoch of the key, uses a 96 bit instead of a 128 bit tag and has no key
+// ids. A key can seal at most 2^32 tokens and only for 2^24 seconds (about
+// 194 days) after its epoch, after which Seal returns ErrKeyExhausted, so
+// keys must be rotated well within that. The ttl can be at most 97 days.
+// It is goroutine safe.
+type ShortTokener struct {
+	aead    cipher.AEAD
+	counter CounterStore
+	epoch   time.Time
+	ttl     time.Duration
+	clock   func() time.Time
+}
+
+var _ SealUnsealer = (*ShortTokener)(nil)
+
+// NewShortTokener returns a ShortTokener that seals with key, which must be
+// 16, 24 or 32 bytes, and takes nonces from counter. epoch is when the key
+// was created; timestamps are stored relative to it, so that they never wrap
+// around and an old token never looks freshly sealed.
+func NewShortTokener(key []byte, epoch time.Time, ttl time.Duration, counter CounterStore) (*ShortTokener, error) {
+	if ttl <= 0 || ttl > shortMaxTTL {
+		return nil, fmt.Errorf("securetoken: short token ttl must be between 0 and %s", shortMaxTTL)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCMWithTagSize(block, shortTagSize)
+	if err != nil {
+		return nil, err
+	}
+	return &ShortTokener{aead: aead, counter: counter, epoch: epoch.Truncate(time.Second), ttl: ttl}, nil
+}
+
+// Seal seals plaintext into a short token.
+// It returns ErrKeyExhausted once the key is 2^24 seconds past its epoch.
+func (t *ShortTokener) Seal(plaintext []byte) ([]byte, error) {
+	age := t.now().Sub(t.epoch)
+	if age < 0 {
+		return nil, errors.New("securetoken: short token key epoch is in the future")
+	}
+	if age >= shortKeyLifetime {
+		return nil, ErrKeyExhausted
+	}
+	n, err := t.counter.Next()
+	if err != nil {
+		return nil, err
+	}
+	if n > 1<<32-1 {
+		return nil, ErrKeyExhausted
+	}
+	tok := make([]byte, shortHeaderLength, shortHeaderLength+len(plaintext)+shortTagSize)
+	ts := uint32(age / time.Second)
+	tok[0], tok[1], tok[2] = byte(ts>>16), byte(ts>>8), byte(ts)
+	binary.BigEndian.PutUint32(tok[shortTimestampLength:], uint32(n))
+	tok = t.aead.Seal(tok, shortNonce(tok), plaintext, nil)
+	buf := make([]byte, base64.RawURLEncoding.EncodedLen(len(tok)))
+	base64.RawURLEncoding.Encode(buf, tok)
+	return buf, nil
+}
+
+// Unseal unseals a token sealed by Seal. Of the UnsealOptions,
+// only WithMaxAge and WithIgnoreExpiry are supported.
+func (t *ShortTokener) Unseal(sealed []byte, opts ...UnsealOption) ([]byte, error) {
+	cfg := newUnsealConfig(opts)
+	if cfg.aad != nil || cfg.needsClaims() != nil {
+		return nil, ErrTokenInvalid
+	}
+	tok := make([]byte, base64.RawURLEncoding.DecodedLen(len(sealed)))
+	n, err := base64.RawURLEncoding.Decode(tok, sealed)
+	if err != nil || n < shortHeaderLength+shortTagSize {
+		return nil, ErrTokenInvalid
+	}
+	tok = tok[:n]
+	plaintext, err := t.aead.Open(nil, shortNonce(tok), tok[shortHeaderLength:], nil)
+	if err != nil {
+		return nil, ErrTokenInvalid
+	}
+	ts := uint32(tok[0])<<16 | uint32(tok[1])<<8 | uint32(tok[2])
+	if err := cfg.checkAge(t.now(), t.epoch.Add(time.Duration(ts)*time.Second), t.ttl); err != nil {
+		return nil, err
+	}
+	return plaintext, nil
+}
+
+func (t *ShortTokener) now() time.Time {
+	if t.clock != nil {
+		return t.clock()
+	}
+	return timeNow()
+}
+
+// shortNonce returns the nonce of a short token: its header padded with zeros.
+func shortNonce(tok []byte) []byte {
+	nonce := make([]byte, 12)
+	copy(nonce, tok[:shortHeaderLength])
+	return nonce
+}
+
+// A FileCounterStore is a CounterStore that persists its counter in a file.
+// It reserves counters in blocks, writing the end of each block to the file
+// before handing out any counter of it, so a restart skips the rest of the
+// block instead of reusing counters.
+type FileCounterStore struct {
+	path  string
+	block uint64
+
+	mu   sync.Mutex
+	next uint64
+	end  uint64
+}
+
+// NewFileCounterStore returns a FileCounterStore that keeps its counter in
+// the file at path, which is created if it does not exist, and reserves
+// block counters at a time.
+func NewFileCounterStore(path string, block uint64) (*FileCounterStore, error) {
+	if block == 0 {
+		return nil, errors.New("securetoken: counter block must not be 0")
+	}
+	s := &FileCounterStore{path: path, block: block}
+	buf, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if len(buf) > 0 {
+		s.next, err = strconv.ParseUint(strings.TrimSpace(string(buf)), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("securetoken: counter file %s: %w", path, err)
+		}
+	}
+	s.end = s.next
+	return s, nil
+}
+
+// Next implements CounterStore.
+func (s *FileCounterStore) Next() (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.next == s.end {
+		if err := s.reserve(s.end + s.block); err != nil {
+			return 0, err
+		}
+		s.end += s.block
+	}
+	n := s.next
+	s.next++
+	return n, nil
+}
+
+// reserve durably records that counters below end may have been used.
+func (s *FileCounterStore) reserve(end uint64) error {
+	tmp := s.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(strconv.FormatUint(end, 10) + "\n"); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
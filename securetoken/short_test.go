@@ -0,0 +1,85 @@
+package securetoken
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testCounter uint64
+
+func (c *testCounter) Next() (uint64, error) {
+	n := uint64(*c)
+	*c++
+	return n, nil
+}
+
+func TestShortTokener(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	setNow(now)
+	defer restoreNow()
+	var counter testCounter
+	tok, err := NewShortTokener(key, now.Add(-time.Hour), time.Hour, &counter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := tok.Seal([]byte("42"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sealed) >= 30 {
+		t.Errorf("Seal(\"42\") = %q (%d characters); expected under 30", sealed, len(sealed))
+	}
+	if empty, _ := tok.Seal(nil); len(empty) != 26 {
+		t.Errorf("Seal(nil) = %q; expected 26 characters", empty)
+	}
+	if plaintext, err := tok.Unseal(sealed); string(plaintext) != "42" || err != nil {
+		t.Errorf("Unseal(%q) = %q, %v; expected \"42\", <nil>", sealed, plaintext, err)
+	}
+	tampered := append([]byte(nil), sealed...)
+	tampered[3] ^= 1
+	if _, err := tok.Unseal(tampered); err != ErrTokenInvalid {
+		t.Errorf("Unseal(%q) returned %v; expected %s", tampered, err, ErrTokenInvalid)
+	}
+
+	setNow(now.Add(2 * time.Hour))
+	if _, err := tok.Unseal(sealed); err != ErrTokenExpired {
+		t.Errorf("Unseal(%q) after ttl returned %v; expected %s", sealed, err, ErrTokenExpired)
+	}
+
+	// A token never comes back once the timestamp would have wrapped.
+	setNow(now.Add(shortKeyLifetime))
+	if _, err := tok.Unseal(sealed); err != ErrTokenExpired {
+		t.Errorf("Unseal(%q) 2^24 seconds later returned %v; expected %s", sealed, err, ErrTokenExpired)
+	}
+	if _, err := tok.Seal(nil); err != ErrKeyExhausted {
+		t.Errorf("Seal() 2^24 seconds after the epoch returned %v; expected %s", err, ErrKeyExhausted)
+	}
+
+	setNow(now)
+	counter = 1 << 32
+	if _, err := tok.Seal(nil); err != ErrKeyExhausted {
+		t.Errorf("Seal() after 2^32 tokens returned %v; expected %s", err, ErrKeyExhausted)
+	}
+}
+
+func TestFileCounterStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "counter")
+	s, err := NewFileCounterStore(path, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := uint64(0); i < 3; i++ {
+		if n, err := s.Next(); n != i || err != nil {
+			t.Fatalf("Next() = %d, %v; expected %d, <nil>", n, err, i)
+		}
+	}
+	// A restart skips the rest of the reserved block.
+	s, err = NewFileCounterStore(path, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := s.Next(); n != 10 || err != nil {
+		t.Errorf("Next() after restart = %d, %v; expected 10, <nil>", n, err)
+	}
+}
@@ -0,0 +1,65 @@
+//go:build gorm
+
+package sqltoken
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm/schema"
+)
+
+// This file is only built with the gorm build tag, so that the package
+// does not depend on GORM unless it is used:
+//
+//	go build -tags gorm
+
+func init() {
+	schema.RegisterSerializer("securetoken", Serializer{})
+}
+
+// Serializer is a GORM serializer that seals string and []byte fields,
+// registered as "securetoken":
+//
+//	type User struct {
+//		Email string `gorm:"serializer:securetoken"`
+//	}
+type Serializer struct{}
+
+// Scan implements schema.SerializerInterface.
+func (Serializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	if dbValue == nil {
+		return nil
+	}
+	buf, err := unseal(dbValue)
+	if err != nil {
+		return err
+	}
+	v := reflect.New(field.FieldType).Elem()
+	switch {
+	case v.Kind() == reflect.String:
+		v.SetString(string(buf))
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+		v.SetBytes(buf)
+	default:
+		return fmt.Errorf("sqltoken: cannot unseal into field %s of type %s", field.Name, field.FieldType)
+	}
+	field.ReflectValueOf(ctx, dst).Set(v)
+	return nil
+}
+
+// Value implements schema.SerializerInterface.
+func (Serializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	switch v := fieldValue.(type) {
+	case string:
+		return seal([]byte(v))
+	case []byte:
+		if v == nil {
+			return nil, nil
+		}
+		return seal(v)
+	default:
+		return nil, fmt.Errorf("sqltoken: cannot seal field %s of type %T", field.Name, fieldValue)
+	}
+}
@@ -0,0 +1,239 @@
+package sqltoken
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/nicksnyder/go-securetoken/securetoken"
+)
+
+// A Rewrapper re-seals a sealed column of a live database with the current
+// primary key of a Tokener, e.g. after a key has been compromised, without
+// taking the table offline. It walks the table in primary key order in
+// batches, and each row is updated only if the column has not changed since
+// it was read, so concurrent writes by the application win.
+type Rewrapper struct {
+	// DB is the database.
+	DB *sql.DB
+
+	// Table, KeyColumn and Column name the table, its primary key and the
+	// sealed column. They are put into queries as is, so they must not come
+	// from untrusted input.
+	Table, KeyColumn, Column string
+
+	// Placeholder returns the query placeholder for the nth argument,
+	// counting from 1, e.g. "$1" for PostgreSQL. It defaults to "?".
+	Placeholder func(n int) string
+
+	// Tokener unseals the column with any of its keys, including the old
+	// ones, and seals it again with its primary key.
+	Tokener securetoken.SealUnsealer
+
+	// NeedsRewrap reports whether a sealed value must be re-sealed,
+	// e.g. because its key id is compromised (see securetoken.ParseRaw).
+	// nil re-seals every value.
+	NeedsRewrap func(sealed []byte) bool
+
+	// BatchSize is the number of rows read at a time. It defaults to 100.
+	BatchSize int
+
+	// RowsPerSecond limits the rate at which rows are updated,
+	// to spare the database. 0 means unlimited.
+	RowsPerSecond float64
+
+	// Checkpoint, if not nil, records the last key of every batch,
+	// so that a Run that is interrupted resumes where it stopped.
+	Checkpoint Checkpoint
+
+	// Progress, if not nil, is called after every batch.
+	Progress func(Progress)
+}
+
+// Progress counts the rows that a Rewrapper has handled.
+type Progress struct {
+	// Scanned rows were read.
+	Scanned int
+
+	// Rewrapped rows were re-sealed.
+	Rewrapped int
+
+	// Skipped rows were NULL or did not need to be re-sealed.
+	Skipped int
+
+	// Failed rows could not be unsealed and were left unchanged.
+	Failed int
+
+	// Conflicts are rows that the application changed while they were
+	// being re-sealed, which were left unchanged.
+	Conflicts int
+
+	// LastKey is the primary key of the last row read.
+	LastKey interface{}
+}
+
+// A Checkpoint stores the position of a Rewrapper.
+// Keys are the values scanned from the primary key column.
+type Checkpoint interface {
+	// Load returns the saved key, or ok false if there is none.
+	Load(ctx context.Context) (key interface{}, ok bool, err error)
+
+	// Save saves key.
+	Save(ctx context.Context, key interface{}) error
+}
+
+// A MemoryCheckpoint is a Checkpoint in memory, which lets a Rewrapper
+// resume within a process, e.g. after its context was canceled.
+// It is goroutine safe.
+type MemoryCheckpoint struct {
+	mu  sync.Mutex
+	key interface{}
+	ok  bool
+}
+
+// Load implements Checkpoint.
+func (c *MemoryCheckpoint) Load(ctx context.Context) (interface{}, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.key, c.ok, nil
+}
+
+// Save implements Checkpoint.
+func (c *MemoryCheckpoint) Save(ctx context.Context, key interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.key, c.ok = key, true
+	return nil
+}
+
+// Run re-seals the column until every row has been handled or ctx is
+// done. It returns the progress of this run and the first error that
+// stopped it; rows that fail to unseal are counted rather than returned.
+func (rw *Rewrapper) Run(ctx context.Context) (Progress, error) {
+	var p Progress
+	var after interface{}
+	hasAfter := false
+	if rw.Checkpoint != nil {
+		var err error
+		if after, hasAfter, err = rw.Checkpoint.Load(ctx); err != nil {
+			return p, err
+		}
+	}
+	var tick *time.Ticker
+	if rw.RowsPerSecond > 0 {
+		tick = time.NewTicker(time.Duration(float64(time.Second) / rw.RowsPerSecond))
+		defer tick.Stop()
+	}
+	for {
+		rows, err := rw.batch(ctx, after, hasAfter)
+		if err != nil {
+			return p, err
+		}
+		if len(rows) == 0 {
+			return p, nil
+		}
+		for _, r := range rows {
+			if tick != nil {
+				select {
+				case <-tick.C:
+				case <-ctx.Done():
+					return p, ctx.Err()
+				}
+			}
+			if err := rw.rewrap(ctx, r, &p); err != nil {
+				return p, err
+			}
+		}
+		after, hasAfter = rows[len(rows)-1].key, true
+		p.LastKey = after
+		if rw.Checkpoint != nil {
+			if err := rw.Checkpoint.Save(ctx, after); err != nil {
+				return p, err
+			}
+		}
+		if rw.Progress != nil {
+			rw.Progress(p)
+		}
+	}
+}
+
+type rewrapRow struct {
+	key    interface{}
+	sealed sql.NullString
+}
+
+// batch reads the next rows after the given key.
+func (rw *Rewrapper) batch(ctx context.Context, after interface{}, hasAfter bool) ([]rewrapRow, error) {
+	query := fmt.Sprintf("SELECT %s, %s FROM %s", rw.KeyColumn, rw.Column, rw.Table)
+	var args []interface{}
+	if hasAfter {
+		query += fmt.Sprintf(" WHERE %s > %s", rw.KeyColumn, rw.placeholder(1))
+		args = append(args, after)
+	}
+	query += fmt.Sprintf(" ORDER BY %s LIMIT %d", rw.KeyColumn, rw.batchSize())
+	rows, err := rw.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var batch []rewrapRow
+	for rows.Next() {
+		var r rewrapRow
+		if err := rows.Scan(&r.key, &r.sealed); err != nil {
+			return nil, err
+		}
+		batch = append(batch, r)
+	}
+	return batch, rows.Err()
+}
+
+// rewrap re-seals the value of r, if it needs to be, and counts it in p.
+func (rw *Rewrapper) rewrap(ctx context.Context, r rewrapRow, p *Progress) error {
+	p.Scanned++
+	old := []byte(r.sealed.String)
+	if !r.sealed.Valid || (rw.NeedsRewrap != nil && !rw.NeedsRewrap(old)) {
+		p.Skipped++
+		return nil
+	}
+	plaintext, err := rw.Tokener.Unseal(old, securetoken.WithIgnoreExpiry())
+	if err != nil {
+		p.Failed++
+		return nil
+	}
+	sealed, err := rw.Tokener.Seal(plaintext)
+	if err != nil {
+		return err
+	}
+	query := fmt.Sprintf("UPDATE %s SET %s = %s WHERE %s = %s AND %s = %s",
+		rw.Table, rw.Column, rw.placeholder(1), rw.KeyColumn, rw.placeholder(2), rw.Column, rw.placeholder(3))
+	res, err := rw.DB.ExecContext(ctx, query, string(sealed), r.key, r.sealed.String)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		p.Conflicts++
+		return nil
+	}
+	p.Rewrapped++
+	return nil
+}
+
+func (rw *Rewrapper) placeholder(n int) string {
+	if rw.Placeholder != nil {
+		return rw.Placeholder(n)
+	}
+	return "?"
+}
+
+func (rw *Rewrapper) batchSize() int {
+	if rw.BatchSize > 0 {
+		return rw.BatchSize
+	}
+	return 100
+}
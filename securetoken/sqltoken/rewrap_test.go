@@ -0,0 +1,194 @@
+package sqltoken
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/base64"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nicksnyder/go-securetoken/securetoken"
+)
+
+// fakeTable is a table with an integer primary key and a nullable text
+// column, behind a database/sql driver that understands the queries of
+// Rewrapper.
+type fakeTable struct {
+	mu      sync.Mutex
+	values  map[int64]*string
+	queries int
+	onQuery func(t *fakeTable) // called with mu held after a batch is read
+}
+
+func (t *fakeTable) Connect(ctx context.Context) (driver.Conn, error) { return fakeConn{t}, nil }
+func (t *fakeTable) Driver() driver.Driver                            { return nil }
+
+type fakeConn struct{ t *fakeTable }
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt{c.t, query}, nil }
+func (c fakeConn) Close() error                              { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)                 { return nil, errors.New("not supported") }
+
+type fakeStmt struct {
+	t     *fakeTable
+	query string
+}
+
+func (s fakeStmt) Close() error  { return nil }
+func (s fakeStmt) NumInput() int { return -1 }
+
+func (s fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.t.mu.Lock()
+	defer s.t.mu.Unlock()
+	if !strings.HasPrefix(s.query, "UPDATE users SET secret = ? WHERE id = ? AND secret = ?") {
+		return nil, errors.New("unexpected query: " + s.query)
+	}
+	v, ok := s.t.values[args[1].(int64)]
+	if !ok || v == nil || *v != args[2].(string) {
+		return driver.RowsAffected(0), nil
+	}
+	sealed := args[0].(string)
+	s.t.values[args[1].(int64)] = &sealed
+	return driver.RowsAffected(1), nil
+}
+
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.t.mu.Lock()
+	defer s.t.mu.Unlock()
+	if !strings.HasPrefix(s.query, "SELECT id, secret FROM users") || !strings.HasSuffix(s.query, "ORDER BY id LIMIT 2") {
+		return nil, errors.New("unexpected query: " + s.query)
+	}
+	s.t.queries++
+	var keys []int64
+	for k := range s.t.values {
+		if len(args) == 0 || k > args[0].(int64) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	if len(keys) > 2 {
+		keys = keys[:2]
+	}
+	rows := &fakeRows{}
+	for _, k := range keys {
+		var v driver.Value
+		if s.t.values[k] != nil {
+			v = *s.t.values[k]
+		}
+		rows.rows = append(rows.rows, []driver.Value{k, v})
+	}
+	if s.t.onQuery != nil {
+		s.t.onQuery(s.t)
+	}
+	return rows, nil
+}
+
+type fakeRows struct{ rows [][]driver.Value }
+
+func (r *fakeRows) Columns() []string { return []string{"id", "secret"} }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func TestRewrapper(t *testing.T) {
+	old := securetoken.NewKeyring()
+	if err := old.AddKey(1, []byte("0123456789abcdef")); err != nil {
+		t.Fatal(err)
+	}
+	oldTok, err := securetoken.NewKeyringTokener(old, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	table := &fakeTable{values: map[int64]*string{}}
+	for i, s := range []string{"a", "b", "", "d", "e"} {
+		if s == "" {
+			table.values[int64(i+1)] = nil
+			continue
+		}
+		sealed, err := oldTok.Seal([]byte(s))
+		if err != nil {
+			t.Fatal(err)
+		}
+		v := string(sealed)
+		table.values[int64(i+1)] = &v
+	}
+	forged := "forged"
+	table.values[6] = &forged
+	// The application rewrites row 4 while the rewrapper works on its batch.
+	table.onQuery = func(t *fakeTable) {
+		if t.queries == 2 {
+			v := "rewritten"
+			t.values[4] = &v
+		}
+	}
+
+	if err := old.AddKey(2, []byte("fedcba9876543210")); err != nil {
+		t.Fatal(err)
+	}
+	if err := old.SetPrimary(2); err != nil {
+		t.Fatal(err)
+	}
+	var progress []Progress
+	checkpoint := &MemoryCheckpoint{}
+	rw := &Rewrapper{
+		DB:        sql.OpenDB(table),
+		Table:     "users",
+		KeyColumn: "id",
+		Column:    "secret",
+		Tokener:   oldTok,
+		NeedsRewrap: func(sealed []byte) bool {
+			decoded, err := base64.URLEncoding.DecodeString(string(sealed))
+			if err != nil {
+				return true
+			}
+			raw, err := securetoken.ParseRaw(decoded, securetoken.MinNonceLength)
+			return err != nil || raw.KeyID == 1
+		},
+		BatchSize:     2,
+		RowsPerSecond: 1000,
+		Checkpoint:    checkpoint,
+		Progress:      func(p Progress) { progress = append(progress, p) },
+	}
+	p, err := rw.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Progress{Scanned: 6, Rewrapped: 3, Skipped: 1, Failed: 1, Conflicts: 1, LastKey: int64(6)}
+	if p != expected {
+		t.Errorf("Run() = %+v; expected %+v", p, expected)
+	}
+	if len(progress) != 3 {
+		t.Errorf("Progress was called %d times; expected once per batch", len(progress))
+	}
+
+	only2 := securetoken.NewKeyring()
+	if err := only2.AddKey(2, []byte("fedcba9876543210")); err != nil {
+		t.Fatal(err)
+	}
+	newTok, err := securetoken.NewKeyringTokener(only2, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []int64{1, 2, 5} {
+		if data, err := newTok.Unseal([]byte(*table.values[id]), securetoken.WithIgnoreExpiry()); err != nil {
+			t.Errorf("row %d = %q, %v after Run; expected it to unseal with the new key", id, data, err)
+		}
+	}
+
+	// A second run resumes after the checkpoint and finds nothing to do.
+	if p, err := rw.Run(context.Background()); p.Scanned != 0 || err != nil {
+		t.Errorf("Run() after a complete run = %+v, %v; expected nothing scanned", p, err)
+	}
+}
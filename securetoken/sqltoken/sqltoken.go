@@ -0,0 +1,126 @@
+// Package sqltoken provides database column types that are sealed
+// by a securetoken.Tokener when they are written and unsealed when
+// they are read, for application level encryption of fields such as
+// personal data.
+//
+// The Tokener is set once with SetTokener, since database/sql gives
+// Valuers and Scanners no other way to reach it. Sealed columns never
+// expire: the ttl of the Tokener is ignored when unsealing, so it can be
+// shared with other uses of the same keys.
+//
+// SealedString and SealedBytes work with any library built on
+// database/sql, including sqlx, as field types:
+//
+//	type User struct {
+//		ID    int64
+//		Email sqltoken.SealedString `db:"email"`
+//	}
+//
+// GORM users can instead keep plain string and []byte fields and tag them
+// `gorm:"serializer:securetoken"` when building with the gorm tag (see Serializer).
+//
+// A Rewrapper re-seals a column with a new key while the database is in use.
+package sqltoken
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/nicksnyder/go-securetoken/securetoken"
+)
+
+var errNoTokener = errors.New("sqltoken: SetTokener has not been called")
+
+var tokener atomic.Value // holds tokenerBox
+
+type tokenerBox struct {
+	t securetoken.SealUnsealer
+}
+
+// SetTokener sets the Tokener that seals and unseals columns.
+// It should be called before the database is used.
+func SetTokener(t securetoken.SealUnsealer) {
+	tokener.Store(tokenerBox{t})
+}
+
+func getTokener() (securetoken.SealUnsealer, error) {
+	box, _ := tokener.Load().(tokenerBox)
+	if box.t == nil {
+		return nil, errNoTokener
+	}
+	return box.t, nil
+}
+
+// A SealedString is a string that is stored sealed.
+type SealedString string
+
+// Value implements driver.Valuer.
+func (s SealedString) Value() (driver.Value, error) {
+	return seal([]byte(s))
+}
+
+// Scan implements sql.Scanner.
+func (s *SealedString) Scan(src interface{}) error {
+	buf, err := unseal(src)
+	if err != nil {
+		return err
+	}
+	*s = SealedString(buf)
+	return nil
+}
+
+// A SealedBytes is a byte slice that is stored sealed.
+type SealedBytes []byte
+
+// Value implements driver.Valuer. A nil SealedBytes is stored as NULL.
+func (b SealedBytes) Value() (driver.Value, error) {
+	if b == nil {
+		return nil, nil
+	}
+	return seal(b)
+}
+
+// Scan implements sql.Scanner.
+func (b *SealedBytes) Scan(src interface{}) error {
+	if src == nil {
+		*b = nil
+		return nil
+	}
+	buf, err := unseal(src)
+	if err != nil {
+		return err
+	}
+	*b = buf
+	return nil
+}
+
+func seal(plaintext []byte) (driver.Value, error) {
+	t, err := getTokener()
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := t.Seal(plaintext)
+	if err != nil {
+		return nil, err
+	}
+	return string(sealed), nil
+}
+
+func unseal(src interface{}) ([]byte, error) {
+	var sealed []byte
+	switch src := src.(type) {
+	case string:
+		sealed = []byte(src)
+	case []byte:
+		sealed = src
+	default:
+		return nil, fmt.Errorf("sqltoken: cannot scan %T into a sealed column", src)
+	}
+	t, err := getTokener()
+	if err != nil {
+		return nil, err
+	}
+	return t.Unseal(sealed, securetoken.WithIgnoreExpiry())
+}
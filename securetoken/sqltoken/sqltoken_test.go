@@ -0,0 +1,52 @@
+package sqltoken
+
+import (
+	"testing"
+
+	"github.com/nicksnyder/go-securetoken/securetoken"
+	"github.com/nicksnyder/go-securetoken/securetoken/securetokentest"
+)
+
+func TestSealedString(t *testing.T) {
+	tok := securetokentest.NewTokener(t)
+	SetTokener(tok)
+
+	v, err := SealedString("alice@example.com").Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored := v.(string)
+	if stored == "alice@example.com" {
+		t.Fatalf("Value() = %q; expected it to be sealed", stored)
+	}
+	// Columns outlive the ttl of the Tokener.
+	tok.Clock.Advance(2 * securetokentest.TTL)
+	for _, src := range []interface{}{stored, []byte(stored)} {
+		var s SealedString
+		if err := s.Scan(src); s != "alice@example.com" || err != nil {
+			t.Errorf("Scan(%T) = %q, %v; expected alice@example.com, <nil>", src, s, err)
+		}
+	}
+	var s SealedString
+	if err := s.Scan("forged"); err != securetoken.ErrTokenInvalid {
+		t.Errorf("Scan(\"forged\") returned %v; expected %s", err, securetoken.ErrTokenInvalid)
+	}
+}
+
+func TestSealedBytes(t *testing.T) {
+	SetTokener(securetokentest.NewTokener(t))
+	if v, err := SealedBytes(nil).Value(); v != nil || err != nil {
+		t.Errorf("Value() of nil = %v, %v; expected <nil>, <nil>", v, err)
+	}
+	v, err := SealedBytes("secret").Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b SealedBytes
+	if err := b.Scan(v); string(b) != "secret" || err != nil {
+		t.Errorf("Scan() = %q, %v; expected \"secret\", <nil>", b, err)
+	}
+	if err := b.Scan(nil); b != nil || err != nil {
+		t.Errorf("Scan(nil) = %q, %v; expected nil, <nil>", b, err)
+	}
+}
@@ -0,0 +1,38 @@
+package securetoken
+
+import (
+	"fmt"
+	"time"
+)
+
+// WithSoftTTL returns an Option that flags tokens older than d as stale.
+// Stale tokens still unseal until the ttl of the Tokener, the hard expiry,
+// but UnsealClaims sets Claims.Stale and WithStale reports them, so that
+// they can be refreshed or revalidated (e.g. by checking that the user
+// still exists) while a short soft ttl limits how long a token is trusted
+// without a check. A d of 0 disables it.
+func WithSoftTTL(d time.Duration) Option {
+	return func(t *Tokener) error {
+		if d < 0 {
+			return fmt.Errorf("securetoken: invalid soft ttl %s", d)
+		}
+		t.softTTL = d
+		return nil
+	}
+}
+
+// WithStale returns an UnsealOption that sets *stale to whether the token
+// is older than the soft ttl of the Tokener, for callers of Unseal.
+func WithStale(stale *bool) UnsealOption {
+	return func(cfg *unsealConfig) {
+		cfg.stale = stale
+	}
+}
+
+// setStale records whether the token being unsealed is stale.
+func (cfg *unsealConfig) setStale(stale bool) {
+	cfg.isStale = stale
+	if cfg.stale != nil {
+		*cfg.stale = stale
+	}
+}
@@ -0,0 +1,72 @@
+package securetoken
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSoftTTL(t *testing.T) {
+	setNow(time.Unix(1000, 0))
+	defer restoreNow()
+
+	tok, err := NewTokener(key, ttl, WithSoftTTL(ttl/4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := tok.SealClaims(&Claims{Subject: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		age   time.Duration
+		stale bool
+		err   error
+	}{
+		{0, false, nil},
+		{ttl / 4, false, nil},
+		{ttl/4 + time.Second, true, nil},
+		{ttl, true, nil},
+		{ttl + time.Second, false, ErrTokenExpired},
+	}
+	for _, test := range tests {
+		setNow(time.Unix(1000, 0).Add(test.age))
+		var stale bool
+		if _, err := tok.Unseal(sealed, WithStale(&stale)); stale != test.stale || err != test.err {
+			t.Errorf("Unseal(%q) after %s = stale %t, %v; expected stale %t, %v", sealed, test.age, stale, err, test.stale, test.err)
+		}
+		c, err := tok.UnsealClaims(sealed)
+		if err != test.err || (err == nil && c.Stale != test.stale) {
+			t.Errorf("UnsealClaims(%q) after %s = %+v, %v; expected Stale %t, %v", sealed, test.age, c, err, test.stale, test.err)
+		}
+	}
+
+	if _, err := NewTokener(key, ttl, WithSoftTTL(-time.Second)); err == nil {
+		t.Error("NewTokener(WithSoftTTL(-1s)) returned nil error")
+	}
+}
+
+func TestReconfigureSoftTTL(t *testing.T) {
+	setNow(time.Unix(1000, 0))
+	defer restoreNow()
+
+	tok, err := NewTokener(key, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := tok.Seal([]byte("data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	setNow(time.Unix(1000, 0).Add(ttl / 2))
+	var stale bool
+	if _, err := tok.Unseal(sealed, WithStale(&stale)); stale || err != nil {
+		t.Fatalf("Unseal(%q) = stale %t, %v; expected stale false, <nil>", sealed, stale, err)
+	}
+	if err := tok.Reconfigure(WithSoftTTL(ttl / 4)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tok.Unseal(sealed, WithStale(&stale)); !stale || err != nil {
+		t.Errorf("Unseal(%q) after Reconfigure = stale %t, %v; expected stale true, <nil>", sealed, stale, err)
+	}
+}
@@ -0,0 +1,45 @@
+package securetoken
+
+import (
+	"errors"
+	"time"
+)
+
+// Authentication methods for Claims.AuthMethods, from RFC 8176.
+const (
+	AuthPassword = "pwd"
+	AuthOTP      = "otp"
+	AuthMFA      = "mfa"
+	AuthHardware = "hwk" // e.g. WebAuthn with a security key
+)
+
+// ErrInsufficientStrength is returned by RequireStrength when the subject
+// did not authenticate strongly enough or recently enough.
+var ErrInsufficientStrength = errors.New("securetoken: insufficient authentication strength")
+
+// SetAuth records that the subject authenticated with methods at t.
+func (c *Claims) SetAuth(t time.Time, methods ...string) {
+	c.AuthMethods = methods
+	c.AuthTime = t.Unix()
+}
+
+// RequireStrength returns ErrInsufficientStrength unless the subject
+// authenticated with at least one of methods no more than maxAge before now.
+// A maxAge of 0 accepts authentication of any age,
+// and no methods accepts any method.
+func (c *Claims) RequireStrength(now time.Time, maxAge time.Duration, methods ...string) error {
+	if maxAge > 0 && (c.AuthTime == 0 || now.Sub(time.Unix(c.AuthTime, 0)) > maxAge) {
+		return ErrInsufficientStrength
+	}
+	if len(methods) == 0 {
+		return nil
+	}
+	for _, have := range c.AuthMethods {
+		for _, want := range methods {
+			if have == want {
+				return nil
+			}
+		}
+	}
+	return ErrInsufficientStrength
+}
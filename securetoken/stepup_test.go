@@ -0,0 +1,36 @@
+package securetoken
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRequireStrength(t *testing.T) {
+	now := time.Unix(1000, 0)
+	c := &Claims{}
+	c.SetAuth(now.Add(-5*time.Minute), AuthPassword, AuthMFA)
+
+	tests := []struct {
+		maxAge  time.Duration
+		methods []string
+		ok      bool
+	}{
+		{0, nil, true},
+		{10 * time.Minute, nil, true},
+		{time.Minute, nil, false},
+		{0, []string{AuthMFA}, true},
+		{0, []string{AuthHardware}, false},
+		{0, []string{AuthHardware, AuthMFA}, true},
+		{10 * time.Minute, []string{AuthMFA}, true},
+		{time.Minute, []string{AuthMFA}, false},
+	}
+	for _, test := range tests {
+		err := c.RequireStrength(now, test.maxAge, test.methods...)
+		if (err == nil) != test.ok {
+			t.Errorf("RequireStrength(%s, %v) returned %v; expected ok %t", test.maxAge, test.methods, err, test.ok)
+		}
+	}
+	if err := (&Claims{}).RequireStrength(now, time.Hour); err != ErrInsufficientStrength {
+		t.Errorf("RequireStrength() without auth time returned %v; expected %s", err, ErrInsufficientStrength)
+	}
+}
@@ -0,0 +1,57 @@
+package securetoken
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// subjectClaims are the fields of Claims that UnsealSubject decodes.
+type subjectClaims struct {
+	Subject string        `json:"sub"`
+	TTL     time.Duration `json:"ttl"`
+	Actor   *Actor        `json:"act"`
+	Purpose string        `json:"pur"`
+}
+
+// UnsealSubject unseals a token produced by SealClaims and returns only its
+// Subject, for hot paths that only need to know who the token is about.
+// It makes the same checks as UnsealClaims but decodes just the claims that
+// they need rather than the whole payload, unless the Tokener has a
+// revocation store, a TTLPolicy, a claims schema, an audit hook or
+// WithAfterUnseal hooks, or opts check the audience or context, which
+// need all of the claims.
+func (t *Tokener) UnsealSubject(sealed []byte, opts ...UnsealOption) (string, error) {
+	cfg := newUnsealConfig(opts)
+	if t.revoked != nil || t.ttlPolicy != nil || t.schemas != nil || t.auditHook != nil || len(t.afterUnseal) > 0 || cfg.needsClaims() != nil {
+		c, err := t.UnsealClaims(sealed, opts...)
+		if err != nil {
+			return "", err
+		}
+		return c.Subject, nil
+	}
+	subject, err := t.unsealSubject(sealed, cfg)
+	t.logUnseal("", len(sealed), nil, err)
+	t.reportUnseal(nil, cfg, len(sealed), err)
+	return subject, err
+}
+
+func (t *Tokener) unsealSubject(sealed []byte, cfg *unsealConfig) (string, error) {
+	cfg.claimsTTL = t.embedTTL
+	cfg.claims = true
+	payload, raw, err := t.unsealFor("", sealed, cfg)
+	if err != nil {
+		return "", err
+	}
+	var sc subjectClaims
+	if err := json.Unmarshal(payload, &sc); err != nil {
+		return "", ErrTokenInvalid
+	}
+	c := &Claims{Subject: sc.Subject, TTL: sc.TTL, Actor: sc.Actor, Purpose: sc.Purpose, IssuedAt: raw.Timestamp}
+	if err := t.checkBreakGlass(c); err != nil {
+		return "", err
+	}
+	if !cfg.ignoreExpiry && (t.claimsExpired(c) || c.delegationExpired(t.now())) {
+		return "", ErrTokenExpired
+	}
+	return c.Subject, nil
+}
@@ -0,0 +1,82 @@
+package securetoken
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestUnsealSubject(t *testing.T) {
+	setNow(time.Unix(1000, 0))
+	defer restoreNow()
+
+	tok, err := NewTokener(key, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := tok.SealClaims(&Claims{Subject: "alice", Scopes: []string{"a", "b"}, Data: json.RawMessage(`{"x":[1,2,3]}`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	short, err := tok.SealClaims(&Claims{Subject: "bob", TTL: ttl / 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subject, err := tok.UnsealSubject(sealed); subject != "alice" || err != nil {
+		t.Errorf("UnsealSubject(%q) = %q, %v; expected %q, <nil>", sealed, subject, err, "alice")
+	}
+
+	setNow(time.Unix(1000, 0).Add(ttl / 2).Add(time.Second))
+	if subject, err := tok.UnsealSubject(short); subject != "" || err != ErrTokenExpired {
+		t.Errorf("UnsealSubject(%q) = %q, %v; expected \"\", %s", short, subject, err, ErrTokenExpired)
+	}
+	if subject, err := tok.UnsealSubject(short, WithIgnoreExpiry()); subject != "bob" || err != nil {
+		t.Errorf("UnsealSubject(%q, WithIgnoreExpiry()) = %q, %v; expected %q, <nil>", short, subject, err, "bob")
+	}
+	if _, err := tok.UnsealSubject([]byte("garbage")); err != ErrTokenInvalid {
+		t.Errorf("UnsealSubject(garbage) returned %v; expected %s", err, ErrTokenInvalid)
+	}
+
+	if _, err := tok.UnsealSubject(sealed, WithAudience("api")); err != ErrWrongAudience {
+		t.Errorf("UnsealSubject(%q, WithAudience(\"api\")) returned %v; expected %s", sealed, err, ErrWrongAudience)
+	}
+}
+
+func TestUnsealSubjectRevoked(t *testing.T) {
+	store := NewMemoryRevocationStore()
+	tok, err := NewTokener(key, ttl, WithRevocationStore(store))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := tok.SealClaims(&Claims{ID: "1", Subject: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Revoke("1", time.Now().Add(ttl)); err != nil {
+		t.Fatal(err)
+	}
+	if subject, err := tok.UnsealSubject(sealed); subject != "" || err != ErrTokenRevoked {
+		t.Errorf("UnsealSubject(%q) = %q, %v; expected \"\", %s", sealed, subject, err, ErrTokenRevoked)
+	}
+}
+
+func BenchmarkUnsealSubject(b *testing.B) {
+	tok, err := NewTokener(key, ttl)
+	if err != nil {
+		b.Fatal(err)
+	}
+	sealed, err := tok.SealClaims(&Claims{Subject: "alice", Scopes: []string{"a", "b"}, Data: json.RawMessage(`{"x":[1,2,3]}`)})
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Run("UnsealClaims", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			tok.UnsealClaims(sealed)
+		}
+	})
+	b.Run("UnsealSubject", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			tok.UnsealSubject(sealed)
+		}
+	})
+}
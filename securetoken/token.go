@@ -0,0 +1,65 @@
+package securetoken
+
+import (
+	"database/sql/driver"
+	"fmt"
+)
+
+// A Token is a sealed token, as returned by Seal.
+//
+// Its String method is redacted so that tokens are not leaked by logging,
+// but it marshals to and from text (and so JSON) and database columns
+// as the token itself.
+type Token []byte
+
+// String returns a redacted description of tok.
+func (tok Token) String() string {
+	if len(tok) == 0 {
+		return "securetoken.Token(empty)"
+	}
+	return "securetoken.Token(redacted)"
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (tok Token) MarshalText() ([]byte, error) {
+	return []byte(tok), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (tok *Token) UnmarshalText(text []byte) error {
+	*tok = append((*tok)[:0], text...)
+	return nil
+}
+
+// Value implements driver.Valuer. An empty token is stored as NULL.
+func (tok Token) Value() (driver.Value, error) {
+	if len(tok) == 0 {
+		return nil, nil
+	}
+	return string(tok), nil
+}
+
+// Scan implements sql.Scanner.
+func (tok *Token) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case nil:
+		*tok = nil
+	case string:
+		*tok = Token(src)
+	case []byte:
+		*tok = append(Token(nil), src...)
+	default:
+		return fmt.Errorf("securetoken: cannot scan %T into Token", src)
+	}
+	return nil
+}
+
+// SealToken is similar to Seal except it returns a Token.
+func (t *Tokener) SealToken(plaintext []byte) (Token, error) {
+	return t.Seal(plaintext)
+}
+
+// UnsealToken is similar to Unseal except its input is a Token.
+func (t *Tokener) UnsealToken(tok Token, opts ...UnsealOption) ([]byte, error) {
+	return t.Unseal(tok, opts...)
+}
@@ -0,0 +1,60 @@
+package securetoken
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestTokenRedacted(t *testing.T) {
+	tok := Token("secret-token")
+	for _, format := range []string{"%s", "%v", "%+v", "%q", "%x"} {
+		if s := fmt.Sprintf(format, tok); strings.Contains(s, "secret") || strings.Contains(s, fmt.Sprintf("%x", "secret")) {
+			t.Errorf("Sprintf(%q, tok) = %q; expected it to be redacted", format, s)
+		}
+	}
+}
+
+func TestTokenJSON(t *testing.T) {
+	tokener, err := NewTokener(key, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tok, err := tokener.SealToken([]byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf, err := json.Marshal(struct{ Token Token }{tok})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v struct{ Token Token }
+	if err := json.Unmarshal(buf, &v); err != nil {
+		t.Fatal(err)
+	}
+	if plaintext, err := tokener.UnsealToken(v.Token); string(plaintext) != "hello" || err != nil {
+		t.Errorf("UnsealToken() after JSON round trip = %q, %v; expected \"hello\", <nil>", plaintext, err)
+	}
+}
+
+func TestTokenSQL(t *testing.T) {
+	tok := Token("abc")
+	v, err := tok.Value()
+	if v != "abc" || err != nil {
+		t.Errorf("Value() = %v, %v; expected \"abc\", <nil>", v, err)
+	}
+	if v, _ := Token(nil).Value(); v != nil {
+		t.Errorf("Value() of empty token = %v; expected <nil>", v)
+	}
+	for _, src := range []interface{}{"abc", []byte("abc")} {
+		var got Token
+		if err := got.Scan(src); string(got) != "abc" || err != nil {
+			t.Errorf("Scan(%#v) = %q, %v; expected \"abc\", <nil>", src, []byte(got), err)
+		}
+	}
+	var got Token
+	if err := got.Scan(42); err == nil {
+		t.Error("Scan(42) returned no error")
+	}
+}
@@ -0,0 +1,42 @@
+package securetoken
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// WithTimestampTruncation returns an Option that hides when tokens were
+// sealed to within d, e.g. 10 minutes, so that the timestamp in a token
+// does not reveal precisely when its user logged in.
+//
+// The timestamp is truncated to a multiple of d and the remainder is
+// filled at random rather than left as zero, which would make nonces
+// repeat. Tokens therefore expire up to d earlier or later than their ttl,
+// and d must be shorter than the ttl.
+func WithTimestampTruncation(d time.Duration) Option {
+	return func(t *Tokener) error {
+		if d <= 0 || d >= t.ttl {
+			return fmt.Errorf("securetoken: timestamp truncation %s is not between 0 and the ttl %s", d, t.ttl)
+		}
+		t.truncate = d
+		return nil
+	}
+}
+
+// timestamp returns the time to put in a token sealed at now.
+func (t *Tokener) timestamp(now time.Time) (time.Time, error) {
+	if t.truncate <= 0 {
+		return now, nil
+	}
+	r := t.random
+	if r == nil {
+		r = rand.Reader
+	}
+	jitter, err := rand.Int(r, big.NewInt(int64(t.truncate)))
+	if err != nil {
+		return time.Time{}, err
+	}
+	return now.Truncate(t.truncate).Add(time.Duration(jitter.Int64())), nil
+}
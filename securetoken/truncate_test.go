@@ -0,0 +1,42 @@
+package securetoken
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimestampTruncation(t *testing.T) {
+	now := time.Unix(1000, 0).Add(7 * time.Second)
+	setNow(now)
+	defer restoreNow()
+
+	const d = 10 * time.Second
+	tok, err := NewTokener(key, ttl, WithTimestampTruncation(d))
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := map[time.Time]bool{}
+	for i := 0; i < 10; i++ {
+		sealed, err := tok.SealClaims(&Claims{Subject: "alice"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		c, err := tok.UnsealClaims(sealed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.IssuedAt.Before(time.Unix(1000, 0)) || !c.IssuedAt.Before(time.Unix(1000, 0).Add(d)) {
+			t.Errorf("IssuedAt = %s; expected within %s of %s", c.IssuedAt, d, time.Unix(1000, 0))
+		}
+		seen[c.IssuedAt] = true
+	}
+	if len(seen) < 2 {
+		t.Errorf("10 tokens had %d distinct timestamps; expected the remainder to be random", len(seen))
+	}
+
+	for _, d := range []time.Duration{0, -time.Second, ttl} {
+		if _, err := NewTokener(key, ttl, WithTimestampTruncation(d)); err == nil {
+			t.Errorf("NewTokener(WithTimestampTruncation(%s)) returned nil error", d)
+		}
+	}
+}
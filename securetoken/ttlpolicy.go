@@ -0,0 +1,143 @@
+package securetoken
+
+import (
+	"sync"
+	"time"
+)
+
+// A TTLPolicy decides when tokens unsealed by UnsealClaims expire, for
+// session rules that a fixed ttl cannot express. The ttl of the Tokener
+// (and the TTL of the claims) still apply, so a policy can only expire
+// tokens earlier: the Tokener ttl should be the longest that any token
+// may live.
+type TTLPolicy interface {
+	// Expiry returns when the token with claims c expires.
+	// c has IssuedAt set, and now is the time that it is being unsealed.
+	Expiry(c *Claims, now time.Time) time.Time
+}
+
+// TTLPolicyFunc is an adapter to allow the use of ordinary functions as TTLPolicies.
+type TTLPolicyFunc func(c *Claims, now time.Time) time.Time
+
+// Expiry returns f(c, now).
+func (f TTLPolicyFunc) Expiry(c *Claims, now time.Time) time.Time {
+	return f(c, now)
+}
+
+// WithTTLPolicy returns an Option that makes UnsealClaims return
+// ErrTokenExpired for tokens that p says have expired.
+func WithTTLPolicy(p TTLPolicy) Option {
+	return func(t *Tokener) error {
+		t.ttlPolicy = p
+		return nil
+	}
+}
+
+// policyExpired reports whether c has expired according to the TTLPolicy of t.
+func (t *Tokener) policyExpired(c *Claims) bool {
+	if t.ttlPolicy == nil {
+		return false
+	}
+	now := t.now()
+	return now.After(t.ttlPolicy.Expiry(c, now).Add(t.policy().leeway))
+}
+
+// FixedTTL returns a TTLPolicy that expires tokens d after they were sealed.
+func FixedTTL(d time.Duration) TTLPolicy {
+	return TTLPolicyFunc(func(c *Claims, now time.Time) time.Time {
+		return c.IssuedAt.Add(d)
+	})
+}
+
+// PerSubjectTTL returns a TTLPolicy that applies the policy returned by f
+// for the subject of each token, e.g. a shorter one for administrators.
+func PerSubjectTTL(f func(subject string) TTLPolicy) TTLPolicy {
+	return TTLPolicyFunc(func(c *Claims, now time.Time) time.Time {
+		return f(c.Subject).Expiry(c, now)
+	})
+}
+
+// BusinessHoursTTL is a TTLPolicy for tokens that should not outlive the
+// working day: tokens sealed during business hours expire at the end of
+// them, and tokens sealed at other times expire after OffHours.
+type BusinessHoursTTL struct {
+	// Location is the time zone of the business hours. nil means UTC.
+	Location *time.Location
+
+	// Start and End are the business hours, as offsets from midnight.
+	Start, End time.Duration
+
+	// Weekend, if true, makes Saturday and Sunday outside business hours.
+	Weekend bool
+
+	// OffHours is the ttl of tokens sealed outside business hours.
+	OffHours time.Duration
+}
+
+// Expiry implements TTLPolicy.
+func (p *BusinessHoursTTL) Expiry(c *Claims, now time.Time) time.Time {
+	loc := p.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	issued := c.IssuedAt.In(loc)
+	y, m, d := issued.Date()
+	midnight := time.Date(y, m, d, 0, 0, 0, 0, loc)
+	weekend := issued.Weekday() == time.Saturday || issued.Weekday() == time.Sunday
+	if (p.Weekend && weekend) || issued.Before(midnight.Add(p.Start)) || !issued.Before(midnight.Add(p.End)) {
+		return c.IssuedAt.Add(p.OffHours)
+	}
+	return midnight.Add(p.End)
+}
+
+// A SlidingTTL is a TTLPolicy that expires tokens once they have not been
+// used for its idle timeout, so that active sessions stay alive. It tracks
+// when each token was last unsealed by its ID in memory, so it only slides
+// within one process; tokens without an ID expire the idle timeout after
+// they were sealed. It is goroutine safe.
+type SlidingTTL struct {
+	idle time.Duration
+
+	mu       sync.Mutex
+	lastUsed map[string]time.Time
+	pruned   time.Time
+}
+
+// NewSlidingTTL returns a SlidingTTL with the given idle timeout.
+func NewSlidingTTL(idle time.Duration) *SlidingTTL {
+	return &SlidingTTL{idle: idle, lastUsed: map[string]time.Time{}}
+}
+
+// Expiry implements TTLPolicy. Unless the token has expired,
+// it records that the token was used at now.
+func (s *SlidingTTL) Expiry(c *Claims, now time.Time) time.Time {
+	if c.ID == "" {
+		return c.IssuedAt.Add(s.idle)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prune(now)
+	last, ok := s.lastUsed[c.ID]
+	if !ok || last.Before(c.IssuedAt) {
+		last = c.IssuedAt
+	}
+	expiry := last.Add(s.idle)
+	if !now.After(expiry) {
+		s.lastUsed[c.ID] = now
+	}
+	return expiry
+}
+
+// prune forgets tokens that have been idle for too long, at most once per
+// idle timeout. s.mu must be held.
+func (s *SlidingTTL) prune(now time.Time) {
+	if now.Sub(s.pruned) < s.idle {
+		return
+	}
+	s.pruned = now
+	for id, last := range s.lastUsed {
+		if now.Sub(last) > s.idle {
+			delete(s.lastUsed, id)
+		}
+	}
+}
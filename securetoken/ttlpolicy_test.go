@@ -0,0 +1,92 @@
+package securetoken
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTTLPolicy(t *testing.T) {
+	setNow(time.Unix(1000, 0))
+	defer restoreNow()
+
+	tok, err := NewTokener(key, time.Hour, WithTTLPolicy(PerSubjectTTL(func(subject string) TTLPolicy {
+		if subject == "admin" {
+			return FixedTTL(time.Minute)
+		}
+		return FixedTTL(time.Hour)
+	})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	admin, err := tok.SealClaims(&Claims{Subject: "admin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	user, err := tok.SealClaims(&Claims{Subject: "user"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	setNow(time.Unix(1000, 0).Add(2 * time.Minute))
+	if _, err := tok.UnsealClaims(admin); err != ErrTokenExpired {
+		t.Errorf("UnsealClaims(admin) returned %v; expected %s", err, ErrTokenExpired)
+	}
+	if _, err := tok.UnsealSubject(admin); err != ErrTokenExpired {
+		t.Errorf("UnsealSubject(admin) returned %v; expected %s", err, ErrTokenExpired)
+	}
+	if _, err := tok.UnsealClaims(admin, WithIgnoreExpiry()); err != nil {
+		t.Errorf("UnsealClaims(admin, WithIgnoreExpiry()) returned %v", err)
+	}
+	if _, err := tok.UnsealClaims(user); err != nil {
+		t.Errorf("UnsealClaims(user) returned %v", err)
+	}
+
+	// The Tokener ttl still applies.
+	setNow(time.Unix(1000, 0).Add(time.Hour + time.Second))
+	if _, err := tok.UnsealClaims(user); err != ErrTokenExpired {
+		t.Errorf("UnsealClaims(user) after the Tokener ttl returned %v; expected %s", err, ErrTokenExpired)
+	}
+}
+
+func TestBusinessHoursTTL(t *testing.T) {
+	p := &BusinessHoursTTL{Start: 9 * time.Hour, End: 17 * time.Hour, Weekend: true, OffHours: time.Hour}
+	monday := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		issued time.Time
+		expiry time.Time
+	}{
+		{monday.Add(10 * time.Hour), monday.Add(17 * time.Hour)},
+		{monday.Add(9 * time.Hour), monday.Add(17 * time.Hour)},
+		{monday.Add(8 * time.Hour), monday.Add(9 * time.Hour)},
+		{monday.Add(17 * time.Hour), monday.Add(18 * time.Hour)},
+		{monday.Add(-14 * time.Hour), monday.Add(-13 * time.Hour)}, // Sunday 10:00
+	}
+	for _, test := range tests {
+		if expiry := p.Expiry(&Claims{IssuedAt: test.issued}, test.issued); !expiry.Equal(test.expiry) {
+			t.Errorf("Expiry(issued %s) = %s; expected %s", test.issued, expiry, test.expiry)
+		}
+	}
+}
+
+func TestSlidingTTL(t *testing.T) {
+	setNow(time.Unix(1000, 0))
+	defer restoreNow()
+
+	tok, err := NewTokener(key, time.Hour, WithTTLPolicy(NewSlidingTTL(10*time.Minute)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := tok.SealClaims(&Claims{ID: "1", Subject: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= 5; i++ {
+		setNow(time.Unix(1000, 0).Add(time.Duration(i) * 9 * time.Minute))
+		if _, err := tok.UnsealClaims(sealed); err != nil {
+			t.Fatalf("UnsealClaims() after %d uses 9 minutes apart returned %v", i, err)
+		}
+	}
+	setNow(time.Unix(1000, 0).Add(45*time.Minute + 11*time.Minute))
+	if _, err := tok.UnsealClaims(sealed); err != ErrTokenExpired {
+		t.Errorf("UnsealClaims() after 11 idle minutes returned %v; expected %s", err, ErrTokenExpired)
+	}
+}
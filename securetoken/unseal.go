@@ -0,0 +1,99 @@
+package securetoken
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrWrongAudience is returned by UnsealClaims when WithAudience is given
+// and the token was sealed for a different audience.
+var ErrWrongAudience = errors.New("securetoken: token not intended for this audience")
+
+var errAudienceNeedsClaims = errors.New("securetoken: WithAudience requires UnsealClaims")
+
+// An UnsealOption adjusts the checks made by a single call to Unseal
+// or UnsealClaims.
+type UnsealOption func(*unsealConfig)
+
+type unsealConfig struct {
+	maxAge       time.Duration
+	aad          []byte
+	audience     string
+	hasAudience  bool
+	ignoreExpiry bool
+	info         RequestInfo
+	stale        *bool
+	isStale      bool
+	claimsTTL    bool // the ttl is checked by unsealClaims (see WithEmbeddedTTL)
+	context      map[string]string
+	payloadLen   int  // set by unseal for the metrics hook
+	claims       bool // the payload is checked as claims
+
+	dst []byte // the buffer that AppendUnseal appends to
+}
+
+func newUnsealConfig(opts []UnsealOption) *unsealConfig {
+	cfg := &unsealConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// WithMaxAge returns an UnsealOption that rejects tokens sealed more than
+// d ago with ErrTokenExpired, in addition to the ttl of the Tokener.
+func WithMaxAge(d time.Duration) UnsealOption {
+	return func(cfg *unsealConfig) {
+		cfg.maxAge = d
+	}
+}
+
+// WithAAD returns an UnsealOption that unseals tokens sealed by SealAAD with aad.
+// Tokens sealed with different additional data are invalid.
+func WithAAD(aad []byte) UnsealOption {
+	return func(cfg *unsealConfig) {
+		cfg.aad = aad
+	}
+}
+
+// WithAudience returns an UnsealOption that makes UnsealClaims return
+// ErrWrongAudience unless the Audience of the claims is audience.
+// Unseal has no claims to check, so it rejects every token when given this option.
+func WithAudience(audience string) UnsealOption {
+	return func(cfg *unsealConfig) {
+		cfg.audience = audience
+		cfg.hasAudience = true
+	}
+}
+
+// WithIgnoreExpiry returns an UnsealOption that accepts tokens older than
+// the ttl of the Tokener (or the TTL of their claims), e.g. to read the
+// subject of an expired session when asking the user to log in again.
+// WithMaxAge still applies.
+func WithIgnoreExpiry() UnsealOption {
+	return func(cfg *unsealConfig) {
+		cfg.ignoreExpiry = true
+	}
+}
+
+// needsClaims returns an error if cfg has options that only UnsealClaims supports.
+func (cfg *unsealConfig) needsClaims() error {
+	if cfg.hasAudience {
+		return errAudienceNeedsClaims
+	}
+	if cfg.context != nil {
+		return errContextNeedsClaims
+	}
+	return nil
+}
+
+// checkAge returns ErrTokenExpired if a token sealed at ts is too old at now.
+func (cfg *unsealConfig) checkAge(now, ts time.Time, ttl time.Duration) error {
+	if !cfg.ignoreExpiry && !cfg.claimsTTL && now.Add(-ttl).After(ts) {
+		return ErrTokenExpired
+	}
+	if cfg.maxAge > 0 && now.Add(-cfg.maxAge).After(ts) {
+		return ErrTokenExpired
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package securetoken
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUnsealOptions(t *testing.T) {
+	now := time.Unix(1000, 0)
+	setNow(now)
+	defer restoreNow()
+	tok, err := NewTokener(key, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := tok.Seal([]byte("x"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bound, err := tok.SealAAD([]byte("x"), []byte("order-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	setNow(now.Add(ttl / 2))
+
+	tests := []struct {
+		sealed []byte
+		opts   []UnsealOption
+		err    error
+	}{
+		{sealed, nil, nil},
+		{sealed, []UnsealOption{WithMaxAge(ttl / 4)}, ErrTokenExpired},
+		{sealed, []UnsealOption{WithMaxAge(ttl)}, nil},
+		{sealed, []UnsealOption{WithAAD([]byte("order-1"))}, ErrTokenInvalid},
+		{bound, nil, ErrTokenInvalid},
+		{bound, []UnsealOption{WithAAD([]byte("order-2"))}, ErrTokenInvalid},
+		{bound, []UnsealOption{WithAAD([]byte("order-1"))}, nil},
+		{sealed, []UnsealOption{WithAudience("api")}, errAudienceNeedsClaims},
+	}
+	for i, test := range tests {
+		if _, err := tok.Unseal(test.sealed, test.opts...); err != test.err {
+			t.Errorf("%d: Unseal(%q) returned %v; expected %v", i, test.sealed, err, test.err)
+		}
+	}
+
+	setNow(now.Add(2 * ttl))
+	if _, err := tok.Unseal(sealed); err != ErrTokenExpired {
+		t.Errorf("Unseal(%q) returned %v; expected %s", sealed, err, ErrTokenExpired)
+	}
+	if _, err := tok.Unseal(sealed, WithIgnoreExpiry()); err != nil {
+		t.Errorf("Unseal(%q, WithIgnoreExpiry()) returned %v; expected <nil>", sealed, err)
+	}
+	if _, err := tok.Unseal(sealed, WithIgnoreExpiry(), WithMaxAge(ttl)); err != ErrTokenExpired {
+		t.Errorf("Unseal(%q, WithIgnoreExpiry(), WithMaxAge(ttl)) returned %v; expected %s", sealed, err, ErrTokenExpired)
+	}
+}
+
+func TestUnsealClaimsWithAudience(t *testing.T) {
+	tok, err := NewTokener(key, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := tok.SealClaims(&Claims{Subject: "alice", Audience: "billing"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c, err := tok.UnsealClaims(sealed, WithAudience("billing")); err != nil || c.Subject != "alice" {
+		t.Errorf("UnsealClaims(%q, WithAudience(billing)) = %+v, %v; expected alice, <nil>", sealed, c, err)
+	}
+	if c, err := tok.UnsealClaims(sealed, WithAudience("admin")); err != ErrWrongAudience {
+		t.Errorf("UnsealClaims(%q, WithAudience(admin)) = %+v, %v; expected %s", sealed, c, err, ErrWrongAudience)
+	}
+}